@@ -0,0 +1,499 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/volts-dev/utils"
+)
+
+// 聚合函数的空值语义(与 SQL 一致):
+//   - nil 与空白字符串视为 NULL,所有聚合均忽略 NULL;0 和 false 不是 NULL
+//   - 数值聚合(Sum/Avg/Min/Max/Median/Percentile/StdDev)接受 int/uint/float 各类型、
+//     json.Number 以及可解析为数字的字符串/[]byte(如数据库驱动返回的 "12.50"),
+//     无法转换为数字的值同样按 NULL 忽略
+//   - Sum 在没有任何非空值时返回 0;其余返回 (值, ok),ok 为 false 表示结果为 NULL
+//   - CountField(field)/CountDistinct(field) 统计非空值(任意类型),Count() 返回记录数
+//   - 设置了过滤条件(见 filter.go)时只聚合满足条件的记录,与 Count 一致
+//   - 列中含 TDecimal 或数值字符串时 Sum/Avg 以定点数精确累加,仅最终结果转为 float64;
+//     需要精确结果时使用 SumDecimal/AvgDecimal/MinDecimal/MaxDecimal
+
+// Sum 字段所有非空数值之和,无值时返回 0
+func (self *TDataSet) Sum(field string) float64 {
+	if nums, ok := self.columnNumbers(field); ok {
+		var sum float64
+		for _, n := range nums {
+			sum += n
+		}
+		return sum
+	}
+	return sumOf(self.columnValues(field))
+}
+
+// Avg 字段非空数值的算术平均值
+func (self *TDataSet) Avg(field string) (float64, bool) {
+	if nums, ok := self.columnNumbers(field); ok {
+		if len(nums) == 0 {
+			return 0, false
+		}
+		var sum float64
+		for _, n := range nums {
+			sum += n
+		}
+		return sum / float64(len(nums)), true
+	}
+	return avgOf(self.columnValues(field))
+}
+
+// Min 字段非空数值中的最小值
+func (self *TDataSet) Min(field string) (float64, bool) {
+	if nums, ok := self.columnNumbers(field); ok {
+		if len(nums) == 0 {
+			return 0, false
+		}
+		return slices.Min(nums), true
+	}
+	return minOf(self.columnValues(field))
+}
+
+// Max 字段非空数值中的最大值
+func (self *TDataSet) Max(field string) (float64, bool) {
+	if nums, ok := self.columnNumbers(field); ok {
+		if len(nums) == 0 {
+			return 0, false
+		}
+		return slices.Max(nums), true
+	}
+	return maxOf(self.columnValues(field))
+}
+
+// SumDecimal 字段所有非空数值的精确和,无值时返回 0
+func (self *TDataSet) SumDecimal(field string) TDecimal {
+	return sumDecimalOf(self.columnValues(field))
+}
+
+// AvgDecimal 字段非空数值的精确平均值,除法精度见 DecimalDivisionPrecision
+func (self *TDataSet) AvgDecimal(field string) (TDecimal, bool) {
+	return avgDecimalOf(self.columnValues(field))
+}
+
+func (self *TDataSet) MinDecimal(field string) (TDecimal, bool) {
+	return extremeDecimalOf(self.columnValues(field), -1)
+}
+
+func (self *TDataSet) MaxDecimal(field string) (TDecimal, bool) {
+	return extremeDecimalOf(self.columnValues(field), 1)
+}
+
+// CountField 字段非空值的个数(同 SQL COUNT(field))。
+// 未命名为 Count(field):Count() 已用于返回记录数,保留其含义以兼容现有调用
+func (self *TDataSet) CountField(field string) int {
+	return countOf(self.columnValues(field))
+}
+
+// CountDistinct 字段非空值去重后的个数,数值按数值比较(1 与 int64(1) 视为相同)
+func (self *TDataSet) CountDistinct(field string) int {
+	return countDistinctOf(self.columnValues(field))
+}
+
+// Median 字段非空数值的中位数,等同 Percentile(field, 0.5)
+func (self *TDataSet) Median(field string) (float64, bool) {
+	return percentileOf(self.columnValues(field), 0.5)
+}
+
+// Percentile 字段非空数值的 p 分位数(0 <= p <= 1),
+// 采用线性插值(同 PostgreSQL percentile_cont)
+func (self *TDataSet) Percentile(field string, p float64) (float64, bool) {
+	return percentileOf(self.columnValues(field), p)
+}
+
+// StdDev 字段非空数值的样本标准差(同 SQL stddev_samp),少于 2 个值时为 NULL
+func (self *TDataSet) StdDev(field string) (float64, bool) {
+	return stdDevOf(self.columnValues(field))
+}
+
+// columnValues 取字段所有满足过滤条件的记录的原始值(含 nil),支持计算字段
+func (self *TDataSet) columnValues(field string) []any {
+	if self == nil {
+		return nil
+	}
+
+	values := make([]any, 0, len(self.Data))
+	self.Range(func(_ int, rec *TRecordSet) error {
+		values = append(values, rec.GetByField(field))
+		return nil
+	})
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// isNull 判断值是否为聚合意义上的 NULL
+func isNull(v any) bool {
+	switch s := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(s) == ""
+	case []byte:
+		return strings.TrimSpace(string(s)) == ""
+	}
+	return false
+}
+
+// isBlank 同 utils.IsBlank(零值、空字符串、空 map/slice 为空),
+// 其不支持的结构体等类型(如 TDecimal,utils.IsBlank 会 panic)视为非空
+func isBlank(v any) bool {
+	switch v.(type) {
+	case time.Time, *time.Time:
+		return utils.IsBlank(v)
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Struct, reflect.Pointer, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	}
+	return utils.IsBlank(v)
+}
+
+// toFloat 将数值或数值字符串转为 float64
+func toFloat(v any) (float64, bool) {
+	if n, ok := toNumber(v); ok {
+		return n, true
+	}
+
+	var s string
+	switch x := v.(type) {
+	case TDecimal:
+		return x.Float64(), true
+	case string:
+		s = x
+	case []byte:
+		s = string(x)
+	case json.Number:
+		s = string(x)
+	default:
+		return 0, false
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// numbers 过滤出非空数值
+func numbers(values []any) []float64 {
+	res := make([]float64, 0, len(values))
+	for _, v := range values {
+		if isNull(v) {
+			continue
+		}
+		if n, ok := toFloat(v); ok {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+func countOf(values []any) int {
+	cnt := 0
+	for _, v := range values {
+		if !isNull(v) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// needExact 列中是否含有需要精确累加的值(定点数或十进制字符串)
+func needExact(values []any) bool {
+	for _, v := range values {
+		switch v.(type) {
+		case TDecimal, string, []byte, json.Number:
+			return true
+		}
+	}
+	return false
+}
+
+// hasDecimal 列中是否含有 TDecimal,含有时求和结果保持为 TDecimal
+func hasDecimal(values []any) bool {
+	for _, v := range values {
+		if _, ok := v.(TDecimal); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// decimals 过滤出非空数值并转为定点数
+func decimals(values []any) []TDecimal {
+	res := make([]TDecimal, 0, len(values))
+	for _, v := range values {
+		if isNull(v) {
+			continue
+		}
+		if d, err := NewDecimal(v); err == nil {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+func sumOf(values []any) float64 {
+	if needExact(values) {
+		return sumDecimalOf(values).Float64()
+	}
+
+	var sum float64
+	for _, n := range numbers(values) {
+		sum += n
+	}
+	return sum
+}
+
+func avgOf(values []any) (float64, bool) {
+	if needExact(values) {
+		avg, ok := avgDecimalOf(values)
+		return avg.Float64(), ok
+	}
+
+	nums := numbers(values)
+	if len(nums) == 0 {
+		return 0, false
+	}
+
+	var sum float64
+	for _, n := range nums {
+		sum += n
+	}
+	return sum / float64(len(nums)), true
+}
+
+func sumDecimalOf(values []any) TDecimal {
+	var sum TDecimal
+	for _, d := range decimals(values) {
+		sum = sum.Add(d)
+	}
+	return sum
+}
+
+func avgDecimalOf(values []any) (TDecimal, bool) {
+	decs := decimals(values)
+	if len(decs) == 0 {
+		return TDecimal{}, false
+	}
+
+	var sum TDecimal
+	for _, d := range decs {
+		sum = sum.Add(d)
+	}
+	return sum.Div(MustDecimal(len(decs))), true
+}
+
+// extremeDecimalOf sign 为 -1 取最小值,1 取最大值
+func extremeDecimalOf(values []any, sign int) (TDecimal, bool) {
+	decs := decimals(values)
+	if len(decs) == 0 {
+		return TDecimal{}, false
+	}
+
+	res := decs[0]
+	for _, d := range decs[1:] {
+		if d.Cmp(res) == sign {
+			res = d
+		}
+	}
+	return res, true
+}
+
+func minOf(values []any) (float64, bool) {
+	nums := numbers(values)
+	if len(nums) == 0 {
+		return 0, false
+	}
+	return slices.Min(nums), true
+}
+
+func maxOf(values []any) (float64, bool) {
+	nums := numbers(values)
+	if len(nums) == 0 {
+		return 0, false
+	}
+	return slices.Max(nums), true
+}
+
+func countDistinctOf(values []any) int {
+	seen := make(map[any]struct{})
+	for _, v := range values {
+		if isNull(v) {
+			continue
+		}
+		seen[distinctKey(v)] = struct{}{}
+	}
+	return len(seen)
+}
+
+// distinctKey 生成去重用的键:整数及整值浮点数统一为 int64 键(大整数不丢精度),
+// 其余浮点数为 float64 键,不可比较的值(map/slice)退化为字符串
+func distinctKey(v any) any {
+	if n, ok := toInteger(v); ok {
+		return n
+	}
+	if n, ok := toNumber(v); ok {
+		if n == math.Trunc(n) && math.Abs(n) < 1<<63 {
+			return int64(n)
+		}
+		return n
+	}
+
+	if d, ok := v.(TDecimal); ok {
+		if r := d.Rat(); r.IsInt() && r.Num().IsInt64() {
+			return r.Num().Int64()
+		} else if f, exact := r.Float64(); exact {
+			return f
+		}
+		return d.Rat().RatString()
+	}
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	if !reflect.TypeOf(v).Comparable() {
+		return fmt.Sprintf("%T:%v", v, v)
+	}
+	return v
+}
+
+func percentileOf(values []any, p float64) (float64, bool) {
+	nums := numbers(values)
+	if len(nums) == 0 || p < 0 || p > 1 || math.IsNaN(p) {
+		return 0, false
+	}
+
+	slices.Sort(nums)
+	pos := p * float64(len(nums)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	if lower == upper {
+		return nums[lower], true
+	}
+	return nums[lower] + (nums[upper]-nums[lower])*(pos-float64(lower)), true
+}
+
+func stdDevOf(values []any) (float64, bool) {
+	nums := numbers(values)
+	if len(nums) < 2 {
+		return 0, false
+	}
+
+	var mean float64
+	for _, n := range nums {
+		mean += n
+	}
+	mean /= float64(len(nums))
+
+	var sq float64
+	for _, n := range nums {
+		sq += (n - mean) * (n - mean)
+	}
+	return math.Sqrt(sq / float64(len(nums)-1)), true
+}
+
+type (
+	// TAggregate 聚合函数,输入一组字段值(可含 NULL),返回聚合结果,结果为 NULL 时返回 nil
+	TAggregate func(values []any) any
+)
+
+var (
+	// AggSum 求和;列中含 TDecimal 时返回精确的 TDecimal,否则返回 float64
+	AggSum TAggregate = func(values []any) any {
+		if hasDecimal(values) {
+			return sumDecimalOf(values)
+		}
+		return sumOf(values)
+	}
+	AggAvg TAggregate = func(values []any) any {
+		return nullable(avgOf(values))
+	}
+	AggMin TAggregate = func(values []any) any {
+		return nullable(minOf(values))
+	}
+	AggMax TAggregate = func(values []any) any {
+		return nullable(maxOf(values))
+	}
+	AggCount TAggregate = func(values []any) any {
+		return countOf(values)
+	}
+	AggCountDistinct TAggregate = func(values []any) any {
+		return countDistinctOf(values)
+	}
+	AggMedian TAggregate = func(values []any) any {
+		return nullable(percentileOf(values, 0.5))
+	}
+	AggStdDev TAggregate = func(values []any) any {
+		return nullable(stdDevOf(values))
+	}
+	// AggFirst 第一个非空值
+	AggFirst TAggregate = func(values []any) any {
+		for _, v := range values {
+			if !isNull(v) {
+				return v
+			}
+		}
+		return nil
+	}
+)
+
+// aggregates 按名称索引的聚合函数,名称同 SQL(小写),见 RegisterAggregate/LookupAggregate
+var aggregates = struct {
+	sync.RWMutex
+	m map[string]TAggregate
+}{m: map[string]TAggregate{
+	"sum":            AggSum,
+	"avg":            AggAvg,
+	"min":            AggMin,
+	"max":            AggMax,
+	"count":          AggCount,
+	"count_distinct": AggCountDistinct,
+	"median":         AggMedian,
+	"stddev":         AggStdDev,
+	"first":          AggFirst,
+}}
+
+// RegisterAggregate 按名称注册聚合函数,同名的被替换,agg 为 nil 时移除。名称不区分大小写。
+func RegisterAggregate(name string, agg TAggregate) {
+	name = strings.ToLower(name)
+	aggregates.Lock()
+	defer aggregates.Unlock()
+	if agg == nil {
+		delete(aggregates.m, name)
+		return
+	}
+	aggregates.m[name] = agg
+}
+
+// LookupAggregate 按名称(不区分大小写)查找聚合函数,如 LookupAggregate("sum")
+func LookupAggregate(name string) (TAggregate, bool) {
+	aggregates.RLock()
+	defer aggregates.RUnlock()
+	agg, has := aggregates.m[strings.ToLower(name)]
+	return agg, has
+}
+
+func nullable(v float64, ok bool) any {
+	if !ok {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,120 @@
+package dataset
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDatasetAggregates(t *testing.T) {
+	ds := newFixture(aggFixture)
+
+	if v := ds.Sum("amount"); v != 20 {
+		t.Errorf("Sum expected 20, got %v", v)
+	}
+	// 0 参与平均,nil/空串/非数值被忽略
+	if v, ok := ds.Avg("amount"); !ok || v != 5 {
+		t.Errorf("Avg expected 5, got %v %v", v, ok)
+	}
+	if v, ok := ds.Min("amount"); !ok || v != 0 {
+		t.Errorf("Min expected 0, got %v %v", v, ok)
+	}
+	if v, ok := ds.Max("amount"); !ok || v != 10 {
+		t.Errorf("Max expected 10, got %v %v", v, ok)
+	}
+	if v := ds.Count(); v != 7 {
+		t.Errorf("Count() expected 7 records, got %v", v)
+	}
+	if v := ds.CountField("amount"); v != 5 {
+		t.Errorf("CountField(amount) expected 5 non-null, got %v", v)
+	}
+	if v, ok := ds.Median("amount"); !ok || v != 5 {
+		t.Errorf("Median expected 5, got %v %v", v, ok)
+	}
+	if v, ok := ds.Percentile("amount", 0.25); !ok || v != 1.875 {
+		t.Errorf("Percentile(0.25) expected 1.875, got %v %v", v, ok)
+	}
+	if v, ok := ds.StdDev("amount"); !ok || math.Abs(v-4.564355) > 1e-6 {
+		t.Errorf("StdDev expected 4.564355, got %v %v", v, ok)
+	}
+
+	// 过滤条件下 Count() 仍返回(满足条件的)记录数,CountField 只计非空值
+	if err := ds.SetFilterExpr("id >= 3"); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Count() != 5 || ds.CountField("amount") != 3 {
+		t.Errorf("under a filter expected Count() 5 and CountField 3, got %v %v", ds.Count(), ds.CountField("amount"))
+	}
+}
+
+func TestDatasetAggregatesNull(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"id": 1, "amount": nil},
+	))
+
+	if v := ds.Sum("amount"); v != 0 {
+		t.Errorf("Sum of nulls expected 0, got %v", v)
+	}
+	if _, ok := ds.Avg("amount"); ok {
+		t.Error("Avg of nulls should be NULL")
+	}
+	if _, ok := ds.Max("missing"); ok {
+		t.Error("Max of unknown field should be NULL")
+	}
+	if _, ok := NewDataSet(WithData(map[string]any{"amount": 1})).StdDev("amount"); ok {
+		t.Error("StdDev of a single value should be NULL")
+	}
+}
+
+func TestDatasetCountDistinct(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"v": 1},
+		map[string]any{"v": int64(1)},
+		map[string]any{"v": 1.0},
+		map[string]any{"v": "a"},
+		map[string]any{"v": nil},
+		map[string]any{"v": []any{1}},
+		map[string]any{"v": int64(1)<<60 + 1},
+		map[string]any{"v": int64(1) << 60},
+	))
+
+	if v := ds.CountDistinct("v"); v != 5 {
+		t.Errorf("CountDistinct expected 5, got %v", v)
+	}
+}
+
+func TestFieldSetAggregates(t *testing.T) {
+	ds := newFixture(aggFixture)
+	ds.First()
+
+	field := ds.FieldByName("amount")
+	if v := field.Sum(); v != 20 {
+		t.Errorf("FieldSet.Sum expected 20, got %v", v)
+	}
+	if v := field.Count(); v != 5 {
+		t.Errorf("FieldSet.Count expected 5, got %v", v)
+	}
+	if v, ok := field.Avg(); !ok || v != 5 {
+		t.Errorf("FieldSet.Avg expected 5, got %v", v)
+	}
+
+	rec := NewRecordSet(map[string]any{"amount": "3"})
+	if v := rec.FieldByName("amount").Sum(); v != 3 {
+		t.Errorf("standalone record Sum expected 3, got %v", v)
+	}
+}
+
+func TestAggregateRegistry(t *testing.T) {
+	if agg, has := LookupAggregate("SUM"); !has || agg(newFixture(aggFixture).ValueBy("amount")) != 20.0 {
+		t.Fatal("expected built-in sum aggregate")
+	}
+
+	RegisterAggregate("Last", func(values []any) any { return values[len(values)-1] })
+	defer RegisterAggregate("last", nil)
+	if agg, has := LookupAggregate("last"); !has || agg([]any{1, 2}) != 2 {
+		t.Fatal("expected registered aggregate")
+	}
+	RegisterAggregate("last", nil)
+	if _, has := LookupAggregate("last"); has {
+		t.Fatal("nil should remove the aggregate")
+	}
+}
@@ -0,0 +1,206 @@
+package dataset
+
+import (
+	"fmt"
+)
+
+type (
+	// TCalcField 计算字段(虚拟列):值由同一记录的其他字段计算得到,不占用 values 存储
+	TCalcField struct {
+		Name string
+		Deps []string // 依赖字段,任一依赖经 SetByField 变更时该记录的缓存失效
+		Func func(*TRecordSet) any
+	}
+)
+
+// AddCalcField 注册计算字段,例如 amount = qty * price:
+//
+//	ds.AddCalcField("amount", []string{"qty", "price"}, func(rec *TRecordSet) any {
+//		return rec.FieldByName("qty").AsFloat() * rec.FieldByName("price").AsFloat()
+//	})
+//
+// 计算字段可通过 GetByField/FieldByName/AsMap 读取,并参与 SortBy/Filter/GroupBy。
+// 计算结果按记录缓存,依赖字段(含依赖的其他计算字段)变更时自动失效。
+// 计算字段为只读,对其 SetByField 会返回 false。
+func (self *TDataSet) AddCalcField(name string, deps []string, fn func(*TRecordSet) any) error {
+	if name == "" || fn == nil {
+		return fmt.Errorf("calc field must have a name and a function")
+	}
+	if self.IsFrozen() {
+		return ErrFrozen
+	}
+
+	if self.parent != nil {
+		err := self.parent.AddCalcField(name, deps, fn)
+		self.syncView()
+		return err
+	}
+
+	self.Lock()
+	defer self.Unlock()
+
+	if _, has := self.fieldsIndex[name]; has {
+		return fmt.Errorf("the field < %s > is already a stored field of this dataset", name)
+	}
+
+	if self.calcFields == nil {
+		self.calcFields = make(map[string]*TCalcField)
+		self.calcDeps = make(map[string][]string)
+	}
+
+	// #检查循环依赖
+	for _, dep := range deps {
+		if dep == name || self.calcReaches(dep, name, make(map[string]bool)) {
+			return fmt.Errorf("calc field < %s > has a circular dependency on < %s >", name, dep)
+		}
+	}
+
+	if old, has := self.calcFields[name]; has {
+		self.removeCalcDeps(old)
+	}
+
+	field := &TCalcField{
+		Name: name,
+		Deps: append([]string(nil), deps...),
+		Func: fn,
+	}
+	self.calcFields[name] = field
+	for _, dep := range field.Deps {
+		self.calcDeps[dep] = append(self.calcDeps[dep], name)
+	}
+
+	// 旧缓存可能由同名旧函数计算得到
+	for _, rec := range self.Data {
+		rec.invalidateCalc(name)
+	}
+
+	return nil
+}
+
+// RemoveCalcField 移除计算字段及其所有缓存
+func (self *TDataSet) RemoveCalcField(name string) {
+	if self.IsFrozen() {
+		return
+	}
+	if self.parent != nil {
+		self.parent.RemoveCalcField(name)
+		self.syncView()
+		return
+	}
+
+	self.Lock()
+	defer self.Unlock()
+
+	field, has := self.calcFields[name]
+	if !has {
+		return
+	}
+
+	self.removeCalcDeps(field)
+	delete(self.calcFields, name)
+	for _, rec := range self.Data {
+		rec.invalidateCalc(name)
+	}
+}
+
+// CalcFields 返回所有计算字段名称
+func (self *TDataSet) CalcFields() []string {
+	if self == nil || len(self.owner().calcFields) == 0 {
+		return nil
+	}
+
+	calcFields := self.owner().calcFields
+	names := make([]string, 0, len(calcFields))
+	for name := range calcFields {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (self *TDataSet) IsCalcField(name string) bool {
+	if self == nil {
+		return false
+	}
+	_, has := self.owner().calcFields[name]
+	return has
+}
+
+func (self *TDataSet) removeCalcDeps(field *TCalcField) {
+	for _, dep := range field.Deps {
+		names := self.calcDeps[dep]
+		for i, n := range names {
+			if n == field.Name {
+				names = append(names[:i], names[i+1:]...)
+				break
+			}
+		}
+		if len(names) == 0 {
+			delete(self.calcDeps, dep)
+		} else {
+			self.calcDeps[dep] = names
+		}
+	}
+}
+
+// calcReaches 判断计算字段 from 是否(间接)依赖 target
+func (self *TDataSet) calcReaches(from, target string, visited map[string]bool) bool {
+	field, has := self.calcFields[from]
+	if !has || visited[from] {
+		return false
+	}
+	visited[from] = true
+
+	for _, dep := range field.Deps {
+		if dep == target || self.calcReaches(dep, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// getCalc 读取计算字段值,未缓存时计算并缓存。
+// 读取会写入缓存,故缓存由所属数据集的 calcMu 保护,使并发只读访问保持安全;
+// 计算函数在锁外执行,以便其中读取其他计算字段。
+func (self *TRecordSet) getCalc(field *TCalcField) any {
+	mu := &self.dataset.calcMu
+	mu.Lock()
+	v, has := self.calcCache[field.Name]
+	mu.Unlock()
+	if has {
+		return v
+	}
+
+	v = field.Func(self)
+	mu.Lock()
+	if self.calcCache == nil {
+		self.calcCache = make(map[string]any)
+	}
+	self.calcCache[field.Name] = v
+	mu.Unlock()
+	return v
+}
+
+// invalidateCalc 清除 field 的缓存及所有(间接)依赖 field 的计算字段缓存
+func (self *TRecordSet) invalidateCalc(field string) {
+	if self.dataset == nil {
+		self.calcCache = nil
+		return
+	}
+
+	self.dataset.calcMu.Lock()
+	self.invalidateCalcLocked(field)
+	self.dataset.calcMu.Unlock()
+}
+
+func (self *TRecordSet) invalidateCalcLocked(field string) {
+	if self.calcCache == nil {
+		return
+	}
+
+	delete(self.calcCache, field)
+	for _, name := range self.dataset.calcDeps[field] {
+		if _, has := self.calcCache[name]; has {
+			self.invalidateCalcLocked(name)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package dataset
+
+import (
+	"testing"
+)
+
+func TestCalcFieldAccess(t *testing.T) {
+	ds := newFixture(calcFixture)
+
+	ds.First()
+	if v := ds.Record().GetByField("amount"); v != 20.0 {
+		t.Fatalf("expected amount 20, got %v", v)
+	}
+	if v := ds.FieldByName("amount").AsFloat(); v != 20.0 {
+		t.Fatalf("FieldByName expected 20, got %v", v)
+	}
+	if !ds.Record().FieldByName("amount").IsValid {
+		t.Fatal("calc field should be valid")
+	}
+	if m := ds.Record().AsMap(); m["amount"] != 20.0 {
+		t.Fatalf("AsMap expected amount 20, got %v", m["amount"])
+	}
+	if !ds.HasField("amount") {
+		t.Fatal("HasField should report calc field")
+	}
+	if ds.Record().SetByField("amount", 1.0) {
+		t.Fatal("calc field should be read only")
+	}
+}
+
+func TestCalcFieldCacheInvalidation(t *testing.T) {
+	ds := newFixture(calcFixture)
+
+	calls := 0
+	ds.AddCalcField("amount_tax", []string{"amount"}, func(rec *TRecordSet) any {
+		calls++
+		return rec.FieldByName("amount").AsFloat() * 1.1
+	})
+
+	rec := ds.Data[0]
+	rec.GetByField("amount_tax")
+	rec.GetByField("amount_tax")
+	if calls != 1 {
+		t.Fatalf("expected calc cached after first read, got %d calls", calls)
+	}
+
+	rec.SetByField("qty", 3)
+	if v := rec.GetByField("amount"); v != 30.0 {
+		t.Fatalf("expected amount 30 after qty change, got %v", v)
+	}
+	rec.GetByField("amount_tax")
+	if calls != 2 {
+		t.Fatalf("expected dependent calc field invalidated transitively, got %d calls", calls)
+	}
+
+	// 非依赖字段变更不影响缓存
+	rec.SetByField("id", 9)
+	rec.GetByField("amount_tax")
+	if calls != 2 {
+		t.Fatalf("unrelated field should keep cache, got %d calls", calls)
+	}
+}
+
+func TestCalcFieldSortAndFilter(t *testing.T) {
+	ds := newFixture(calcFixture)
+
+	ds.SortBy("amount desc")
+	var ids []any
+	ds.Range(func(pos int, rec *TRecordSet) error {
+		ids = append(ids, rec.GetByField("id"))
+		return nil
+	})
+	if ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Fatalf("unexpected order %v", ids)
+	}
+
+	filtered := ds.Filter("amount", []any{7.5})
+	if filtered.Count() != 1 {
+		t.Fatalf("expected 1 record, got %d", filtered.Count())
+	}
+}
+
+func TestCalcFieldCircular(t *testing.T) {
+	ds := NewDataSet()
+	fn := func(rec *TRecordSet) any { return nil }
+	if err := ds.AddCalcField("a", []string{"b"}, fn); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.AddCalcField("b", []string{"a"}, fn); err == nil {
+		t.Fatal("expected circular dependency error")
+	}
+}
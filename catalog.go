@@ -0,0 +1,113 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+type (
+	// TCatalog 按名称登记的数据集集合,可用 SQL 查询其中的数据集,由 NewCatalog 创建。
+	// 登记/移除与查询可被多个 goroutine 同时调用;查询期间被查询的数据集不应被修改
+	// (需要与写入并发时登记快照的 DataSet() 或在 TConcurrentDataSet.View 中查询)。
+	TCatalog struct {
+		mu       sync.RWMutex
+		datasets map[string]*TDataSet
+	}
+)
+
+// NewCatalog 创建目录,datasets 以各自的 Name 登记
+func NewCatalog(datasets ...*TDataSet) *TCatalog {
+	catalog := &TCatalog{datasets: make(map[string]*TDataSet)}
+	for _, ds := range datasets {
+		catalog.Register(ds)
+	}
+	return catalog
+}
+
+// Register 以 ds.Name 登记数据集,同名的数据集被替换。Name 为空时返回错误。
+func (self *TCatalog) Register(ds *TDataSet) error {
+	if ds == nil || ds.Name == "" {
+		return fmt.Errorf("dataset must have a name to be registered")
+	}
+	self.RegisterAs(ds.Name, ds)
+	return nil
+}
+
+// RegisterAs 以 name 登记数据集,不改变 ds.Name
+func (self *TCatalog) RegisterAs(name string, ds *TDataSet) {
+	if name == "" || ds == nil {
+		return
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.datasets[name] = ds
+}
+
+// Unregister 移除登记的数据集
+func (self *TCatalog) Unregister(name string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	delete(self.datasets, name)
+}
+
+// DataSet 按名称(先精确匹配,再不区分大小写)查找数据集,不存在时返回 nil
+func (self *TCatalog) DataSet(name string) *TDataSet {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	ds, _ := lookupTable(self.datasets, name)
+	return ds
+}
+
+// Names 已登记的名称,按字母排序
+func (self *TCatalog) Names() []string {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return slices.Sorted(maps.Keys(self.datasets))
+}
+
+// Query 执行 SELECT 查询并返回结果数据集,例如:
+//
+//	res, err := catalog.Query(ctx, `SELECT partner_id, sum(amount) FROM invoice
+//		WHERE state = 'posted' GROUP BY partner_id ORDER BY 2 DESC LIMIT 10`)
+//
+// 语法同 RegisterTable 的 SELECT,FROM 引用登记的名称;args 对应 ? 或 $n 占位符。
+// 聚合结果列默认以小写函数名命名(如 sum),可用 AS 指定;重名的列加 _2、_3 等后缀。
+// 结果与源数据集无关,可自由修改。ctx 取消时停止执行并返回 ctx.Err()。
+func (self *TCatalog) Query(ctx context.Context, query string, args ...any) (*TDataSet, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	stmt, params, err := parseSQL(query)
+	if err != nil {
+		return nil, err
+	}
+	sel, ok := stmt.(*tSelectStmt)
+	if !ok {
+		return nil, fmt.Errorf("only SELECT statements can be queried")
+	}
+	if len(args) < params {
+		return nil, fmt.Errorf("query expects %d arguments, got %d", params, len(args))
+	}
+
+	var table *TDataSet
+	if sel.table != "" {
+		self.mu.RLock()
+		table, err = lookupTable(self.datasets, sel.table)
+		self.mu.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plan, err := planSelect(sel, table)
+	if err != nil {
+		return nil, err
+	}
+	return plan.run(ctx, args)
+}
@@ -0,0 +1,59 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCatalogQuery(t *testing.T) {
+	invoices := newFixture(sqlInvoiceFixture)
+	invoices.Name = "invoice"
+	catalog := NewCatalog(invoices)
+	if err := catalog.Register(NewDataSet()); err == nil {
+		t.Fatal("registering a dataset without a name should fail")
+	}
+	catalog.RegisterAs("partners", NewDataSet())
+	if fmt.Sprint(catalog.Names()) != "[invoice partners]" || catalog.DataSet("INVOICE") != invoices {
+		t.Fatalf("unexpected catalog %v", catalog.Names())
+	}
+
+	res, err := catalog.Query(context.Background(), "SELECT partner_id, sum(amount) FROM invoice WHERE state = 'posted' GROUP BY partner_id ORDER BY 2 DESC LIMIT 10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(res.Fields()) != "[partner_id sum]" || res.Count() != 3 {
+		t.Fatalf("unexpected result %v with %d rows", res.Fields(), res.Count())
+	}
+	if got := fmt.Sprint(res.Record().AsMap()); got != "map[partner_id:10 sum:400]" {
+		t.Fatalf("unexpected first row %s", got)
+	}
+
+	// 结果独立于源数据集
+	res.Record().SetByField("sum", 0)
+	if invoices.Sum("amount") != 650 {
+		t.Fatal("query result should not share records with the source")
+	}
+
+	res, err = catalog.Query(context.Background(), "SELECT id FROM invoice WHERE amount > ? ORDER BY id", 150)
+	if err != nil || fmt.Sprint(res.ValueBy("id")) != "[3 4]" {
+		t.Fatalf("unexpected parameterized result: %v", err)
+	}
+
+	if _, err := catalog.Query(context.Background(), "SELECT * FROM nope"); !errors.Is(err, ErrUnknownTable) {
+		t.Fatalf("expected ErrUnknownTable, got %v", err)
+	}
+	if _, err := catalog.Query(context.Background(), "DELETE FROM invoice"); err == nil || invoices.Count() != 5 {
+		t.Fatal("catalog queries must be read-only")
+	}
+	if _, err := catalog.Query(context.Background(), "SELECT id FROM invoice WHERE id = ?"); err == nil {
+		t.Fatal("missing arguments should fail")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := catalog.Query(ctx, "SELECT * FROM invoice"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
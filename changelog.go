@@ -0,0 +1,539 @@
+package dataset
+
+import (
+	"errors"
+	"slices"
+)
+
+// DefaultUndoLimit Savepoint 自动启用变更日志时的可撤销步数
+const DefaultUndoLimit = 100
+
+// ErrInvalidSavepoint 保存点不存在或已释放
+var ErrInvalidSavepoint = errors.New("invalid savepoint")
+
+type (
+	// TChange 变更日志中的一项变更
+	TChange struct {
+		Kind     TChangeKind
+		Record   *TRecordSet
+		Index    int    // 插入/删除时记录的位置
+		Field    string // 仅 ChangeUpdate
+		OldValue any
+		NewValue any
+	}
+
+	// TRecordDelta 被更新的记录及其更新过的字段
+	TRecordDelta struct {
+		Record *TRecordSet
+		Fields []string
+	}
+
+	// TDelta 自 AcceptChanges(或启用变更日志)以来的净变更:
+	// 插入后又删除的记录不出现;插入后更新的记录只计入 Inserted;更新后删除的只计入 Deleted。
+	TDelta struct {
+		Inserted []*TRecordSet
+		Updated  []TRecordDelta
+		Deleted  []*TRecordSet // 已从数据集移除,记录的值仍可读取
+	}
+
+	// TSavepoint 保存点标识
+	TSavepoint uint64
+
+	tChangeStep struct {
+		id      uint64
+		changes []TChange
+	}
+
+	// tSavepoint 保存点及其创建时最近一步的 id
+	tSavepoint struct {
+		id   uint64
+		step uint64
+	}
+
+	// tChangeLog 变更日志:一次操作(SetByField、一次 AppendRecord/Clear/EditRecord 调用)为一步。
+	// 日志持有所涉及记录的指针,使被删除的记录可被恢复。
+	tChangeLog struct {
+		limit      int
+		nextID     uint64
+		nextSP     uint64
+		steps      []*tChangeStep // 可撤销的步骤
+		redo       []*tChangeStep
+		committed  []TChange // 超出 limit 不再可撤销、但仍计入 Delta 的早期变更,见 compactChanges
+		compacted  int       // 上次合并后 committed 的长度
+		savepoints []tSavepoint
+		depth      int
+		current    *tChangeStep
+		replaying  bool
+	}
+)
+
+// WithChangeLog 启用变更日志,见 TDataSet.EnableChangeLog
+func WithChangeLog(limit int) Option {
+	return func(cfg *Config) {
+		cfg.dataset.EnableChangeLog(limit)
+	}
+}
+
+// EnableChangeLog 启用变更日志,记录 SetByField、插入与删除(含 Clear/EditRecord/DeleteRecord),
+// 用于 Undo/Redo、保存点以及 Delta 提取。limit 为可撤销的最大步数,<= 0 表示不限;
+// 更早的步骤不再可撤销,但仍计入 Delta:其变更按记录与字段合并,
+// 日志的大小随被修改的记录数而非修改次数增长,AcceptChanges 时释放。已启用时只修改 limit。
+//
+// 撤销/重做/回滚直接恢复数据,不触发变更钩子与校验规则。
+func (self *TDataSet) EnableChangeLog(limit int) {
+	hooks := self.ensureHooks()
+	if hooks.log == nil {
+		hooks.log = &tChangeLog{}
+	}
+	hooks.log.limit = limit
+	hooks.log.trim()
+}
+
+// changeLog 已启用的变更日志,未启用时为 nil
+func (self *TDataSet) changeLog() *tChangeLog {
+	if hooks := self.owner().hooks; hooks != nil {
+		return hooks.log
+	}
+	return nil
+}
+
+// Savepoint 创建保存点,变更日志未启用时以 DefaultUndoLimit 启用。
+// 保存点之后的步骤在释放前不会因 limit 被丢弃。
+func (self *TDataSet) Savepoint() TSavepoint {
+	log := self.changeLog()
+	if log == nil {
+		self.EnableChangeLog(DefaultUndoLimit)
+		log = self.changeLog()
+	}
+
+	var step uint64
+	if n := len(log.steps); n > 0 {
+		step = log.steps[n-1].id
+	}
+	log.nextSP++
+	log.savepoints = append(log.savepoints, tSavepoint{id: log.nextSP, step: step})
+	return TSavepoint(log.nextSP)
+}
+
+// RollbackTo 撤销保存点之后的所有变更(不可重做),保存点保留,其后创建的保存点被释放
+func (self *TDataSet) RollbackTo(sp TSavepoint) error {
+	if self.IsFrozen() {
+		return ErrFrozen
+	}
+
+	log := self.changeLog()
+	idx := log.savepoint(sp)
+	if idx < 0 {
+		return ErrInvalidSavepoint
+	}
+
+	root := self.owner()
+	for len(log.steps) > 0 && log.steps[len(log.steps)-1].id > log.savepoints[idx].step {
+		step := log.pop()
+		root.replay(step, true)
+	}
+	log.savepoints = log.savepoints[:idx+1]
+	log.clearRedo()
+	return nil
+}
+
+// ReleaseSavepoint 释放保存点及其后创建的保存点,不改变数据
+func (self *TDataSet) ReleaseSavepoint(sp TSavepoint) error {
+	log := self.changeLog()
+	idx := log.savepoint(sp)
+	if idx < 0 {
+		return ErrInvalidSavepoint
+	}
+
+	log.savepoints = log.savepoints[:idx]
+	log.trim()
+	return nil
+}
+
+// Undo 撤销最近一步变更,无可撤销的步骤时返回 false
+func (self *TDataSet) Undo() bool {
+	log := self.changeLog()
+	if log == nil || len(log.steps) == 0 || self.IsFrozen() {
+		return false
+	}
+
+	step := log.pop()
+	self.owner().replay(step, true)
+	log.redo = append(log.redo, step)
+	return true
+}
+
+// Redo 重做最近一次撤销的步骤,产生新的变更后不可再重做
+func (self *TDataSet) Redo() bool {
+	log := self.changeLog()
+	if log == nil || len(log.redo) == 0 || self.IsFrozen() {
+		return false
+	}
+
+	step := log.redo[len(log.redo)-1]
+	log.redo = log.redo[:len(log.redo)-1]
+	self.owner().replay(step, false)
+	log.steps = append(log.steps, step)
+	log.trim()
+	return true
+}
+
+// CanUndo 是否有可撤销的步骤
+func (self *TDataSet) CanUndo() bool {
+	log := self.changeLog()
+	return log != nil && len(log.steps) > 0
+}
+
+// CanRedo 是否有可重做的步骤
+func (self *TDataSet) CanRedo() bool {
+	log := self.changeLog()
+	return log != nil && len(log.redo) > 0
+}
+
+// Changes 自 AcceptChanges 以来按发生顺序的全部变更(不含已撤销的)。
+// 超出撤销步数的早期变更已按记录与字段合并(见 compactChanges),不再逐次列出。
+func (self *TDataSet) Changes() []TChange {
+	log := self.changeLog()
+	if log == nil {
+		return nil
+	}
+
+	res := slices.Clone(log.committed)
+	for _, step := range log.steps {
+		res = append(res, step.changes...)
+	}
+	return res
+}
+
+// Delta 由变更日志计算净变更,变更日志未启用时返回 nil
+func (self *TDataSet) Delta() *TDelta {
+	if self.changeLog() == nil {
+		return nil
+	}
+
+	type (
+		recordState struct {
+			kind   TChangeKind
+			fields []string
+		}
+	)
+
+	var (
+		order  []*TRecordSet
+		states = make(map[*TRecordSet]*recordState)
+	)
+	for _, c := range self.Changes() {
+		rec := c.Record.latest()
+		state := states[rec]
+		switch c.Kind {
+		case ChangeInsert:
+			state = &recordState{kind: ChangeInsert}
+			states[rec] = state
+			order = append(order, rec)
+		case ChangeUpdate:
+			if state == nil {
+				state = &recordState{kind: ChangeUpdate}
+				states[rec] = state
+				order = append(order, rec)
+			}
+			if state.kind == ChangeUpdate && !slices.Contains(state.fields, c.Field) {
+				state.fields = append(state.fields, c.Field)
+			}
+		case ChangeDelete:
+			if state != nil && state.kind == ChangeInsert {
+				delete(states, rec)
+				continue
+			}
+			if state == nil {
+				order = append(order, rec)
+			}
+			states[rec] = &recordState{kind: ChangeDelete}
+		}
+	}
+
+	delta := &TDelta{}
+	for _, rec := range order {
+		state, has := states[rec]
+		if !has {
+			continue
+		}
+		delete(states, rec) // 同一记录只输出一次
+
+		switch state.kind {
+		case ChangeInsert:
+			delta.Inserted = append(delta.Inserted, rec)
+		case ChangeUpdate:
+			delta.Updated = append(delta.Updated, TRecordDelta{Record: rec, Fields: state.fields})
+		case ChangeDelete:
+			delta.Deleted = append(delta.Deleted, rec)
+		}
+	}
+	return delta
+}
+
+// AcceptChanges 清空变更日志(如数据已保存),撤销/重做记录与保存点一并清除
+func (self *TDataSet) AcceptChanges() {
+	log := self.changeLog()
+	if log == nil {
+		return
+	}
+
+	log.clearRedo()
+	log.committed = nil
+	log.compacted = 0
+	log.steps = nil
+	log.savepoints = nil
+}
+
+// disableChangeLog 停用临时启用的变更日志并释放其持有的记录;仍有保存点时保留
+func (self *TDataSet) disableChangeLog() {
+	log := self.changeLog()
+	if log == nil || len(log.savepoints) > 0 {
+		return
+	}
+	self.AcceptChanges()
+	self.owner().hooks.log = nil
+}
+
+// replay 撤销(undo 为 true)或重做一个步骤
+func (self *TDataSet) replay(step *tChangeStep, undo bool) {
+	log := self.changeLog()
+	log.replaying = true
+	defer func() { log.replaying = false }()
+
+	if undo {
+		for i := len(step.changes) - 1; i >= 0; i-- {
+			c := step.changes[i]
+			switch c.Kind {
+			case ChangeInsert:
+				self.removeRecord(c.Record)
+			case ChangeUpdate:
+				self.setRaw(c.Record, c.Field, c.OldValue)
+			case ChangeDelete:
+				self.insertRecord(c.Index, c.Record)
+			}
+		}
+		return
+	}
+
+	for _, c := range step.changes {
+		switch c.Kind {
+		case ChangeInsert:
+			self.insertRecord(c.Index, c.Record)
+		case ChangeUpdate:
+			self.setRaw(c.Record, c.Field, c.NewValue)
+		case ChangeDelete:
+			self.removeRecord(c.Record)
+		}
+	}
+}
+
+// removeRecord 从 Data 中移除记录
+func (self *TDataSet) removeRecord(rec *TRecordSet) {
+	rec = rec.latest()
+	self.Lock()
+	pos := slices.Index(self.Data, rec)
+	if pos >= 0 {
+		self.removeAt(pos)
+		// 撤销新增后游标可能越过末尾,移回最后一条记录
+		if cnt := len(self.Data); int(self.position.Load()) >= cnt {
+			self.position.Store(int32(max(cnt-1, 0)))
+		}
+	}
+	self.Unlock()
+
+	if pos >= 0 {
+		self.release(rec)
+	}
+}
+
+// insertRecord 将记录插回 Data 的 pos 位置(超出时追加)
+func (self *TDataSet) insertRecord(pos int, rec *TRecordSet) {
+	rec = rec.latest()
+	if rec.dataset == self && rec.store == nil && self.store != nil {
+		self.setValues(rec, rec.values) // 移出时复制回记录的值重新写入列式存储
+	}
+	self.Lock()
+	defer self.Unlock()
+
+	self.ensureDataOwned()
+	pos = max(0, min(pos, len(self.Data)))
+	self.Data = slices.Insert(self.Data, pos, rec)
+	for i := pos; i < len(self.Data); i++ {
+		if self.Data[i].dataset == self {
+			self.Data[i].index = i
+		}
+	}
+	if self.RecordsIndex != nil {
+		self.RecordsIndex = nil
+	}
+}
+
+// setRaw 直接写入字段值,不触发钩子与校验
+func (self *TDataSet) setRaw(rec *TRecordSet, field string, value any) {
+	idx, has := self.fieldsIndex[field]
+	if !has {
+		if idx = self.AddField(field); idx < 0 {
+			return
+		}
+	}
+
+	rec = rec.writable()
+	rec.set(idx, value, false)
+	rec.invalidateCalc(field)
+}
+
+// begin/end 将其间的变更合并为一步,可嵌套
+func (self *tChangeLog) begin() {
+	if self == nil {
+		return
+	}
+	if self.depth == 0 {
+		self.current = &tChangeStep{}
+	}
+	self.depth++
+}
+
+func (self *tChangeLog) end() {
+	if self == nil || self.depth == 0 {
+		return
+	}
+	self.depth--
+	if self.depth == 0 {
+		step := self.current
+		self.current = nil
+		if len(step.changes) > 0 {
+			self.push(step)
+		}
+	}
+}
+
+func (self *tChangeLog) record(c TChange) {
+	if self == nil || self.replaying {
+		return
+	}
+
+	if self.depth > 0 {
+		self.current.changes = append(self.current.changes, c)
+		return
+	}
+	self.push(&tChangeStep{changes: []TChange{c}})
+}
+
+func (self *tChangeLog) push(step *tChangeStep) {
+	self.nextID++
+	step.id = self.nextID
+	self.steps = append(self.steps, step)
+	self.clearRedo()
+	self.trim()
+}
+
+func (self *tChangeLog) pop() *tChangeStep {
+	step := self.steps[len(self.steps)-1]
+	self.steps = self.steps[:len(self.steps)-1]
+	return step
+}
+
+// trim 将超出 limit 的最早步骤移入 committed,保存点之后的步骤保留。
+// committed 的长度翻倍时合并一次,使其随被修改的记录数而非修改次数增长。
+func (self *tChangeLog) trim() {
+	for self.limit > 0 && len(self.steps) > self.limit {
+		oldest := self.steps[0]
+		for _, sp := range self.savepoints {
+			if sp.step < oldest.id {
+				return
+			}
+		}
+		self.committed = append(self.committed, oldest.changes...)
+		self.steps = self.steps[1:]
+	}
+	if len(self.committed) >= 2*max(self.compacted, self.limit) && len(self.committed) > 0 {
+		self.committed = compactChanges(self.committed)
+		self.compacted = len(self.committed)
+	}
+}
+
+// savepoint 保存点在 savepoints 中的位置,不存在时返回 -1
+func (self *tChangeLog) savepoint(sp TSavepoint) int {
+	if self == nil {
+		return -1
+	}
+	return slices.IndexFunc(self.savepoints, func(s tSavepoint) bool { return s.id == uint64(sp) })
+}
+
+func (self *tChangeLog) clearRedo() {
+	self.redo = nil
+}
+
+// compactChanges 按记录合并变更而不改变由其计算的 Delta:同一字段的多次更新合为一项
+// (保留最初的旧值与最新的新值),插入后的更新并入插入,插入后又删除的记录移除,
+// 更新后删除的只保留删除。
+func compactChanges(changes []TChange) []TChange {
+	type (
+		recordState struct {
+			kind    TChangeKind
+			entries []int          // 记录在 res 中的各项,首项决定记录在 Delta 中的顺序
+			fields  map[string]int // 更新的字段 -> 所在项
+		}
+	)
+
+	var (
+		res    = make([]TChange, 0, len(changes))
+		states = make(map[*TRecordSet]*recordState)
+	)
+	// replace 以 c 替换记录的首项并移除其余各项
+	replace := func(state *recordState, c TChange) {
+		for _, i := range state.entries {
+			res[i].Record = nil
+		}
+		res[state.entries[0]] = c
+		state.entries = state.entries[:1]
+		state.fields = nil
+	}
+
+	for _, c := range changes {
+		rec := c.Record.latest()
+		state := states[rec]
+		switch c.Kind {
+		case ChangeInsert:
+			if state != nil {
+				replace(state, c)
+				state.kind = ChangeInsert
+				continue
+			}
+			states[rec] = &recordState{kind: ChangeInsert, entries: []int{len(res)}}
+		case ChangeUpdate:
+			if state == nil {
+				state = &recordState{kind: ChangeUpdate, fields: make(map[string]int)}
+				states[rec] = state
+			}
+			if state.kind != ChangeUpdate {
+				continue // 插入后的更新已体现在记录中,删除后的更新不计入 Delta
+			}
+			if i, has := state.fields[c.Field]; has {
+				res[i].NewValue = c.NewValue
+				continue
+			}
+			state.fields[c.Field] = len(res)
+			state.entries = append(state.entries, len(res))
+		case ChangeDelete:
+			switch {
+			case state == nil:
+				states[rec] = &recordState{kind: ChangeDelete, entries: []int{len(res)}}
+			case state.kind == ChangeInsert:
+				replace(state, TChange{})
+				delete(states, rec)
+				continue
+			case state.kind == ChangeUpdate:
+				replace(state, c)
+				state.kind = ChangeDelete
+				continue
+			default:
+				continue
+			}
+		}
+		res = append(res, c)
+	}
+
+	return slices.DeleteFunc(res, func(c TChange) bool { return c.Record == nil })
+}
@@ -0,0 +1,172 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func datasetIds(ds *TDataSet) string {
+	ids := make([]any, 0, ds.Count())
+	for _, rec := range ds.Data {
+		ids = append(ids, rec.GetByField("id"))
+	}
+	return fmt.Sprint(ids)
+}
+
+func TestDatasetUndoRedo(t *testing.T) {
+	ds := newFixture(viewFixture, WithChangeLog(0))
+	ds.AcceptChanges()
+
+	ds.Data[0].SetByField("cat", "Z")
+	ds.NewRecord(map[string]any{"id": 4, "cat": "D"})
+	ds.Delete(1)
+	ds.EditRecord("3", map[string]any{"cat": "E", "note": "x"})
+	if datasetIds(ds) != "[1 3 4]" {
+		t.Fatalf("unexpected ids %s", datasetIds(ds))
+	}
+
+	// EditRecord 为一步
+	if !ds.Undo() || ds.Data[1].GetByField("cat") != "A" || ds.Data[1].GetByField("note") != nil {
+		t.Fatal("undo should revert the whole EditRecord")
+	}
+	if !ds.Undo() || datasetIds(ds) != "[1 2 3 4]" || ds.Data[1].index != 1 {
+		t.Fatalf("undo delete should restore the record, got %s", datasetIds(ds))
+	}
+	if !ds.Undo() || datasetIds(ds) != "[1 2 3]" {
+		t.Fatalf("undo insert should remove the record, got %s", datasetIds(ds))
+	}
+	if ds.Position() != 2 || !ds.SetKeyField("id") || ds.Count() != 3 {
+		t.Fatalf("undo insert should keep the cursor on the last record, got position %d count %d", ds.Position(), ds.Count())
+	}
+	if !ds.Undo() || ds.Data[0].GetByField("cat") != "A" || ds.CanUndo() {
+		t.Fatal("undo update should restore the old value")
+	}
+
+	if !ds.Redo() || !ds.Redo() || ds.Data[0].GetByField("cat") != "Z" || datasetIds(ds) != "[1 2 3 4]" {
+		t.Fatal("redo should re-apply undone steps")
+	}
+
+	// 新变更清空重做
+	ds.Data[0].SetByField("cat", "Y")
+	if ds.CanRedo() || ds.Redo() {
+		t.Fatal("new changes should clear the redo stack")
+	}
+
+	// Clear 可整体撤销
+	ds.Clear()
+	if !ds.Undo() || datasetIds(ds) != "[1 2 3 4]" {
+		t.Fatalf("undo clear should restore all records in order, got %s", datasetIds(ds))
+	}
+}
+
+func TestDatasetSavepoint(t *testing.T) {
+	ds := newFixture(viewFixture, WithChangeLog(1))
+	sp := ds.Savepoint()
+
+	ds.Data[0].SetByField("cat", "X")
+	inner := ds.Savepoint()
+	ds.Data[1].SetByField("cat", "Y")
+	ds.NewRecord(map[string]any{"id": 5})
+
+	// 保存点之后的步骤不因 limit 丢弃
+	if err := ds.RollbackTo(inner); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Count() != 3 || ds.Data[1].GetByField("cat") != "B" || ds.Data[0].GetByField("cat") != "X" {
+		t.Fatal("rollback should undo changes after the savepoint only")
+	}
+	if ds.RecordByKey(3, "id") == nil || ds.Count() != 3 || ds.Eof() {
+		t.Fatalf("rollback should keep the cursor inside the records, got position %d count %d", ds.Position(), ds.Count())
+	}
+
+	if err := ds.RollbackTo(sp); err != nil || ds.Data[0].GetByField("cat") != "A" {
+		t.Fatalf("rollback to the outer savepoint failed: %v", err)
+	}
+	if err := ds.RollbackTo(inner); !errors.Is(err, ErrInvalidSavepoint) {
+		t.Fatal("savepoints created after the rollback target should be released")
+	}
+
+	if err := ds.ReleaseSavepoint(sp); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.ReleaseSavepoint(sp); !errors.Is(err, ErrInvalidSavepoint) {
+		t.Fatal("released savepoint should be invalid")
+	}
+
+	// limit 生效:只能撤销最后一步
+	ds.Data[0].SetByField("cat", "1")
+	ds.Data[0].SetByField("cat", "2")
+	if !ds.Undo() || ds.Undo() || ds.Data[0].GetByField("cat") != "1" {
+		t.Fatal("undo stack should be bounded by limit")
+	}
+}
+
+func TestDatasetSavepointUnique(t *testing.T) {
+	ds := newFixture(viewFixture)
+	outer := ds.Savepoint()
+	inner := ds.Savepoint()
+	if outer == inner {
+		t.Fatal("savepoints should have unique ids")
+	}
+	if err := ds.ReleaseSavepoint(inner); err != nil {
+		t.Fatal(err)
+	}
+	ds.Data[0].SetByField("cat", "X")
+	if err := ds.RollbackTo(outer); err != nil || ds.Data[0].GetByField("cat") != "A" {
+		t.Fatalf("releasing the inner savepoint should keep the outer one: %v", err)
+	}
+}
+
+func TestDatasetChangeLogCompaction(t *testing.T) {
+	ds := newFixture(viewFixture, WithChangeLog(1))
+	for i := 0; i < 1000; i++ {
+		ds.Data[0].SetByField("cat", i)
+		ds.NewRecord(map[string]any{"id": 100 + i})
+		ds.Delete(ds.Count() - 1)
+	}
+	ds.Data[1].SetByField("cat", "Y")
+	ds.Delete(1)
+
+	if n := len(ds.changeLog().committed); n > 10 {
+		t.Fatalf("committed changes should be merged per record, got %d", n)
+	}
+	changes := ds.Changes()
+	if changes[0].Field != "cat" || changes[0].OldValue != "A" || changes[0].NewValue != 999 {
+		t.Fatalf("merged update should keep the first old value and the last new value, got %+v", changes[0])
+	}
+	delta := ds.Delta()
+	if len(delta.Inserted) != 0 || len(delta.Updated) != 1 || delta.Updated[0].Record != ds.Data[0] || len(delta.Deleted) != 1 {
+		t.Fatalf("unexpected delta %+v", delta)
+	}
+}
+
+func TestDatasetDelta(t *testing.T) {
+	ds := newFixture(viewFixture, WithChangeLog(2))
+	ds.AcceptChanges()
+
+	ds.Data[0].SetByField("cat", "X")
+	ds.Data[0].SetByField("tags", nil)
+	ds.Data[0].SetByField("cat", "Y")
+	ds.NewRecord(map[string]any{"id": 4})
+	ds.NewRecord(map[string]any{"id": 5})
+	ds.Data[3].SetByField("cat", "N")
+	ds.Delete(4) // 插入后删除:无净变更
+	ds.Delete(1)
+
+	delta := ds.Delta()
+	if len(delta.Inserted) != 1 || delta.Inserted[0].GetByField("id") != 4 {
+		t.Fatalf("unexpected inserted records %v", delta.Inserted)
+	}
+	if len(delta.Updated) != 1 || delta.Updated[0].Record != ds.Data[0] || fmt.Sprint(delta.Updated[0].Fields) != "[cat tags]" {
+		t.Fatalf("unexpected updated records %+v", delta.Updated)
+	}
+	if len(delta.Deleted) != 1 || delta.Deleted[0].GetByField("id") != 2 {
+		t.Fatal("deleted record should stay readable in the delta")
+	}
+
+	ds.AcceptChanges()
+	if d := ds.Delta(); len(d.Inserted)+len(d.Updated)+len(d.Deleted) != 0 || ds.CanUndo() {
+		t.Fatal("AcceptChanges should clear the change log")
+	}
+}
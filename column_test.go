@@ -0,0 +1,83 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDatasetColumnar(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	ds := NewDataSet(WithColumnar())
+	ds.NewRecord(map[string]any{"id": 1, "big": int64(1) << 40, "price": 1.5, "ok": true, "name": "a", "at": now, "tags": []any{"x"}})
+	ds.NewRecord(map[string]any{"id": 2, "big": nil, "price": 2.5, "ok": false, "name": "b", "at": now, "tags": nil})
+
+	if !ds.IsColumnar() || ds.Data[0].values != nil || ds.Data[0].store == nil {
+		t.Fatal("records should be stored in columns")
+	}
+
+	rec := ds.Data[0]
+	if rec.GetByField("id") != 1 || rec.GetByField("big") != int64(1)<<40 || rec.GetByField("price") != 1.5 ||
+		rec.GetByField("ok") != true || rec.GetByField("name") != "a" || rec.GetByField("at") != now {
+		t.Fatalf("values should keep their types, got %v", rec.AsMap())
+	}
+	if ds.Data[1].GetByField("big") != nil || ds.Data[1].GetByField("ok") != false || ds.Data[1].GetByField("tags") != nil {
+		t.Fatal("NULL and false values should be distinguished")
+	}
+
+	// 类型不一致时退化为 []any,已有值不变
+	ds.Data[1].SetByField("id", "two")
+	if ds.Data[0].GetByField("id") != 1 || ds.Data[1].GetByField("id") != "two" {
+		t.Fatal("mixed column should keep all values")
+	}
+
+	// 新增字段、删除后复用行槽
+	ds.Data[0].SetByField("note", "n")
+	slot := ds.Data[0].slot
+	ds.Delete(0)
+	ds.NewRecord(map[string]any{"id": 3})
+	if last := ds.Data[ds.Count()-1]; last.slot != slot || last.GetByField("note") != nil || last.GetByField("price") != nil {
+		t.Fatal("released slot should be reused and cleared")
+	}
+
+	if v, ok := ds.Max("price"); !ok || v != 2.5 || ds.Sum("price") != 2.5 {
+		t.Fatalf("unexpected aggregates max=%v sum=%v", v, ds.Sum("price"))
+	}
+}
+
+func TestDatasetColumnarDerived(t *testing.T) {
+	ds := newFixture(viewFixture, WithColumnar()) // WithData 先执行,已有记录迁移到列式存储
+	if ds.Data[0].store == nil {
+		t.Fatal("existing records should be migrated")
+	}
+
+	snap := ds.Snapshot()
+	view := ds.Filter("cat", []any{"A"})
+	ds.Data[0].SetByField("cat", "Z")
+	if snap.Get(0, "cat") != "A" || view.Data[0].GetByField("cat") != "Z" {
+		t.Fatal("snapshot should be isolated while views share records")
+	}
+
+	ds.SortBy("id desc")
+	if ds.Data[0].GetByField("id") != 3 || ds.Data[2].GetByField("cat") != "Z" {
+		t.Fatal("sort should keep record values")
+	}
+
+	clone := ds.Clone(true)
+	clone.Data[0].SetByField("cat", "C")
+	if !clone.IsColumnar() || ds.Data[0].GetByField("cat") != "A" {
+		t.Fatal("clone should be an independent columnar dataset")
+	}
+
+	if err := ds.SetFieldKind("id", KindDecimal); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ds.Data[0].GetByField("id").(TDecimal); !ok {
+		t.Fatal("field kind conversion should rewrite the column")
+	}
+
+	ds.EnableChangeLog(0)
+	ds.Delete(0)
+	if !ds.Undo() || ds.Data[0].GetByField("cat") != "A" || ds.Count() != 3 {
+		t.Fatal("undo should restore a deleted columnar record")
+	}
+}
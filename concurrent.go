@@ -0,0 +1,299 @@
+package dataset
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type (
+	// TConcurrentDataSet 并发安全的数据集包装。
+	//
+	// 并发约定:
+	//   - 所有公开方法均可被多个 goroutine 同时调用,读方法共享读锁,写方法独占写锁;
+	//     包装使用自己的锁,与 TDataSet 内嵌的 RWMutex 无关,调用方无需也不应操作后者。
+	//   - 方法不返回内部记录指针:记录以 map 副本返回(嵌套的 map/slice 同样复制),
+	//     Filter/GroupBy/Clone 返回深复制的独立 TDataSet,调用方可随意修改。
+	//   - 包装后不要再直接使用被包装的 *TDataSet;需要批量操作时使用
+	//     View(只读)/Update(读写)在锁内访问,回调中不得调用本包装的其他方法,
+	//     也不得把记录指针带出回调。
+	//   - 不提供游标(First/Next/Record),游标在多个使用者间共享没有意义。
+	TConcurrentDataSet struct {
+		mu sync.RWMutex
+		ds *TDataSet
+	}
+)
+
+// NewConcurrentDataSet 创建并发安全的数据集,opts 同 NewDataSet
+func NewConcurrentDataSet(opts ...Option) *TConcurrentDataSet {
+	return &TConcurrentDataSet{ds: NewDataSet(opts...)}
+}
+
+// NewConcurrent 包装已有数据集,此后调用方不应再直接访问 ds
+func NewConcurrent(ds *TDataSet) *TConcurrentDataSet {
+	if ds == nil {
+		ds = NewDataSet()
+	}
+	return &TConcurrentDataSet{ds: ds}
+}
+
+// View 在读锁内执行 fn,fn 不得修改数据集
+func (self *TConcurrentDataSet) View(fn func(ds *TDataSet) error) error {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return fn(self.ds)
+}
+
+// Update 在写锁内执行 fn
+func (self *TConcurrentDataSet) Update(fn func(ds *TDataSet) error) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return fn(self.ds)
+}
+
+// Transaction 同 TDataSet.Transaction,fn 在锁外执行,只在提交时持有写锁,
+// 读者只会看到提交前或提交后的完整数据
+func (self *TConcurrentDataSet) Transaction(fn func(tx *Tx) error) error {
+	tx, err := stageTx(fn)
+	if err != nil {
+		return err
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.owner().commitTx(tx)
+}
+
+// Count 记录数
+func (self *TConcurrentDataSet) Count() int {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.ds.Count()
+}
+
+// CountField 字段非空值的个数
+func (self *TConcurrentDataSet) CountField(field string) int {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.ds.CountField(field)
+}
+
+// Fields 字段列表副本
+func (self *TConcurrentDataSet) Fields() []string {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return slices.Clone(self.ds.Fields())
+}
+
+func (self *TConcurrentDataSet) HasField(name string) bool {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.ds.HasField(name)
+}
+
+func (self *TConcurrentDataSet) SetFields(fields ...string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.ds.SetFields(fields...)
+}
+
+func (self *TConcurrentDataSet) AddField(name string) int {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.AddField(name)
+}
+
+func (self *TConcurrentDataSet) SetKeyField(name string) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.SetKeyField(name)
+}
+
+func (self *TConcurrentDataSet) NewRecord(record map[string]any) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.NewRecord(record)
+}
+
+// NewRecordContext 同 TDataSet.NewRecordContext
+func (self *TConcurrentDataSet) NewRecordContext(ctx context.Context, record map[string]any) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.NewRecordContext(ctx, record)
+}
+
+// AppendRecord 追加记录,之后调用方不应再修改传入的记录
+func (self *TConcurrentDataSet) AppendRecord(records ...*TRecordSet) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.AppendRecord(records...)
+}
+
+// Get 第 idx 条记录的字段值,越界时返回 nil
+func (self *TConcurrentDataSet) Get(idx int, field string) any {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	if idx < 0 || idx >= len(self.ds.Data) {
+		return nil
+	}
+	return cloneValue(self.ds.Data[idx].GetByField(field))
+}
+
+// Set 设置第 idx 条记录的字段值,越界或设置失败时返回 false
+func (self *TConcurrentDataSet) Set(idx int, field string, value any) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if idx < 0 || idx >= len(self.ds.Data) {
+		return false
+	}
+	return self.ds.Data[idx].SetByField(field, value)
+}
+
+// RecordAsMap 第 idx 条记录的 AsMap 副本,越界时返回 nil
+func (self *TConcurrentDataSet) RecordAsMap(idx int) map[string]any {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	if idx < 0 || idx >= len(self.ds.Data) {
+		return nil
+	}
+	return copyMap(self.ds.Data[idx].AsMap())
+}
+
+// RecordByKey 按 KeyField 查找记录,返回 AsMap 副本,未找到时返回 nil
+func (self *TConcurrentDataSet) RecordByKey(key any) map[string]any {
+	self.mu.RLock()
+	if index := self.ds.RecordsIndex; index != nil || self.ds.KeyField == "" {
+		defer self.mu.RUnlock()
+		if rec, has := index[mapKey(key)]; has {
+			return copyMap(rec.AsMap())
+		}
+		return nil
+	}
+	self.mu.RUnlock()
+
+	// 主键索引在写操作后被清除,需在写锁内重建;查找在同一写锁内完成,
+	// 避免释放锁后其他写操作再次清除索引
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if rec := self.ds.RecordByKey(key); rec != nil {
+		return copyMap(rec.AsMap())
+	}
+	return nil
+}
+
+// Keys 同 TDataSet.Keys
+func (self *TConcurrentDataSet) Keys(fieldName ...string) []any {
+	if len(fieldName) > 0 {
+		self.mu.RLock()
+		defer self.mu.RUnlock()
+		return self.ds.Keys(fieldName...)
+	}
+
+	// 未指定字段时可能重建主键索引
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.Keys()
+}
+
+func (self *TConcurrentDataSet) Delete(idx int) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.Delete(idx)
+}
+
+func (self *TConcurrentDataSet) Clear() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.ds.Clear()
+}
+
+func (self *TConcurrentDataSet) SortBy(orders ...string) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.ds.SortBy(orders...)
+}
+
+// Aggregate 以 agg 聚合字段所有值,如 Aggregate("amount", AggSum)
+func (self *TConcurrentDataSet) Aggregate(field string, agg TAggregate) any {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return agg(self.ds.columnValues(field))
+}
+
+// Range 遍历记录的 AsMap 副本。副本在读锁内生成,fn 在锁外调用,可调用本包装的任意方法。
+func (self *TConcurrentDataSet) Range(fn func(pos int, record map[string]any) error) error {
+	self.mu.RLock()
+	records := make([]map[string]any, len(self.ds.Data))
+	for i, rec := range self.ds.Data {
+		records[i] = copyMap(rec.AsMap())
+	}
+	self.mu.RUnlock()
+
+	for i, m := range records {
+		if err := fn(i, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Filter 同 TDataSet.Filter,返回深复制的独立数据集
+func (self *TConcurrentDataSet) Filter(field string, values []any, inverse ...bool) *TDataSet {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return detach(self.ds.Filter(field, values, inverse...))
+}
+
+// GroupBy 同 TDataSet.GroupBy,各分组为深复制的独立数据集
+func (self *TConcurrentDataSet) GroupBy(field string) map[any]*TDataSet {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	groups := self.ds.GroupBy(field)
+	for key, grp := range groups {
+		groups[key] = detach(grp)
+	}
+	return groups
+}
+
+// Clone 返回当前数据的深复制
+func (self *TConcurrentDataSet) Clone() *TDataSet {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+	return self.ds.Clone(true)
+}
+
+// Snapshot 创建只读快照,快照可在锁外被任意 goroutine 读取,不受之后写操作影响
+func (self *TConcurrentDataSet) Snapshot() *TSnapshot {
+	self.mu.Lock() // Snapshot 会标记共享状态
+	defer self.mu.Unlock()
+	return self.ds.Snapshot()
+}
+
+// Subscribe 同 TDataSet.Subscribe,事件在写操作的锁内投递
+func (self *TConcurrentDataSet) Subscribe(ctx context.Context, filter func(TChangeEvent) bool, opts ...SubscribeOption) <-chan TChangeEvent {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.ds.Subscribe(ctx, filter, opts...)
+}
+
+// detach 将派生数据集转为深复制,并清空视图
+func detach(ds *TDataSet) *TDataSet {
+	if ds == nil || !ds.IsView() {
+		return ds
+	}
+
+	res := ds.Clone(true)
+	ds.Clear()
+	return res
+}
+
+func copyMap(m map[string]any) map[string]any {
+	for k, v := range m {
+		m[k] = cloneValue(v)
+	}
+	return m
+}
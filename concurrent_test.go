@@ -0,0 +1,147 @@
+package dataset
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentDataSetBasic(t *testing.T) {
+	cds := NewConcurrentDataSet(WithData(
+		map[string]any{"id": 1, "cat": "A", "amount": 10.0},
+		map[string]any{"id": 2, "cat": "B", "amount": 20.0},
+	))
+	cds.SetKeyField("id")
+
+	if cds.Count() != 2 {
+		t.Fatalf("expected 2 records, got %d", cds.Count())
+	}
+	if m := cds.RecordByKey(2); m == nil || m["cat"] != "B" {
+		t.Fatalf("RecordByKey(2) = %v", m)
+	}
+
+	// 返回的是副本
+	m := cds.RecordAsMap(0)
+	m["cat"] = "Z"
+	if cds.Get(0, "cat") != "A" {
+		t.Fatal("RecordAsMap must return a copy")
+	}
+
+	filtered := cds.Filter("cat", []any{"A"})
+	if filtered.IsView() || filtered.Count() != 1 {
+		t.Fatal("Filter must return an independent copy")
+	}
+	filtered.Data[0].SetByField("amount", 99.0)
+	if cds.Get(0, "amount") != 10.0 {
+		t.Fatal("editing the filter result must not change the source")
+	}
+
+	if v := cds.Aggregate("amount", AggSum); v != 30.0 {
+		t.Fatalf("expected sum 30, got %v", v)
+	}
+}
+
+// TestConcurrentDataSetStress 需配合 go test -race 运行以检测数据竞争
+func TestConcurrentDataSetStress(t *testing.T) {
+	cds := NewConcurrentDataSet()
+	cds.SetFields("id", "cat", "amount")
+	cds.Update(func(ds *TDataSet) error {
+		return ds.AddCalcField("double", []string{"amount"}, func(rec *TRecordSet) any {
+			return rec.FieldByName("amount").AsInteger() * 2
+		})
+	})
+	for i := 0; i < 50; i++ {
+		cds.NewRecord(map[string]any{"id": i, "cat": fmt.Sprint(i % 3), "amount": i})
+	}
+	cds.SetKeyField("id")
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				switch (w + i) % 10 {
+				case 0:
+					cds.NewRecord(map[string]any{"id": 1000 + w*1000 + i, "cat": "x", "amount": i})
+				case 1:
+					cds.Set(i%cds.Count(), "amount", i)
+				case 2:
+					cds.Get(i%40, "double")
+				case 3:
+					cds.RecordByKey(i % 50)
+				case 4:
+					cds.Filter("cat", []any{"1"})
+				case 5:
+					cds.GroupBy("cat")
+				case 6:
+					cds.Range(func(pos int, rec map[string]any) error { return nil })
+				case 7:
+					cds.Aggregate("double", AggSum)
+				case 8:
+					if i%50 == 0 {
+						cds.Delete(0)
+					}
+					cds.Keys("id")
+				case 9:
+					if i%50 == 0 {
+						cds.SortBy("amount desc")
+					}
+					cds.Set(i%cds.Count(), fmt.Sprintf("f%d", w), i)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if cds.Count() == 0 {
+		t.Fatal("expected records after stress run")
+	}
+}
+
+// 写操作清除主键索引时,查找不应返回假的 nil
+func TestConcurrentDataSetRecordByKeyWhileWriting(t *testing.T) {
+	cds := NewConcurrentDataSet()
+	for i := 1; i <= 50; i++ {
+		cds.NewRecord(map[string]any{"id": i})
+	}
+	cds.SetKeyField("id")
+
+	done := make(chan struct{})
+	writer := make(chan struct{})
+	go func() {
+		defer close(writer)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				cds.NewRecord(map[string]any{"id": 1000 + i})
+			}
+		}
+	}()
+
+	var (
+		wg     sync.WaitGroup
+		missed atomic.Int32
+	)
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				if cds.RecordByKey(i%50+1) == nil {
+					missed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	<-writer
+
+	if n := missed.Load(); n > 0 {
+		t.Fatalf("RecordByKey missed existing keys %d times", n)
+	}
+}
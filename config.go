@@ -1,10 +1,19 @@
 package dataset
 
+import "context"
+
 type (
 	Option func(*Config)
 	Config struct {
-		dataset     *TDataSet
-		checkFields bool
+		dataset         *TDataSet
+		checkFields     bool
+		copyOnDerive    bool            // Filter/GroupBy 返回深复制而非视图
+		deferValidation bool            // 校验规则仅在 Validate 时执行
+		rowLimit        int             // NewDataSetFromRows 最多读取的行数
+		loadCtx         context.Context // NewDataSetFromRows/NewDataSetFromProvider 的取消信号
+		pageSize        int             // NewDataSetFromProvider 每页的记录数
+		pageWindow      int             // NewDataSetFromProvider 缓存的页数
+		noPrefetch      bool            // NewDataSetFromProvider 不预读下一页
 	}
 )
 
@@ -41,6 +50,22 @@ func WithFieldsChecker() Option {
 	}
 }
 
+// WithCopyOnDerive Filter/GroupBy 返回记录深复制的独立数据集,而非默认的视图。
+// 适用于结果需要独立修改、或源数据集随后会被修改/清空的场景。
+func WithCopyOnDerive() Option {
+	return func(cfg *Config) {
+		cfg.copyOnDerive = true
+	}
+}
+
+// WithDeferredValidation 写入时不执行校验规则,仅在调用 Validate 时校验,
+// 适用于先完整填写再统一提示错误的表单场景。
+func WithDeferredValidation() Option {
+	return func(cfg *Config) {
+		cfg.deferValidation = true
+	}
+}
+
 // WithFieldFormater 复制 src 数据集的 fieldFormater 到新数据集。
 // 仅复制格式化器映射(逐项写入新 map,不共享底层 map),不复制数据。
 // src 为 nil 或无格式化器时不做任何操作。
@@ -1,7 +1,9 @@
 package dataset
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -10,6 +12,11 @@ import (
 )
 
 type (
+	// TDataSet 不是并发安全的:除 Delete/Clear/AddField/SetKeyField 等少数方法内部
+	// 短暂加锁外,多数方法(AppendRecord/Filter/GroupBy/Range/Record/SetFields 以及
+	// TRecordSet.SetByField)均不加锁。内嵌的 RWMutex 不可重入,调用方不要在持有它时
+	// 调用 TDataSet 的方法(如 SetByField 新增字段时会调用 AddField 加锁,造成死锁)。
+	// 需要在多个 goroutine 间共享读写时,使用 TConcurrentDataSet 包装。
 	TDataSet struct {
 		sync.RWMutex
 		config       *Config
@@ -19,8 +26,8 @@ type (
 		Name         string              // 数据模型名称 默认为空白 特殊情况下为Model的名称
 		KeyField     string              // 主键字段
 		Data         []*TRecordSet       //
-		FieldCount   int                 // 字段数
-		RecordsIndex map[any]*TRecordSet // 主键引索列表 // for RecordByKey() Keys()
+		FieldCount   int                 // 字段数,视图中只是同步时的副本,以 len(Fields()) 为准
+		RecordsIndex map[any]*TRecordSet // 主键引索列表 // for RecordByKey() Keys() TDecimal 主键以 mapKey 规范化
 
 		// classic 字段存储的数据包含有 Struct/Array/map 等
 		classic bool // 是否存储着经典模式的数据 many2one字段会显示ID和Name
@@ -30,6 +37,31 @@ type (
 		// 仅影响序列化输出:dataset 内存值与访问器(GetByField/AsInteger)仍为 int64,
 		// RecordByKey 等内部逻辑不受影响。应在数据集构建完成后一次性设置,之后只读。
 		fieldFormater map[string]func(any) any
+
+		calcFields map[string]*TCalcField // 计算字段
+		calcDeps   map[string][]string    // 依赖字段 -> 依赖它的计算字段
+		calcMu     sync.Mutex             // 保护记录的计算字段缓存
+		fieldKinds map[string]TFieldKind  // 声明了类型的字段
+
+		parent *TDataSet // 视图所引用的根数据集,见 view.go
+
+		gen        uint64      // 快照代数,见 snapshot.go
+		dataShared bool        // Data 切片与快照共享
+		frozen     atomic.Bool // Freeze 后拒绝修改
+
+		hooks *tHooks // 变更钩子,见 hook.go
+		rules []tRule // 校验规则,见 validate.go
+
+		defaults    map[string]any // 字段默认值,见 defaults.go
+		keySequence *TSequence     // 主键编号生成器
+
+		store *tColumnStore // 列式存储,见 column.go
+
+		filter func(rec *TRecordSet) bool // 实时过滤条件,见 filter.go
+		source *tPagedSource              // 分页数据源,见 paged.go
+
+		scopeOnce sync.Once // 未命名数据集的游标令牌标识,见 page.go
+		scopeID   string
 	}
 )
 
@@ -58,7 +90,7 @@ func (self *TDataSet) Classic(value ...bool) bool {
 // 仅影响 AsMap/AsJson 的输出,不改变内存值与访问器结果。应在数据集构建完成后
 // 一次性调用、之后只读。空入参时清空标记。
 func (self *TDataSet) SetFieldFormater(name string, format func(any) any) {
-	if len(name) == 0 {
+	if len(name) == 0 || self.IsFrozen() {
 		return
 	}
 
@@ -75,50 +107,89 @@ func (self *TDataSet) Range(fn func(pos int, record *TRecordSet) error) error {
 		return nil
 	}
 
-	for i, rec := range self.Data {
-		if err := fn(i, rec); err != nil {
-			return err
+	return self.scan(func(pos int, rec *TRecordSet) error {
+		if !self.accept(rec) {
+			return nil
 		}
-	}
-
-	return nil
+		return fn(pos, rec)
+	})
 }
 
 // TODO  当TDataSet无数据是返回错误
 // TODO HasField()bool
 func (self *TDataSet) FieldByName(field string) (fieldSet *TFieldSet) {
-	if idx, has := self.fieldsIndex[field]; has {
+	if idx, has := self.owner().fieldsIndex[field]; has {
 		return newFieldSet(idx, field, self.Record())
 	}
 	return newFieldSet(-1, field, self.Record())
 }
 
+// IsEmpty 是否没有记录,设置了过滤条件时为没有满足条件的记录
 func (self *TDataSet) IsEmpty() bool {
-	return len(self.Data) == 0
+	return self == nil || self.endAt(self.seek(0))
 }
 
 // return the number of data
+// 字段非空值的个数见 CountField;设置了过滤条件时只计满足条件的记录,见 filter.go
 func (self *TDataSet) Count() int {
 	if self == nil {
 		return 0
 	}
+	if self.source != nil {
+		return self.countPaged()
+	}
+	if self.filter != nil {
+		count := 0
+		for _, rec := range self.Data {
+			if self.accept(rec) {
+				count++
+			}
+		}
+		return count
+	}
 	return len(self.Data)
 }
 
 // clear all records
+// 删除钩子中任一记录被否决时不删除任何记录,见 hook.go
 func (self *TDataSet) Clear() {
-	self.Lock()
-	defer self.Unlock()
+	if self.IsFrozen() {
+		return
+	}
 
+	hooks := self.deleteHooks()
 	for _, rec := range self.Data {
-		rec.Free()
+		if err := hooks.fireBeforeDelete(rec); err != nil {
+			return
+		}
 	}
+
+	log := hooks.changeLog()
+	log.begin()
+	defer log.end()
+
+	self.Lock()
+	data := self.Data
 	self.Data = nil
+	self.dataShared = false
 	if self.RecordsIndex != nil {
 		self.RecordsIndex = nil
 	}
-
 	self.position.Store(0)
+	self.Unlock()
+
+	for _, rec := range data {
+		hooks.fireAfterDelete(rec, 0) // 撤销时按逆序逐条插回首位
+		self.release(rec)
+	}
+}
+
+// deleteHooks 视图的删除只改变视图,不触发删除钩子
+func (self *TDataSet) deleteHooks() *tHooks {
+	if self.parent != nil {
+		return nil
+	}
+	return self.hooks
 }
 
 func (self *TDataSet) Position() int {
@@ -126,29 +197,39 @@ func (self *TDataSet) Position() int {
 }
 
 // set the Pos on first
+// 设置了过滤条件时移到首条满足条件的记录
 func (self *TDataSet) First() {
-	self.position.Store(0)
+	self.position.Store(int32(self.seek(0)))
 }
 
 // goto next record
 func (self *TDataSet) Next() {
-	self.position.Add(1)
+	if self.filter == nil {
+		self.position.Add(1)
+		return
+	}
+	self.position.Store(int32(self.seek(int(self.position.Load()) + 1)))
 }
 
 // is the end of the data list
 func (self *TDataSet) Eof() bool {
-	return self == nil || int(self.position.Load()) >= len(self.Data)
+	return self == nil || self.endAt(self.settle())
 }
 
 // return the current record
 func (self *TDataSet) Record() *TRecordSet {
-	pos := int(self.position.Load())
+	pos := self.settle()
+	if self.source != nil {
+		if rec := self.source.get(pos); rec != nil {
+			return rec
+		}
+	}
 	count := len(self.Data)
 	if count == 0 || count <= pos {
-		// 规避零界点取值
+		// 规避零界点取值:返回未加入 Data 的空白记录,对其设值时才插入数据集
 		rec := NewRecordSet()
-		rec.dataset = self
-		self.Data = append(self.Data, rec)
+		rec.dataset = self.owner()
+		rec.gen = rec.dataset.gen
 		return rec
 	} else {
 		if rec := self.Data[pos]; rec != nil {
@@ -163,7 +244,7 @@ func (self *TDataSet) Record() *TRecordSet {
 // TODO 简化
 func (self *TDataSet) validateFields(record *TRecordSet) error {
 	// #优先记录该数据集的字段
-	if self.Count() == 0 && self.FieldCount == 0 {
+	if len(self.Data) == 0 && self.FieldCount == 0 {
 		idxMap := record.getFieldsIndex()
 		// Clone fieldsIndex properly since we optimized record's fieldsIndex memory,
 		// avoid sharing the map if it could mutate differently or if we want dataset to own it.
@@ -198,40 +279,100 @@ func (self *TDataSet) validateFields(record *TRecordSet) error {
 
 // NOTE:第一条记录决定空dataset的fields 默认情况下会自动舍弃多余字段的数据
 // appending a record.Its fields will be come the standard format when it is the first record of this set
+// 未归属任何数据集的记录直接归入本数据集;已归属其他数据集(或已在本数据集中)的记录
+// 会被复制后追加,原记录的归属与索引保持不变。向视图追加的记录同时追加到其根数据集。
+// 所有值均为 nil 且没有默认值的记录被跳过。
 func (self *TDataSet) AppendRecord(records ...*TRecordSet) error {
+	return self.AppendRecordContext(context.Background(), records...)
+}
+
+// AppendRecordContext 同 AppendRecord,ctx 传给 func(ctx context.Context) any 形式的默认值(见 SetDefault)
+func (self *TDataSet) AppendRecordContext(ctx context.Context, records ...*TRecordSet) error {
+	if self.IsFrozen() {
+		return ErrFrozen
+	}
+	if self.parent != nil {
+		return self.appendToView(ctx, records...)
+	}
+	return self.appendRecords(ctx, false, records...)
+}
+
+// appendRecords 向根数据集追加记录,keepBlank 为 true 时保留所有值均为 nil 的记录
+func (self *TDataSet) appendRecords(ctx context.Context, keepBlank bool, records ...*TRecordSet) error {
+
+	var (
+		inserted []*TRecordSet
+		err      error
+	)
+	log := self.changeLog()
+	log.begin()
+	defer log.end()
+
 	recCount := len(self.Data)
 	for _, rec := range records {
+		rec = rec.latest()
 		if rec == nil {
 			continue
 		}
 
-		if err := self.validateFields(rec); err != nil {
-			return err
+		if err = self.validateFields(rec); err != nil {
+			break
+		}
+		if err = self.hooks.fireBeforeInsert(rec); err != nil {
+			break
 		}
 
-		//#TODO 考虑是否为复制
-		rec.dataset = self //# 将其归为
-		rec.index = recCount
+		self.ensureDefaultFields()
+		values, isBlankRec, cerr := self.recordValues(rec)
+		if cerr != nil {
+			err = cerr
+			break
+		}
+		applied, slots, derr := self.applyDefaults(ctx, values)
+		if derr != nil {
+			err = derr
+			break
+		}
+		if isBlankRec && !applied && !keepBlank {
+			continue
+		}
 
-		values := make([]interface{}, self.FieldCount)
-		isBlankRec := true
-		for f, idx := range self.fieldsIndex {
-			v := rec.GetByField(f)
-			if idx < self.FieldCount {
-				values[idx] = v
-			}
-			if v != nil {
-				isBlankRec = false
+		if self.validateOnWrite() {
+			shell := &TRecordSet{dataset: self, index: recCount, values: values, fieldsCount: self.FieldCount}
+			if errs := self.validateRecord(shell, recCount); len(errs) > 0 {
+				err = errs
+				break
 			}
 		}
+		if err = self.takeSequences(values, slots); err != nil {
+			break
+		}
 
-		if !isBlankRec {
-			rec.values = values
-			rec.fieldsIndex = nil
-			rec.fieldsCount = self.FieldCount
-			self.Data = append(self.Data, rec)
-			recCount++
+		target := rec
+		if rec.dataset != nil && (rec.dataset != self || rec.index != -1 || rec.isShared()) {
+			// 复制,避免改变其他数据集中记录的归属
+			target = NewRecordSet()
+			if len(rec.ClassicValues) > 0 {
+				for f, idx := range self.fieldsIndex {
+					target.set(idx, rec.GetByField(f, true), true)
+				}
+			}
 		}
+
+		target.dataset = self //# 将其归为
+		target.gen = self.gen
+		target.index = recCount
+		target.calcCache = nil // 计算字段缓存依赖于所属数据集的定义
+		self.setValues(target, values)
+		target.fieldsIndex = nil
+		target.fieldsCount = self.FieldCount
+		self.ensureDataOwned()
+		self.Data = append(self.Data, target)
+		inserted = append(inserted, target)
+		recCount++
+	}
+	if err != nil && len(inserted) == 0 {
+		return err
 	}
 	self.position.Store(int32(recCount - 1))
 
@@ -240,17 +381,80 @@ func (self *TDataSet) AppendRecord(records ...*TRecordSet) error {
 		self.RecordsIndex = nil
 	}
 
+	for _, rec := range inserted {
+		self.hooks.fireAfterInsert(rec)
+	}
+
+	return err
+}
+
+// recordValues 按数据集字段顺序取出记录的值并按字段类型转换,isBlank 表示所有值均为 nil
+func (self *TDataSet) recordValues(rec *TRecordSet) (values []any, isBlank bool, err error) {
+	values = make([]interface{}, self.FieldCount)
+	isBlank = true
+	for f, idx := range self.fieldsIndex {
+		v, err := self.convertValue(f, rec.GetByField(f))
+		if err != nil {
+			return nil, false, fmt.Errorf("field < %s >: %w", f, err)
+		}
+		if idx < self.FieldCount {
+			values[idx] = v
+		}
+		if v != nil {
+			isBlank = false
+		}
+	}
+	return values, isBlank, nil
+}
+
+// appendToView 已属于根数据集的记录直接引用,其余先追加到根数据集再引用
+func (self *TDataSet) appendToView(ctx context.Context, records ...*TRecordSet) error {
+	root := self.parent
+	self.ensureDataOwned()
+	for _, rec := range records {
+		rec = rec.latest()
+		if rec == nil {
+			continue
+		}
+
+		if rec.dataset == root && rec.index >= 0 {
+			self.Data = append(self.Data, rec)
+			continue
+		}
+
+		start := len(root.Data)
+		if err := root.AppendRecordContext(ctx, rec); err != nil {
+			self.syncView()
+			return err
+		}
+		for _, added := range root.Data[start:] {
+			self.Data = append(self.Data, added)
+		}
+	}
+	self.syncView()
+	self.position.Store(int32(len(self.Data) - 1))
+
+	if self.RecordsIndex != nil {
+		self.RecordsIndex = nil
+	}
+
 	return nil
 }
 
 // push row to dataset
 func (self *TDataSet) NewRecord(record map[string]interface{}) error {
-	return self.AppendRecord(NewRecordSet(record))
+	return self.NewRecordContext(context.Background(), record)
+}
+
+// NewRecordContext 同 NewRecord,ctx 传给 func(ctx context.Context) any 形式的默认值(见 SetDefault)
+func (self *TDataSet) NewRecordContext(ctx context.Context, record map[string]interface{}) error {
+	return self.AppendRecordContext(ctx, NewRecordSet(record))
 }
 
 func (self *TDataSet) Delete(idx ...int) bool {
-	self.Lock()
-	defer self.Unlock()
+	if self.IsFrozen() {
+		return false
+	}
 
 	cnt := len(self.Data)
 	if cnt == 0 {
@@ -268,23 +472,95 @@ func (self *TDataSet) Delete(idx ...int) bool {
 	}
 
 	rec := self.Data[pos]
-	self.Data = append(self.Data[:pos], self.Data[pos+1:]...)
-	rec.Free()
+	hooks := self.deleteHooks()
+	if err := hooks.fireBeforeDelete(rec); err != nil {
+		return false
+	}
+
+	self.Lock()
+	// 钩子中可能修改了 Data
+	if pos >= len(self.Data) || self.Data[pos] != rec {
+		if pos = slices.Index(self.Data, rec); pos < 0 {
+			self.Unlock()
+			return false
+		}
+	}
+	self.removeAt(pos)
+	self.Unlock()
+
+	hooks.fireAfterDelete(rec, pos)
+	self.release(rec)
 
 	return true
 }
 
-// TODO implement
+// removeAt 从 Data 中移除第 pos 条记录
+func (self *TDataSet) removeAt(pos int) {
+	cnt := len(self.Data)
+	self.ensureDataOwned()
+	copy(self.Data[pos:], self.Data[pos+1:])
+	self.Data[cnt-1] = nil
+	self.Data = self.Data[:cnt-1]
+	for i := pos; i < len(self.Data); i++ {
+		if self.Data[i].dataset == self {
+			self.Data[i].index = i
+		}
+	}
+
+	// 索引可能仍指向被删除的记录
+	if self.RecordsIndex != nil {
+		self.RecordsIndex = nil
+	}
+}
+
+// DeleteRecord 删除主键(KeyField,未设置时为 id)值为 Key 的记录,未找到时返回 false
 func (self *TDataSet) DeleteRecord(Key string) bool {
-	return true
+	idx := self.indexOfKey(Key)
+	if idx < 0 {
+		return false
+	}
+	return self.Delete(idx)
 }
 
-// TODO implement
+// EditRecord 以 Record 中的字段值更新主键值为 Key 的记录,
+// 未找到记录或任一字段写入失败(如被 BeforeUpdate 否决)时返回 false,此前已写入的字段保留
 func (self *TDataSet) EditRecord(Key string, Record map[string]interface{}) bool {
+	idx := self.indexOfKey(Key)
+	if idx < 0 || self.IsFrozen() {
+		return false
+	}
+
+	log := self.changeLog()
+	log.begin()
+	defer log.end()
+
+	rec := self.Data[idx]
+	for field, value := range Record {
+		if !rec.SetByField(field, value) {
+			return false
+		}
+	}
 	return true
 }
 
+// indexOfKey 按主键的字符串形式查找记录位置
+func (self *TDataSet) indexOfKey(key string) int {
+	keyField := self.KeyField
+	if keyField == "" {
+		keyField = "id"
+	}
+
+	for i, rec := range self.Data {
+		if v := rec.GetByField(keyField); !isNull(v) && utils.ToString(v) == key {
+			return i
+		}
+	}
+	return -1
+}
+
 // filed: 可以为格式"filedName/filedName.filedName"
+// TDecimal 值的分组键为去掉尾零的字符串(如 "1.5"),见 mapKey
+// 各分组默认为引用本数据集记录的视图,WithCopyOnDerive 时为深复制,见 view.go
 func (self *TDataSet) GroupBy(field string) map[any]*TDataSet {
 	if self == nil {
 		return nil
@@ -296,9 +572,8 @@ func (self *TDataSet) GroupBy(field string) map[any]*TDataSet {
 
 	// TODO 优化FieldIndex获取减少重复使用
 	groups := make(map[any]*TDataSet)
-	for _, rec := range self.Data {
-		i := rec.GetFieldIndex(fileds[0])
-		if idxValue := rec.get(i, false); idxValue != nil {
+	self.scan(func(_ int, rec *TRecordSet) error {
+		if idxValue := rec.GetByField(fileds[0]); idxValue != nil {
 			var grp *TDataSet
 			if len(fileds) > 1 {
 				if m, ok := idxValue.(map[string]any); ok {
@@ -306,36 +581,36 @@ func (self *TDataSet) GroupBy(field string) map[any]*TDataSet {
 				}
 			}
 
+			idxValue = mapKey(idxValue)
 			grp = groups[idxValue]
 			if grp == nil {
-				grp = NewDataSet(WithFieldFormater(self))
+				grp = self.deriveDataSet()
 				groups[idxValue] = grp
 			}
 
-			grp.AppendRecord(rec)
+			grp.addDerived(rec)
 		}
-	}
+		return nil
+	})
 
 	return groups
 }
 
 // 根据字段取所有记录对应的值
 func (self *TDataSet) ValueBy(fieldName string) (values []any) {
-	if self.Count() == 0 {
-		return nil
-	}
-
-	for _, rec := range self.Data {
+	self.scan(func(_ int, rec *TRecordSet) error {
 		value := rec.GetByField(fieldName)
-		if value != nil && !utils.IsBlank(value) {
+		if value != nil && !isBlank(value) {
 			values = append(values, value)
 		}
-	}
+		return nil
+	})
 
 	return values
 }
 
 // inverse : the result will select inverse
+// 结果默认为引用本数据集记录的视图,WithCopyOnDerive 时为深复制,见 view.go
 func (self *TDataSet) Filter(field string, values []interface{}, inverse ...bool) *TDataSet {
 	if field == "" || len(values) == 0 {
 		return nil
@@ -346,20 +621,20 @@ func (self *TDataSet) Filter(field string, values []interface{}, inverse ...bool
 		inv = inverse[0]
 	}
 
-	newDataSet := NewDataSet(WithFieldFormater(self))
-	for _, rec := range self.Data {
-		i := rec.GetFieldIndex(field)
-		val := rec.get(i, false)
+	newDataSet := self.deriveDataSet()
+	self.scan(func(_ int, rec *TRecordSet) error {
+		val := rec.GetByField(field)
 		if inv {
 			if utils.IndexOf(val, values...) == -1 {
-				newDataSet.AppendRecord(rec)
+				newDataSet.addDerived(rec)
 			}
 		} else {
 			if utils.IndexOf(val, values...) != -1 {
-				newDataSet.AppendRecord(rec)
+				newDataSet.addDerived(rec)
 			}
 		}
-	}
+		return nil
+	})
 
 	return newDataSet
 }
@@ -369,10 +644,19 @@ func (self *TDataSet) RecordByField(field string, val interface{}) (rec *TRecord
 	if field == "" || val == nil {
 		return nil
 	}
+	if self.source != nil {
+		self.scan(func(_ int, r *TRecordSet) error {
+			if r.GetByField(field) == val {
+				rec = r
+				return errStopScan
+			}
+			return nil
+		})
+		return rec
+	}
 
 	for _, rec = range self.Data {
-		i := rec.GetFieldIndex(field)
-		if rec.get(i, false) == val {
+		if rec.GetByField(field) == val {
 			return rec
 		}
 	}
@@ -380,7 +664,11 @@ func (self *TDataSet) RecordByField(field string, val interface{}) (rec *TRecord
 }
 
 // 获取对应KeyFieldd值
+// 由 DataProvider 提供记录时不建立索引,逐页查找
 func (self *TDataSet) RecordByKey(key interface{}, key_field ...string) *TRecordSet {
+	if self.source != nil {
+		return self.recordByKeyPaged(key, key_field...)
+	}
 	// Get the value first without locking extensively to avoid data races
 	// Note: in parallel executions, Rebuilding RecordsIndex inside RecordByKey is not safe,
 	// but rebuilding lazily check if nil protects some paths
@@ -402,7 +690,7 @@ func (self *TDataSet) RecordByKey(key interface{}, key_field ...string) *TRecord
 
 	self.RLock()
 	defer self.RUnlock()
-	if val, has := self.RecordsIndex[key]; has {
+	if val, has := self.RecordsIndex[mapKey(key)]; has {
 		return val
 	}
 
@@ -411,6 +699,15 @@ func (self *TDataSet) RecordByKey(key interface{}, key_field ...string) *TRecord
 
 // 设置固定字段
 func (self *TDataSet) SetFields(fields ...string) {
+	if self.IsFrozen() {
+		return
+	}
+	if self.parent != nil {
+		self.parent.SetFields(fields...)
+		self.syncView()
+		return
+	}
+
 	if len(fields) > 255 {
 		fields = fields[:255]
 	}
@@ -424,6 +721,15 @@ func (self *TDataSet) SetFields(fields ...string) {
 }
 
 func (self *TDataSet) AddField(name string) int {
+	if self.IsFrozen() {
+		return -1
+	}
+	if self.parent != nil {
+		idx := self.parent.AddField(name)
+		self.syncView()
+		return idx
+	}
+
 	self.Lock()
 	defer self.Unlock()
 
@@ -447,9 +753,17 @@ func (self *TDataSet) AddField(name string) int {
 }
 
 // set the field as key
+// 由 DataProvider 提供记录时只设置 KeyField,不建立索引
 func (self *TDataSet) SetKeyField(keyField string) bool {
+	if self.source != nil {
+		self.KeyField = keyField
+		return keyField != ""
+	}
 	// # 非空或非Count查询时提供多行索引
-	if self.Count() == 0 || (self.Record().GetByField(keyField) == nil && len(self.Record().Fields()) == 1 && self.Record().FieldByName("count") != nil) {
+	if len(self.Data) == 0 {
+		return false
+	}
+	if first := self.Data[0]; first != nil && first.GetByField(keyField) == nil && len(first.Fields()) == 1 && first.FieldByName("count") != nil {
 		return false
 	}
 
@@ -467,8 +781,8 @@ func (self *TDataSet) SetKeyField(keyField string) bool {
 	// #赋值
 	for _, rec := range self.Data {
 		value := rec.GetByField(keyField)
-		if value != nil && !utils.IsBlank(value) {
-			self.RecordsIndex[value] = rec //保存ID 对应的 Record
+		if value != nil && !isBlank(value) {
+			self.RecordsIndex[mapKey(value)] = rec //保存ID 对应的 Record
 		}
 	}
 	self.Unlock()
@@ -483,22 +797,27 @@ func (self *TDataSet) IsClassic() bool {
 
 // func (self *TDataSet) Fields() map[string]*TFieldSet {
 func (self *TDataSet) Fields() []string {
-	return self.fields
+	return self.owner().fields
 }
 
 func (self *TDataSet) HasField(name string) bool {
 	if self == nil {
 		return false
 	}
-	_, has := self.fieldsIndex[name]
-	return has
+	if _, has := self.owner().fieldsIndex[name]; has {
+		return true
+	}
+	return self.IsCalcField(name)
 }
 
 // return all the keys value
 // 返回所有记录的非空非Nil主键值
 // 优化：避免为获取 keys 而构建完整索引，直接遍历 dataset
 func (self *TDataSet) Keys(fieldName ...string) (res []interface{}) {
-	if self.Count() == 0 {
+	if self.source != nil {
+		return self.keysPaged(fieldName...)
+	}
+	if len(self.Data) == 0 {
 		return nil
 	}
 
@@ -506,12 +825,12 @@ func (self *TDataSet) Keys(fieldName ...string) (res []interface{}) {
 	// 如果指定字段名，按记录顺序返回所有值（包含重复）
 	if len(fieldName) > 0 {
 		keyField = fieldName[0]
-		ids := make([]interface{}, 0, self.Count())
+		ids := make([]interface{}, 0, len(self.Data))
 
 		self.RLock()
 		for _, rec := range self.Data {
 			value := rec.GetByField(keyField)
-			if value != nil && !utils.IsBlank(value) {
+			if value != nil && !isBlank(value) {
 				ids = append(ids, value)
 			}
 		}
@@ -527,7 +846,7 @@ func (self *TDataSet) Keys(fieldName ...string) (res []interface{}) {
 
 	if self.KeyField == keyField {
 		self.RLock()
-		if self.Count() > 0 && (self.RecordsIndex == nil || len(self.RecordsIndex) == 0) {
+		if len(self.Data) > 0 && (self.RecordsIndex == nil || len(self.RecordsIndex) == 0) {
 			self.RUnlock()
 			self.SetKeyField(self.KeyField)
 			self.RLock()
@@ -541,8 +860,8 @@ func (self *TDataSet) Keys(fieldName ...string) (res []interface{}) {
 	var idRes []interface{}
 	if self.RecordsIndex != nil {
 		idRes = make([]interface{}, 0, len(self.RecordsIndex))
-		for id := range self.RecordsIndex {
-			idRes = append(idRes, id)
+		for _, rec := range self.RecordsIndex {
+			idRes = append(idRes, rec.GetByField(keyField))
 		}
 	}
 	return idRes
@@ -3,6 +3,7 @@ package dataset
 import (
 	"fmt"
 	"math/rand"
+	"runtime"
 	"strconv"
 	"sync"
 	"testing"
@@ -60,6 +61,18 @@ func BenchmarkAppendRecordPreallocated(b *testing.B) {
 	}
 }
 
+// BenchmarkCreateAndClearDataset 反复创建并清空记录
+func BenchmarkCreateAndClearDataset(b *testing.B) {
+	b.ReportAllocs()
+	ds := NewDataSet()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			ds.NewRecord(map[string]any{"id": j, "name": "abc"})
+		}
+		ds.Clear()
+	}
+}
+
 func BenchmarkAppendRecordConcurrent_WithMutex(b *testing.B) {
 	b.ReportAllocs()
 	ds := NewDataSet()
@@ -141,3 +154,89 @@ func BenchmarkRandomAccessParallel(b *testing.B) {
 		}
 	})
 }
+
+// makeAnalyticDataset 数值为主的宽表,用于比较行式与列式存储
+func makeAnalyticDataset(n int, opts ...Option) *TDataSet {
+	ds := NewDataSet(opts...)
+	ds.SetFields("id", "qty", "amount", "rate", "name", "active", "created")
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		ds.NewRecord(map[string]any{
+			"id":      i,
+			"qty":     i % 1000,
+			"amount":  float64(i) * 1.25,
+			"rate":    float64(i%100) / 100,
+			"name":    "item-" + strconv.Itoa(i%50),
+			"active":  i%2 == 0,
+			"created": base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return ds
+}
+
+// benchmarkStorageMemory 报告构建后每行常驻的堆内存(heap-B/row)
+func benchmarkStorageMemory(b *testing.B, opts ...Option) {
+	const rows = 10000
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		ds := makeAnalyticDataset(rows, opts...)
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc))/rows, "heap-B/row")
+		runtime.KeepAlive(ds)
+	}
+}
+
+func BenchmarkStorageMemoryRow(b *testing.B) {
+	benchmarkStorageMemory(b)
+}
+
+func BenchmarkStorageMemoryColumnar(b *testing.B) {
+	benchmarkStorageMemory(b, WithColumnar())
+}
+
+func benchmarkStorageScan(b *testing.B, opts ...Option) {
+	ds := makeAnalyticDataset(100000, opts...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = ds.Sum("amount")
+		_, _ = ds.Max("qty")
+	}
+}
+
+func BenchmarkStorageScanRow(b *testing.B) {
+	benchmarkStorageScan(b)
+}
+
+func BenchmarkStorageScanColumnar(b *testing.B) {
+	benchmarkStorageScan(b, WithColumnar())
+}
+
+func BenchmarkLoadNewRecord(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ds := NewDataSet()
+		for j := 0; j < 10000; j++ {
+			ds.NewRecord(map[string]any{"id": j, "name": "abc", "value": j * 10})
+		}
+	}
+}
+
+func BenchmarkLoadRows(b *testing.B) {
+	fields := []string{"id", "name", "value"}
+	rows := make([][]any, 10000)
+	for j := range rows {
+		rows[j] = []any{j, "abc", j * 10}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ds := NewDataSet()
+		ds.LoadRows(fields, rows)
+	}
+}
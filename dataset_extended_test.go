@@ -352,24 +352,95 @@ func TestDatasetComplex(t *testing.T) {
 	})
 }
 
-func TestRecordSetPoolAndLifecycle(t *testing.T) {
-	t.Run("Reset and Reuse Memory Alignment", func(t *testing.T) {
+func TestRecordSetLifecycle(t *testing.T) {
+	t.Run("Free Detached Record", func(t *testing.T) {
 		rec := NewRecordSet(map[string]any{"a": 1, "b": 2})
 		if rec.GetByField("a") != 1 {
 			t.Errorf("Expected 1")
 		}
 
-		// Free returns it to pool and calls Reset
 		rec.Free()
-
-		// Get a new one from pool
+		if rec.GetByField("a") != nil {
+			t.Errorf("Free should clear a detached record")
+		}
 		rec2 := NewRecordSet(map[string]any{"x": 9, "y": 8, "z": 7})
-		if rec2.GetByField("a") != nil {
-			t.Errorf("Leaked field 'a' across pool reuse")
+		if rec2 == rec || rec2.GetByField("a") != nil || rec2.GetByField("x") != 9 {
+			t.Errorf("Expected a new record")
+		}
+	})
+
+	t.Run("Shared Records Survive Clear", func(t *testing.T) {
+		ds := NewDataSet(WithData(
+			map[string]any{"id": 1, "cat": "A"},
+			map[string]any{"id": 2, "cat": "B"},
+		))
+		rec := ds.Data[0]
+		filtered := ds.Filter("cat", []any{"A"})
+
+		ds.Clear()
+		if filtered.Data[0] != rec || filtered.Data[0].GetByField("id") != 1 {
+			t.Fatalf("Record still referenced by filtered dataset was reset")
+		}
+		rec.Free()
+		if rec.GetByField("id") != 1 {
+			t.Fatalf("Free must not reset a record that belongs to a dataset")
+		}
+	})
+
+	t.Run("Borrowed Record Not Reused", func(t *testing.T) {
+		for _, columnar := range []bool{false, true} {
+			var opts []Option
+			if columnar {
+				opts = append(opts, WithColumnar())
+			}
+			ds := NewDataSet(opts...)
+			ds.NewRecord(map[string]any{"id": 1, "name": "alice"})
+			ds.First()
+			rec := ds.Record() // 借用
+			ds.Clear()
+
+			for i := 0; i < 100; i++ {
+				ds.NewRecord(map[string]any{"id": i, "name": "bob"})
+			}
+			for _, r := range ds.Data {
+				if r == rec {
+					t.Fatal("Released record must not be handed out again")
+				}
+			}
+			if rec.GetByField("name") != "alice" {
+				t.Fatalf("Borrowed record observed another record's values (columnar=%v)", columnar)
+			}
+		}
+	})
+
+	t.Run("Creator Keeps Record", func(t *testing.T) {
+		ds := NewDataSet()
+		rec := NewRecordSet(map[string]any{"id": 1})
+		ds.AppendRecord(rec)
+
+		ds.Delete(0)
+		if rec.GetByField("id") != 1 {
+			t.Fatalf("Delete must not reset a record still held by its creator")
+		}
+	})
+
+	t.Run("Delete Reindexes", func(t *testing.T) {
+		ds := NewDataSet(WithData(
+			map[string]any{"id": 1},
+			map[string]any{"id": 2},
+			map[string]any{"id": 3},
+		))
+		ds.SetKeyField("id")
+		ds.Delete(0)
+
+		if ds.Data[0].index != 0 || ds.Data[1].index != 1 {
+			t.Fatalf("Expected records reindexed after Delete")
+		}
+		if ds.RecordByKey(1) != nil {
+			t.Fatalf("RecordByKey should not return a deleted record")
 		}
-		if rec2.GetByField("x") != 9 {
-			t.Errorf("Expected 9 from new memory")
+		if ds.RecordByKey(3) == nil {
+			t.Fatalf("RecordByKey(3) should still be found")
 		}
-		rec2.Free()
 	})
 }
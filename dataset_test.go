@@ -1,9 +1,13 @@
 package dataset
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"testing"
 	"time"
+
+	"github.com/volts-dev/utils"
 )
 
 type (
@@ -38,6 +42,116 @@ var ds = NewDataSet(
 		}),
 )
 
+// testFixture 各测试共用的数据集,rows 为初始记录,setup 在记录加入后补充字段、规则等
+type testFixture struct {
+	rows  []map[string]any
+	setup func(ds *TDataSet)
+}
+
+var (
+	viewFixture = testFixture{rows: []map[string]any{
+		{"id": 1, "cat": "A", "tags": []any{"x"}},
+		{"id": 2, "cat": "B", "tags": []any{"y"}},
+		{"id": 3, "cat": "A", "tags": []any{"z"}},
+	}}
+
+	// amount 含 0、nil、空串与无法转换为数字的值
+	aggFixture = testFixture{rows: []map[string]any{
+		{"id": 1, "amount": 10},
+		{"id": 2, "amount": int64(0)},
+		{"id": 3, "amount": 2.5},
+		{"id": 4, "amount": "7.50"},
+		{"id": 5, "amount": nil},
+		{"id": 6, "amount": ""},
+		{"id": 7, "amount": "n/a"},
+	}}
+
+	// amount 为计算字段 qty * price
+	calcFixture = testFixture{
+		rows: []map[string]any{
+			{"id": 1, "qty": 2, "price": 10.0},
+			{"id": 2, "qty": 5, "price": 1.5},
+			{"id": 3, "qty": 1, "price": 4.0},
+		},
+		setup: func(ds *TDataSet) {
+			err := ds.AddCalcField("amount", []string{"qty", "price"}, func(rec *TRecordSet) any {
+				return float64(rec.FieldByName("qty").AsInteger()) * rec.FieldByName("price").AsFloat()
+			})
+			if err != nil {
+				panic(err)
+			}
+		},
+	}
+
+	exprFixture = testFixture{rows: []map[string]any{
+		{"id": 1, "amount": 1500, "name": "Acme Ltd", "partner_id": map[string]any{"country": "CN", "name": "Acme"}, "date": "2026-03-01"},
+		{"id": 2, "amount": 800, "name": "Beta", "partner_id": map[string]any{"country": "CN"}, "date": "2026-03-20"},
+		{"id": 3, "amount": 2000, "name": "Gamma", "partner_id": map[string]any{"country": "US"}, "date": "2026-01-15"},
+		{"id": 4, "amount": 3000, "name": "Delta", "date": "2026-02-10"},
+	}}
+
+	// id 为 1..10 的主键
+	pageFixture = testFixture{
+		rows: func() (rows []map[string]any) {
+			for i := 1; i <= 10; i++ {
+				rows = append(rows, map[string]any{"id": i, "score": (i * 7) % 4})
+			}
+			return rows
+		}(),
+		setup: func(ds *TDataSet) { ds.SetKeyField("id") },
+	}
+
+	// partner_id 以字符串格式化作为透视的行键
+	invoiceFixture = testFixture{
+		rows: []map[string]any{
+			{"partner_id": int64(1), "month": "2026-02", "amount": 10.0},
+			{"partner_id": int64(2), "month": "2026-01", "amount": 5.0},
+			{"partner_id": int64(1), "month": "2026-01", "amount": 7.0},
+			{"partner_id": int64(1), "month": "2026-02", "amount": 3.0},
+			{"partner_id": int64(2), "month": nil, "amount": 100.0},
+		},
+		setup: func(ds *TDataSet) {
+			ds.SetFieldFormater("partner_id", func(v any) any { return utils.ToString(v) })
+		},
+	}
+
+	sqlInvoiceFixture = testFixture{rows: []map[string]any{
+		{"id": 1, "partner_id": 10, "state": "posted", "amount": 100},
+		{"id": 2, "partner_id": 20, "state": "draft", "amount": 50},
+		{"id": 3, "partner_id": 10, "state": "posted", "amount": 300},
+		{"id": 4, "partner_id": 30, "state": "posted", "amount": 200},
+		{"id": 5, "partner_id": 20, "state": "posted", "amount": nil},
+	}}
+
+	// 无记录,只有字段与校验规则
+	orderRulesFixture = testFixture{setup: func(ds *TDataSet) {
+		ds.SetFields("name", "qty", "state", "code", "date_start", "date_end")
+		ds.AddRule("name", Required(), Length(2, 10))
+		ds.AddRule("qty", Min(1), Max(100))
+		ds.AddRule("state", OneOf("draft", "done"))
+		ds.AddRule("code", Match(`^[A-Z]{3}$`), func(v any) error {
+			if v == "XXX" {
+				return errors.New("is reserved")
+			}
+			return nil
+		})
+		ds.AddRecordRule("date_end", CompareFields("date_end", ">=", "date_start"))
+	}}
+)
+
+// newFixture 以 fixture 记录的副本创建数据集,各测试之间互不影响
+func newFixture(fixture testFixture, opts ...Option) *TDataSet {
+	rows := make([]map[string]any, len(fixture.rows))
+	for i, row := range fixture.rows {
+		rows[i] = copyMap(maps.Clone(row))
+	}
+	ds := NewDataSet(append([]Option{WithData(rows...)}, opts...)...)
+	if fixture.setup != nil {
+		fixture.setup(ds)
+	}
+	return ds
+}
+
 func TestDatasetKeys(t *testing.T) {
 	fmt.Println(ds.Keys("id")...)
 }
@@ -0,0 +1,335 @@
+package dataset
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type (
+	// TDecimal 定点十进制数,值为 value × 10^-scale。用于金额等需要精确运算的字段,
+	// 避免 float64 累加误差。零值表示 0。TDecimal 不可变,运算均返回新值。
+	TDecimal struct {
+		value *big.Int
+		scale int32
+	}
+)
+
+// DecimalDivisionPrecision 除法结果保留的最大小数位数
+var DecimalDivisionPrecision int32 = 16
+
+var bigTen = big.NewInt(10)
+
+// decimalMaxExponent 科学计数法指数绝对值的上限,避免解析时按指数分配超大整数
+const decimalMaxExponent = 1000
+
+// ParseDecimal 解析十进制字符串,支持符号与科学计数法,如 "12.50"、"-3"、"1.5e3"。
+// 小数位数(scale)按字符串保留,"12.50" 的 scale 为 2。指数绝对值超过 1000 时返回错误。
+func ParseDecimal(s string) (TDecimal, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return TDecimal{}, fmt.Errorf("can not parse empty string as decimal")
+	}
+
+	var exp int64
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(str[i+1:], 10, 32)
+		if err != nil {
+			return TDecimal{}, fmt.Errorf("can not parse %q as decimal", s)
+		}
+		if e > decimalMaxExponent || e < -decimalMaxExponent {
+			return TDecimal{}, fmt.Errorf("can not parse %q as decimal: exponent out of range", s)
+		}
+		exp = e
+		str = str[:i]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+
+	digits := intPart + fracPart
+	sign := ""
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		sign, digits = digits[:1], digits[1:]
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return TDecimal{}, fmt.Errorf("can not parse %q as decimal", s)
+	}
+
+	value, _ := new(big.Int).SetString(sign+digits, 10)
+	scale := int64(len(fracPart)) - exp
+	if scale < 0 {
+		value.Mul(value, pow10(int32(-scale)))
+		scale = 0
+	}
+	if scale > math.MaxInt32 {
+		return TDecimal{}, fmt.Errorf("can not parse %q as decimal: exponent out of range", s)
+	}
+
+	return TDecimal{value: value, scale: int32(scale)}, nil
+}
+
+// MustDecimal 同 NewDecimal,转换失败时 panic
+func MustDecimal(v any) TDecimal {
+	d, err := NewDecimal(v)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDecimal 将整数、浮点数、十进制字符串/[]byte、json.Number、*big.Int 等转换为 TDecimal。
+// 浮点数按其最短十进制表示转换,0.1 即为精确的 0.1。
+func NewDecimal(v any) (TDecimal, error) {
+	switch x := v.(type) {
+	case TDecimal:
+		return x, nil
+	case *TDecimal:
+		if x != nil {
+			return *x, nil
+		}
+	case string:
+		return ParseDecimal(x)
+	case []byte:
+		return ParseDecimal(string(x))
+	case json.Number:
+		return ParseDecimal(string(x))
+	case *big.Int:
+		if x != nil {
+			return TDecimal{value: new(big.Int).Set(x)}, nil
+		}
+	case float32:
+		return decimalFromFloat(float64(x), 32)
+	case float64:
+		return decimalFromFloat(x, 64)
+	case uint:
+		return TDecimal{value: new(big.Int).SetUint64(uint64(x))}, nil
+	case uint64:
+		return TDecimal{value: new(big.Int).SetUint64(x)}, nil
+	default:
+		if n, ok := toInteger(v); ok {
+			return TDecimal{value: big.NewInt(n)}, nil
+		}
+		if n, ok := toNumber(v); ok {
+			return TDecimal{value: big.NewInt(int64(n))}, nil
+		}
+	}
+
+	return TDecimal{}, fmt.Errorf("unable to cast %#v of type %T to decimal", v, v)
+}
+
+func decimalFromFloat(f float64, bitSize int) (TDecimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return TDecimal{}, fmt.Errorf("unable to cast %v to decimal", f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, bitSize))
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (self TDecimal) int() *big.Int {
+	if self.value == nil {
+		return new(big.Int)
+	}
+	return self.value
+}
+
+// rescale 放大到更大的 scale,不丢失精度
+func (self TDecimal) rescale(scale int32) *big.Int {
+	if scale <= self.scale {
+		return self.int()
+	}
+	return new(big.Int).Mul(self.int(), pow10(scale-self.scale))
+}
+
+func (self TDecimal) Scale() int32 {
+	return self.scale
+}
+
+func (self TDecimal) Sign() int {
+	return self.int().Sign()
+}
+
+func (self TDecimal) IsZero() bool {
+	return self.Sign() == 0
+}
+
+func (self TDecimal) Add(d2 TDecimal) TDecimal {
+	scale := max(self.scale, d2.scale)
+	return TDecimal{value: new(big.Int).Add(self.rescale(scale), d2.rescale(scale)), scale: scale}
+}
+
+func (self TDecimal) Sub(d2 TDecimal) TDecimal {
+	scale := max(self.scale, d2.scale)
+	return TDecimal{value: new(big.Int).Sub(self.rescale(scale), d2.rescale(scale)), scale: scale}
+}
+
+func (self TDecimal) Mul(d2 TDecimal) TDecimal {
+	return TDecimal{value: new(big.Int).Mul(self.int(), d2.int()), scale: self.scale + d2.scale}
+}
+
+// Div 除法,结果四舍五入到 DecimalDivisionPrecision 位小数后去掉多余的尾零,
+// 但至少保留两个操作数中较大的 scale。除数为 0 时 panic(同 math/big)。
+func (self TDecimal) Div(d2 TDecimal) TDecimal {
+	if d2.IsZero() {
+		panic("dataset: decimal division by zero")
+	}
+
+	quo := new(big.Rat).Quo(self.Rat(), d2.Rat())
+	res := roundRat(quo, DecimalDivisionPrecision)
+	return res.trim(max(self.scale, d2.scale))
+}
+
+func (self TDecimal) Neg() TDecimal {
+	return TDecimal{value: new(big.Int).Neg(self.int()), scale: self.scale}
+}
+
+func (self TDecimal) Abs() TDecimal {
+	return TDecimal{value: new(big.Int).Abs(self.int()), scale: self.scale}
+}
+
+// Round 四舍五入(远离零)到 scale 位小数
+func (self TDecimal) Round(scale int32) TDecimal {
+	if scale >= self.scale {
+		return TDecimal{value: self.rescale(scale), scale: scale}
+	}
+	return roundRat(self.Rat(), scale)
+}
+
+// trim 去掉尾零,scale 不低于 minScale
+func (self TDecimal) trim(minScale int32) TDecimal {
+	value, scale := new(big.Int).Set(self.int()), self.scale
+	rem := new(big.Int)
+	for scale > minScale {
+		q, r := new(big.Int).QuoRem(value, bigTen, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		value, scale = q, scale-1
+	}
+	return TDecimal{value: value, scale: scale}
+}
+
+func roundRat(r *big.Rat, scale int32) TDecimal {
+	num := new(big.Int).Mul(r.Num(), pow10(scale))
+	den := r.Denom()
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	// |rem| * 2 >= den 时远离零进位
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return TDecimal{value: quo, scale: scale}
+}
+
+// Cmp 精确比较,忽略 scale 差异("1.50" 与 "1.5" 相等)
+func (self TDecimal) Cmp(d2 TDecimal) int {
+	scale := max(self.scale, d2.scale)
+	return self.rescale(scale).Cmp(d2.rescale(scale))
+}
+
+func (self TDecimal) Equal(d2 TDecimal) bool {
+	return self.Cmp(d2) == 0
+}
+
+func (self TDecimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(self.int(), pow10(self.scale))
+}
+
+// Float64 转为最接近的 float64,可能丢失精度
+func (self TDecimal) Float64() float64 {
+	f, _ := self.Rat().Float64()
+	return f
+}
+
+// Int64 截断小数部分后的整数值
+func (self TDecimal) Int64() int64 {
+	return new(big.Int).Quo(self.int(), pow10(self.scale)).Int64()
+}
+
+// mapKey 值作为 map 键时的规范形式。TDecimal 含 *big.Int,相等的值作为键并不相等,
+// 转为去掉尾零的字符串(如 1.50 与 1.5 均为 "1.5"),其余值不变
+func mapKey(v any) any {
+	if d, ok := v.(TDecimal); ok {
+		return d.trim(0).String()
+	}
+	return v
+}
+
+// String 按 scale 输出定点表示,如 "12.50"
+func (self TDecimal) String() string {
+	str := new(big.Int).Abs(self.int()).String()
+	if self.scale > 0 {
+		if pad := int(self.scale) - len(str) + 1; pad > 0 {
+			str = strings.Repeat("0", pad) + str
+		}
+		str = str[:len(str)-int(self.scale)] + "." + str[len(str)-int(self.scale):]
+	}
+	if self.Sign() < 0 {
+		str = "-" + str
+	}
+	return str
+}
+
+// MarshalJSON 输出为 JSON 数字字面量,不经过 float64,精度不丢失。
+// 需要输出字符串(前端 JS 精度)时可给字段设置 DecimalToString 格式化器。
+func (self TDecimal) MarshalJSON() ([]byte, error) {
+	return []byte(self.String()), nil
+}
+
+// UnmarshalJSON 同时接受数字与字符串形式
+func (self *TDecimal) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	if str == "null" {
+		*self = TDecimal{}
+		return nil
+	}
+
+	d, err := ParseDecimal(str)
+	if err != nil {
+		return err
+	}
+	*self = d
+	return nil
+}
+
+// Scan implements sql.Scanner
+func (self *TDecimal) Scan(src any) error {
+	if src == nil {
+		*self = TDecimal{}
+		return nil
+	}
+
+	d, err := NewDecimal(src)
+	if err != nil {
+		return err
+	}
+	*self = d
+	return nil
+}
+
+// Value implements driver.Valuer,以字符串提交给数据库驱动
+func (self TDecimal) Value() (driver.Value, error) {
+	return self.String(), nil
+}
+
+// DecimalToString 字段格式化器:AsMap/AsJson 输出时把 TDecimal 转为字符串,
+// 用法 ds.SetFieldFormater("amount", DecimalToString)
+func DecimalToString(v any) any {
+	if d, ok := v.(TDecimal); ok {
+		return d.String()
+	}
+	return v
+}
@@ -0,0 +1,172 @@
+package dataset
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecimalParse(t *testing.T) {
+	cases := map[string]string{
+		"12.50":   "12.50",
+		"-0.05":   "-0.05",
+		"+3":      "3",
+		"1.5e3":   "1500",
+		"1.25e-1": "0.125",
+		" 7 ":     "7",
+	}
+	for in, want := range cases {
+		d, err := ParseDecimal(in)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", in, err)
+		}
+		if d.String() != want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", in, d, want)
+		}
+	}
+
+	if d, err := ParseDecimal("1e1000"); err != nil || len(d.String()) != 1001 {
+		t.Fatalf("exponent at the limit should parse: %v", err)
+	}
+	for _, in := range []string{"", "abc", "1.2.3", "--1", "1e", "1e2000000000", "1e-2000000000", "1e1001"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) expected error", in)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := MustDecimal("0.1")
+	b := MustDecimal(0.2)
+	if sum := a.Add(b); sum.String() != "0.3" || !sum.Equal(MustDecimal("0.30")) {
+		t.Errorf("0.1 + 0.2 = %s", sum)
+	}
+	if v := MustDecimal("1.10").Mul(MustDecimal(3)).String(); v != "3.30" {
+		t.Errorf("1.10 * 3 = %s", v)
+	}
+	if v := MustDecimal(10).Div(MustDecimal(4)).String(); v != "2.5" {
+		t.Errorf("10 / 4 = %s", v)
+	}
+	if v := MustDecimal(1).Div(MustDecimal(3)).String(); v != "0.3333333333333333" {
+		t.Errorf("1 / 3 = %s", v)
+	}
+	if v := MustDecimal("2.345").Round(2).String(); v != "2.35" {
+		t.Errorf("Round(2.345, 2) = %s", v)
+	}
+	if v := MustDecimal("-2.345").Round(2).String(); v != "-2.35" {
+		t.Errorf("Round(-2.345, 2) = %s", v)
+	}
+	if MustDecimal("1.5").Cmp(MustDecimal("1.50")) != 0 {
+		t.Error("1.5 should equal 1.50")
+	}
+	var zero TDecimal
+	if zero.String() != "0" || !zero.Add(MustDecimal("1.0")).Equal(MustDecimal(1)) {
+		t.Error("zero value should behave as 0")
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	d := MustDecimal("12345678901234567890.12")
+	js, err := json.Marshal(map[string]any{"amount": d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(js) != `{"amount":12345678901234567890.12}` {
+		t.Fatalf("unexpected json %s", js)
+	}
+
+	var out struct {
+		A TDecimal `json:"a"`
+		B TDecimal `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a":"1.05","b":2.10}`), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.A.String() != "1.05" || out.B.String() != "2.10" {
+		t.Fatalf("unexpected decoded values %s %s", out.A, out.B)
+	}
+}
+
+func TestDatasetDecimalField(t *testing.T) {
+	ds := NewDataSet(
+		WithData(
+			map[string]any{"id": 1, "amount": []byte("0.10")},
+			map[string]any{"id": 2, "amount": "0.20"},
+		),
+		WithFieldKind("amount", KindDecimal),
+	)
+	ds.NewRecord(map[string]any{"id": 3, "amount": 0.7})
+	ds.NewRecord(map[string]any{"id": 4, "amount": ""})
+
+	if _, ok := ds.Data[0].GetByField("amount").(TDecimal); !ok {
+		t.Fatalf("expected TDecimal, got %T", ds.Data[0].GetByField("amount"))
+	}
+	if ds.Data[3].GetByField("amount") != nil {
+		t.Fatalf("blank string should be stored as nil, got %v", ds.Data[3].GetByField("amount"))
+	}
+	if v := ds.SumDecimal("amount"); v.String() != "1.00" {
+		t.Errorf("SumDecimal expected 1.00, got %s", v)
+	}
+	if v := ds.Sum("amount"); v != 1 {
+		t.Errorf("Sum expected exactly 1, got %v", v)
+	}
+	if v, _ := ds.MaxDecimal("amount"); v.String() != "0.7" {
+		t.Errorf("MaxDecimal expected 0.7, got %s", v)
+	}
+
+	ds.First()
+	if v := ds.FieldByName("amount").AsDecimal(); v.String() != "0.10" {
+		t.Errorf("AsDecimal expected 0.10, got %s", v)
+	}
+
+	if ds.Data[0].SetByField("amount", "oops") {
+		t.Error("SetByField should reject non-decimal value")
+	}
+	if !ds.Data[0].SetByField("amount", "5") {
+		t.Error("SetByField should accept decimal string")
+	}
+
+	ds.SortBy("amount desc")
+	if ds.Data[0].GetByField("id") != 1 {
+		t.Errorf("expected id 1 first after sort, got %v", ds.Data[0].GetByField("id"))
+	}
+
+	ds.SetFieldFormater("amount", DecimalToString)
+	if js, _ := ds.Data[0].AsJson(); js != `{"amount":"5","id":1}` {
+		t.Errorf("unexpected json %s", js)
+	}
+
+	if err := NewDataSet(WithData(map[string]any{"amount": "x"})).SetFieldKind("amount", KindDecimal); err == nil {
+		t.Error("SetFieldKind should fail on non-decimal data")
+	}
+}
+
+func TestDecimalMapKeys(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"id": MustDecimal("1.50"), "price": MustDecimal("1.50")},
+		map[string]any{"id": MustDecimal("2"), "price": MustDecimal("1.5")},
+		map[string]any{"id": MustDecimal("3.0"), "price": MustDecimal("2.00")},
+	))
+
+	groups := ds.GroupBy("price")
+	if len(groups) != 2 || groups["1.5"].Count() != 2 || groups["2"].Count() != 1 {
+		t.Fatalf("equal decimals should share a group, got %v", groups)
+	}
+
+	ds.SetKeyField("id")
+	if rec := ds.RecordByKey(MustDecimal("1.5")); rec == nil || rec.GetByField("price").(TDecimal).String() != "1.50" {
+		t.Fatal("RecordByKey should find decimal keys regardless of scale")
+	}
+	if ds.RecordByKey(MustDecimal("3")) == nil || ds.RecordByKey(MustDecimal("4")) != nil {
+		t.Fatal("unexpected RecordByKey result")
+	}
+	for _, key := range ds.Keys() {
+		if _, ok := key.(TDecimal); !ok {
+			t.Fatalf("Keys should return the field values, got %T", key)
+		}
+	}
+
+	snap := ds.Snapshot()
+	if snap.RecordByKey(MustDecimal("2.000")) == nil {
+		t.Fatal("snapshot RecordByKey should find decimal keys regardless of scale")
+	}
+}
@@ -0,0 +1,230 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// TSequence 编号生成器,可在多个数据集/goroutine 间共享
+	TSequence struct {
+		mu      sync.Mutex
+		next    int64
+		step    int64
+		prefix  string // 为空时生成 int64,否则生成带前缀的字符串编号
+		padding int
+		now     func() time.Time
+	}
+
+	// tSequenceSlot 待取号的字段:校验时以预览的编号填充,记录通过校验后才取号
+	tSequenceSlot struct {
+		idx   int
+		field string
+		seq   *TSequence
+	}
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// NewCounter 内存计数器,从 start 开始依次生成 int64 编号
+func NewCounter(start int64) *TSequence {
+	return &TSequence{next: start, step: 1}
+}
+
+// NewPatternSequence 带前缀与补零的字符串编号,如 NewPatternSequence("INV/{YYYY}/", 4, 1)
+// 依次生成 INV/2026/0001、INV/2026/0002……
+// 前缀中的 {YYYY}、{YY}、{MM}、{DD} 在生成时替换为当前日期。
+func NewPatternSequence(prefix string, padding int, start int64) *TSequence {
+	return &TSequence{next: start, step: 1, prefix: prefix, padding: padding, now: time.Now}
+}
+
+// NewVirtualSequence 未保存记录的虚拟 id:-1、-2……,不会与数据库分配的正数 id 冲突
+func NewVirtualSequence() *TSequence {
+	return &TSequence{next: -1, step: -1}
+}
+
+// Next 生成下一个编号
+func (self *TSequence) Next() any {
+	self.mu.Lock()
+	n := self.next
+	self.next += self.step
+	self.mu.Unlock()
+	return self.format(n)
+}
+
+// peek 下一个编号,不消耗
+func (self *TSequence) peek() any {
+	self.mu.Lock()
+	n := self.next
+	self.mu.Unlock()
+	return self.format(n)
+}
+
+func (self *TSequence) format(n int64) any {
+	if self.prefix == "" && self.padding == 0 {
+		return n
+	}
+
+	prefix := self.prefix
+	if strings.Contains(prefix, "{") {
+		now := self.now()
+		prefix = strings.NewReplacer(
+			"{YYYY}", now.Format("2006"),
+			"{YY}", now.Format("06"),
+			"{MM}", now.Format("01"),
+			"{DD}", now.Format("02"),
+		).Replace(prefix)
+	}
+	return fmt.Sprintf("%s%0*d", prefix, self.padding, n)
+}
+
+// Reset 设置下一个编号的数值
+func (self *TSequence) Reset(next int64) {
+	self.mu.Lock()
+	self.next = next
+	self.mu.Unlock()
+}
+
+// WithDefault 设置字段默认值,见 TDataSet.SetDefault
+func WithDefault(field string, value any) Option {
+	return func(cfg *Config) {
+		cfg.dataset.SetDefault(field, value)
+	}
+}
+
+// WithKeySequence 设置主键编号生成器,见 TDataSet.SetKeySequence
+func WithKeySequence(seq *TSequence) Option {
+	return func(cfg *Config) {
+		cfg.dataset.SetKeySequence(seq)
+	}
+}
+
+// SetDefault 设置字段默认值,AppendRecord/NewRecord 插入记录时为 NULL(nil/空白)的字段填充默认值。
+// value 可以是:
+//   - 静态值,map/slice 等复合值每条记录各自复制一份;
+//   - 无参数、单返回值的函数,如 func() any、time.Now,每条记录调用一次;
+//   - 以 context.Context 为唯一参数、单返回值的函数,如 func(ctx context.Context) any,
+//     ctx 为 AppendRecordContext/NewRecordContext 传入的上下文(可携带当前用户等请求信息),
+//     其他插入方法传入 context.Background();
+//   - *TSequence,每条通过校验的记录取一个编号,被校验规则拒绝的记录不消耗编号。
+//
+// value 为 nil 时移除默认值,参数或返回值个数不符的函数被忽略。字段不存在时在插入时自动新增。
+func (self *TDataSet) SetDefault(field string, value any) {
+	if field == "" || self.IsFrozen() {
+		return
+	}
+
+	root := self.owner()
+	if value == nil {
+		delete(root.defaults, field)
+		return
+	}
+
+	if fn := reflect.ValueOf(value); fn.Kind() == reflect.Func {
+		t := fn.Type()
+		if t.NumOut() != 1 || t.NumIn() > 1 || (t.NumIn() == 1 && t.In(0) != contextType) {
+			return
+		}
+	}
+	if root.defaults == nil {
+		root.defaults = make(map[string]any)
+	}
+	root.defaults[field] = value
+}
+
+// SetKeySequence 设置主键(KeyField,未设置时为 id)编号生成器,
+// 插入主键为 NULL 的记录时自动编号。seq 为 nil 时取消。
+func (self *TDataSet) SetKeySequence(seq *TSequence) {
+	if !self.IsFrozen() {
+		self.owner().keySequence = seq
+	}
+}
+
+// keyFieldName 主键字段名,未设置 KeyField 时为 id
+func (self *TDataSet) keyFieldName() string {
+	if self.KeyField != "" {
+		return self.KeyField
+	}
+	return "id"
+}
+
+// ensureDefaultFields 为默认值与主键编号字段补充字段定义
+func (self *TDataSet) ensureDefaultFields() {
+	for field := range self.defaults {
+		self.AddField(field)
+	}
+	if self.keySequence != nil {
+		self.AddField(self.keyFieldName())
+	}
+}
+
+// applyDefaults 为 values 中为 NULL 的字段填充默认值,返回是否填充了任何值。
+// 编号字段以预览的编号填充并在 slots 中返回,记录通过校验后由 takeSequences 取号。
+func (self *TDataSet) applyDefaults(ctx context.Context, values []any) (applied bool, slots []tSequenceSlot, err error) {
+	fill := func(field string, value any) error {
+		idx, has := self.fieldsIndex[field]
+		if !has || idx >= len(values) || !isNull(values[idx]) {
+			return nil
+		}
+
+		var v any
+		if seq, ok := value.(*TSequence); ok {
+			slots = append(slots, tSequenceSlot{idx: idx, field: field, seq: seq})
+			v = seq.peek()
+		} else {
+			v = defaultValue(ctx, value)
+		}
+		v, err := self.convertValue(field, v)
+		if err != nil {
+			return fmt.Errorf("default of field < %s >: %w", field, err)
+		}
+		values[idx] = v
+		applied = applied || v != nil
+		return nil
+	}
+
+	for field, value := range self.defaults {
+		if err := fill(field, value); err != nil {
+			return false, nil, err
+		}
+	}
+	if self.keySequence != nil {
+		if err := fill(self.keyFieldName(), self.keySequence); err != nil {
+			return false, nil, err
+		}
+	}
+	return applied, slots, nil
+}
+
+// takeSequences 为通过校验的记录取号,替换 applyDefaults 预览的编号
+func (self *TDataSet) takeSequences(values []any, slots []tSequenceSlot) error {
+	for _, slot := range slots {
+		v, err := self.convertValue(slot.field, slot.seq.Next())
+		if err != nil {
+			return fmt.Errorf("default of field < %s >: %w", slot.field, err)
+		}
+		values[slot.idx] = v
+	}
+	return nil
+}
+
+func defaultValue(ctx context.Context, value any) any {
+	switch v := value.(type) {
+	case func() any:
+		return v()
+	case func(context.Context) any:
+		return v(ctx)
+	}
+
+	if fn := reflect.ValueOf(value); fn.Kind() == reflect.Func {
+		if fn.Type().NumIn() == 1 {
+			return fn.Call([]reflect.Value{reflect.ValueOf(&ctx).Elem()})[0].Interface()
+		}
+		return fn.Call(nil)[0].Interface()
+	}
+	return cloneValue(value)
+}
@@ -0,0 +1,114 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDatasetDefaults(t *testing.T) {
+	user := "admin"
+	ds := NewDataSet(
+		WithDefault("state", "draft"),
+		WithDefault("tags", []any{"new"}),
+		WithDefault("create_uid", func() any { return user }),
+		WithDefault("create_date", time.Now),
+		WithKeySequence(NewCounter(100)),
+	)
+	ds.SetDefault("bad", func(int) any { return 0 }) // 签名不符,忽略
+
+	ds.NewRecord(map[string]any{"name": "a"})
+	ds.NewRecord(map[string]any{"name": "b", "state": "done", "id": 7})
+	user = "demo"
+	ds.NewRecord(map[string]any{}) // 仅有默认值的记录同样插入
+
+	if ds.Count() != 3 || ds.HasField("bad") {
+		t.Fatalf("unexpected dataset: count=%d fields=%v", ds.Count(), ds.Fields())
+	}
+
+	a, b, c := ds.Data[0], ds.Data[1], ds.Data[2]
+	if a.GetByField("state") != "draft" || b.GetByField("state") != "done" {
+		t.Fatal("static default should only fill NULL values")
+	}
+	if a.GetByField("id") != int64(100) || b.GetByField("id") != 7 || c.GetByField("id") != int64(101) {
+		t.Fatalf("unexpected ids %v %v %v", a.GetByField("id"), b.GetByField("id"), c.GetByField("id"))
+	}
+	if a.GetByField("create_uid") != "admin" || c.GetByField("create_uid") != "demo" {
+		t.Fatal("function defaults should be evaluated per record")
+	}
+	if _, ok := a.GetByField("create_date").(time.Time); !ok {
+		t.Fatal("time.Now should be usable as a default")
+	}
+
+	a.GetByField("tags").([]any)[0] = "changed"
+	if b.GetByField("tags").([]any)[0] != "new" {
+		t.Fatal("composite defaults must not be shared between records")
+	}
+}
+
+func TestDatasetSequences(t *testing.T) {
+	seq := NewPatternSequence("INV/{YYYY}/", 4, 1)
+	seq.now = func() time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) }
+
+	ds := NewDataSet(WithKeySequence(seq))
+	ds.KeyField = "name"
+	ds.NewRecord(map[string]any{"amount": 10})
+	ds.NewRecord(map[string]any{"amount": 20})
+	if ds.Data[0].GetByField("name") != "INV/2026/0001" || ds.Data[1].GetByField("name") != "INV/2026/0002" {
+		t.Fatalf("unexpected numbers %v %v", ds.Data[0].GetByField("name"), ds.Data[1].GetByField("name"))
+	}
+
+	virtual := NewVirtualSequence()
+	if virtual.Next() != int64(-1) || virtual.Next() != int64(-2) {
+		t.Fatal("virtual ids should count down from -1")
+	}
+	virtual.Reset(-10)
+	if virtual.Next() != int64(-10) {
+		t.Fatal("Reset should set the next value")
+	}
+}
+
+type ctxUserKey struct{}
+
+func TestDatasetContextDefaults(t *testing.T) {
+	ds := NewDataSet(WithDefault("create_uid", func(ctx context.Context) any {
+		if uid, ok := ctx.Value(ctxUserKey{}).(string); ok {
+			return uid
+		}
+		return "system"
+	}))
+
+	ctx := context.WithValue(context.Background(), ctxUserKey{}, "admin")
+	if err := ds.NewRecordContext(ctx, map[string]any{"name": "a"}); err != nil {
+		t.Fatal(err)
+	}
+	ds.NewRecord(map[string]any{"name": "b"})
+	view := ds.Filter("name", []any{"none"})
+	if err := view.AppendRecordContext(ctx, NewRecordSet(map[string]any{"name": "c"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if ds.Data[0].GetByField("create_uid") != "admin" || ds.Data[1].GetByField("create_uid") != "system" ||
+		ds.Data[2].GetByField("create_uid") != "admin" {
+		t.Fatalf("unexpected users %v %v %v", ds.Data[0].GetByField("create_uid"), ds.Data[1].GetByField("create_uid"), ds.Data[2].GetByField("create_uid"))
+	}
+}
+
+func TestDatasetSequenceRejected(t *testing.T) {
+	ds := NewDataSet(WithKeySequence(NewCounter(1)), WithDefault("ref", NewPatternSequence("R", 2, 1)))
+	ds.AddRule("qty", Min(0))
+
+	ds.NewRecord(map[string]any{"qty": 1})
+	if err := ds.NewRecord(map[string]any{"qty": -1}); err == nil {
+		t.Fatal("expected a validation error")
+	}
+	if err := ds.LoadRows([]string{"qty"}, [][]any{{-1}}); err == nil {
+		t.Fatal("expected a validation error")
+	}
+	ds.NewRecord(map[string]any{"qty": 2})
+
+	rec := ds.Data[1]
+	if ds.Count() != 2 || rec.GetByField("id") != int64(2) || rec.GetByField("ref") != "R02" {
+		t.Fatalf("rejected records should not consume numbers, got %v %v", rec.GetByField("id"), rec.GetByField("ref"))
+	}
+}
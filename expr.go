@@ -0,0 +1,176 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+)
+
+type (
+	// TExpr 编译后的表达式,由 Compile 创建,可被多个 goroutine 同时求值
+	TExpr struct {
+		src    string
+		root   tExpr
+		params int
+		fields []string
+	}
+)
+
+// Compile 编译表达式,例如:
+//
+//	amount > 1000 and partner_id.country == "CN"
+//	lower(name) like 'acme%' or state in ('draft', 'sent')
+//	days_between(date, today()) <= 30
+//
+// 语法:
+//   - 字面量:数值、"字符串" 或 '字符串'、true/false/null;占位符 ? 或 $n 对应 Eval/Where 的 args;
+//   - 字段:名称先精确匹配再不区分大小写,带空格等的名称用 `反引号` 引用;
+//     a.b.c 依次取 map(含 map[string]string)、记录或结构体中的键;
+//   - 运算:+ - * / %(两个字符串相加为连接)、= == != <> < <= > >=、and/&&、or/||、not/!、
+//     [not] in (...)、[not] between ... and ...、[not] like('%'/'_' 通配)、is [not] null;
+//   - 函数(不区分大小写):lower upper trim length/len substr replace contains starts_with
+//     ends_with concat coalesce、abs round floor ceil、now today date year month day hour
+//     minute weekday add_days add_months days_between format_date。
+//
+// 关键字不区分大小写。NULL 按 SQL 三值逻辑处理:与 NULL 比较的结果为 NULL,作为条件时视为不成立。
+// 语法错误为 *TSyntaxError,含出错位置。
+func Compile(expr string) (*TExpr, error) {
+	p, err := newParser(expr, true)
+	if err != nil {
+		return nil, withNear(err, expr)
+	}
+	if p.peek().kind == tokEOF {
+		return nil, &TSyntaxError{Pos: 0, Msg: "empty expression"}
+	}
+
+	root, err := p.parseExpr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = p.errorf("unexpected %s", p.describe())
+	}
+	if err == nil {
+		err = walkExpr(root, func(e tExpr) error {
+			if call, ok := e.(*tCall); ok && isAggregate(call.name) {
+				return &TSyntaxError{Pos: call.pos, Msg: fmt.Sprintf("aggregate function %s is not allowed in expressions", call.name)}
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, withNear(err, expr)
+	}
+
+	res := &TExpr{src: expr, root: root, params: p.params}
+	walkExpr(root, func(e tExpr) error {
+		if col, ok := e.(*tColumnRef); ok && !slices.Contains(res.fields, col.name) {
+			res.fields = append(res.fields, col.name)
+		}
+		return nil
+	})
+	return res, nil
+}
+
+// MustCompile 同 Compile,出错时 panic,用于编译常量表达式
+func MustCompile(expr string) *TExpr {
+	res, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// String 表达式源文本
+func (self *TExpr) String() string {
+	return self.src
+}
+
+// Fields 表达式引用的字段名(路径的首段),按出现顺序
+func (self *TExpr) Fields() []string {
+	return slices.Clone(self.fields)
+}
+
+// Eval 以 rec 的字段值求值,args 对应占位符
+func (self *TExpr) Eval(rec *TRecordSet, args ...any) (any, error) {
+	if len(args) < self.params {
+		return nil, fmt.Errorf("expression expects %d arguments, got %d", self.params, len(args))
+	}
+	return self.root.eval(&tEnv{rec: rec, args: args})
+}
+
+// Match 求值为条件,结果为 NULL 时不成立
+func (self *TExpr) Match(rec *TRecordSet, args ...any) (bool, error) {
+	v, err := self.Eval(rec, args...)
+	if err != nil {
+		return false, err
+	}
+	b, known := truth(v)
+	return known && b, nil
+}
+
+// Where 筛选满足表达式的记录,语法见 Compile。
+// 结果同 Filter 默认为引用本数据集记录的视图,WithCopyOnDerive 时为深复制。
+// 表达式引用了不存在的字段或求值出错时返回错误。
+func (self *TDataSet) Where(expr string, args ...any) (*TDataSet, error) {
+	e, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return self.WhereExpr(e, args...)
+}
+
+// WhereExpr 同 Where,使用已编译的表达式
+func (self *TDataSet) WhereExpr(expr *TExpr, args ...any) (*TDataSet, error) {
+	if expr == nil {
+		return nil, fmt.Errorf("expression is nil")
+	}
+	if len(args) < expr.params {
+		return nil, fmt.Errorf("expression expects %d arguments, got %d", expr.params, len(args))
+	}
+
+	env := &tEnv{args: args, binds: make(map[*tColumnRef]tBinding)}
+	if err := bindColumns(self, env.binds, expr.root); err != nil {
+		return nil, err
+	}
+
+	res := self.deriveDataSet()
+	err := self.scan(func(_ int, rec *TRecordSet) error {
+		env.rec = rec
+		ok, err := evalBool(expr.root, env)
+		if ok {
+			res.addDerived(rec)
+		}
+		return err
+	})
+	if err != nil {
+		res.Clear() // 释放已加入的记录
+		return nil, err
+	}
+	return res, nil
+}
+
+// AddExprField 注册以表达式计算的计算字段,例如
+//
+//	ds.AddExprField("amount", "qty * price")
+//
+// 依赖字段为表达式引用的字段,语法见 Compile,其余同 AddCalcField。求值出错时字段值为 nil。
+func (self *TDataSet) AddExprField(name, expr string) error {
+	e, err := Compile(expr)
+	if err != nil {
+		return err
+	}
+	if e.params > 0 {
+		return fmt.Errorf("calc field expressions cannot have parameters")
+	}
+
+	deps := e.Fields()
+	for i, dep := range deps {
+		if field, has := resolveField(self, dep); has {
+			deps[i] = field
+		}
+	}
+	return self.AddCalcField(name, deps, func(rec *TRecordSet) any {
+		v, err := e.Eval(rec)
+		if err != nil {
+			return nil
+		}
+		return v
+	})
+}
@@ -0,0 +1,146 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDatasetWhere(t *testing.T) {
+	ds := newFixture(exprFixture)
+
+	cases := []struct {
+		expr string
+		args []any
+		want string
+	}{
+		{`amount > 1000 and partner_id.country == "CN"`, nil, "[1]"},
+		{`amount > 1000 && !(partner_id.country = 'US')`, nil, "[1]"},
+		{`partner_id.country == "CN" || amount >= 3000`, nil, "[1 2 4]"},
+		{`partner_id.country is null`, nil, "[4]"},
+		{`id in (2, 3) or name like 'A%'`, nil, "[1 2 3]"},
+		{`id not in (1, 2)`, nil, "[3 4]"},
+		{`amount between ? and ?`, []any{800, 1500}, "[1 2]"},
+		{`lower(name) == "beta" or starts_with(name, 'Gam')`, nil, "[2 3]"},
+		{`contains(upper(name), "LT") and length(name) = 8`, nil, "[1]"},
+		{`month(date) = 3 and year(date) == 2026`, nil, "[1 2]"},
+		{`days_between(date, "2026-03-31") <= 30`, nil, "[1 2]"},
+		{`add_days(date, 10) > '2026-03-25'`, nil, "[2]"},
+		{`format_date(date, "2006-01") = '2026-02'`, nil, "[4]"},
+		{`AMOUNT % 1000 = 0 AND Amount / 1000 >= 2`, nil, "[3 4]"},
+		{`name + "!" == 'Beta!'`, nil, "[2]"},
+		{"`partner_id`.name = 'Acme'", nil, "[1]"},
+		{`round(amount / 7.0, 1) = 114.3`, nil, "[2]"},
+		{`coalesce(partner_id.country, "XX") = "XX"`, nil, "[4]"},
+	}
+	for _, c := range cases {
+		view, err := ds.Where(c.expr, c.args...)
+		if err != nil {
+			t.Fatalf("%s: %v", c.expr, err)
+		}
+		if got := fmt.Sprint(view.ValueBy("id")); got != c.want {
+			t.Fatalf("%s: expected %s, got %s", c.expr, c.want, got)
+		}
+	}
+
+	view, _ := ds.Where("amount > 1000")
+	if !view.IsView() || view.Count() != 3 {
+		t.Fatal("Where should return a view like Filter")
+	}
+
+	if _, err := ds.Where("nope > 1"); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Fatalf("unknown fields should be reported, got %v", err)
+	}
+	if _, err := ds.Where("amount > ?"); err == nil {
+		t.Fatal("missing arguments should fail")
+	}
+	if _, err := ds.Where("name / 2 > 1"); err == nil {
+		t.Fatal("evaluation errors should be returned")
+	}
+}
+
+func TestExprCompile(t *testing.T) {
+	e := MustCompile(`qty * price > 10 and customer.name != ""`)
+	if fmt.Sprint(e.Fields()) != "[qty price customer]" || e.String() == "" {
+		t.Fatalf("unexpected fields %v", e.Fields())
+	}
+
+	rec := NewRecordSet(map[string]any{"qty": 3, "price": 4.5, "customer": map[string]string{"name": "x"},
+		"at": time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)})
+	defer rec.Free()
+	if ok, err := e.Match(rec); err != nil || !ok {
+		t.Fatalf("expected a match: %v", err)
+	}
+	for expr, want := range map[string]any{
+		"qty * price":               13.5,
+		"substr('abcdef', 2, 3)":    "bcd",
+		"replace('a-b-c', '-', '')": "abc",
+		"concat('a', null, 'b')":    "ab",
+		"upper(null)":               nil,
+		"hour(at) + weekday(at)":    int64(10),
+		"floor(price) + ceil(2.1)":  7.0,
+		"abs(-qty)":                 int64(3),
+		"select + 1":                nil, // 表达式中 SQL 关键字可作字段名
+	} {
+		v, err := MustCompile(expr).Eval(rec)
+		if err != nil || v != want {
+			t.Fatalf("%s: expected %#v, got %#v (%v)", expr, want, v, err)
+		}
+	}
+
+	cases := []struct {
+		expr string
+		pos  int
+	}{
+		{"amount >", 8},
+		{"amount > > 1", 9},
+		{`name == "abc`, 8},
+		{"foo(1)", 0},
+		{"sum(amount) > 1", 0},
+		{"a in 1", 5},
+		{"(a > 1", 6},
+		{"a > 1 b", 6},
+		{"", 0},
+	}
+	for _, c := range cases {
+		_, err := Compile(c.expr)
+		var syntax *TSyntaxError
+		if !errors.As(err, &syntax) || syntax.Pos != c.pos {
+			t.Fatalf("%q: expected a syntax error at %d, got %v", c.expr, c.pos, err)
+		}
+	}
+	_, err := Compile("amount > > 1")
+	if err.Error() != `syntax error at position 9 near "> 1": unexpected ">"` {
+		t.Fatalf("unexpected message %q", err)
+	}
+}
+
+func TestDatasetExprField(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"qty": 2, "price": 1.5},
+		map[string]any{"qty": 4, "price": 2},
+	))
+	if err := ds.AddExprField("amount", "QTY * price"); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Data[1].GetByField("amount") != int64(8) {
+		t.Fatalf("unexpected amount %#v", ds.Data[1].GetByField("amount"))
+	}
+
+	// 依赖字段修改后重新计算
+	ds.Data[0].SetByField("qty", 10)
+	if ds.Data[0].GetByField("amount") != 15.0 {
+		t.Fatalf("calc field should be invalidated, got %#v", ds.Data[0].GetByField("amount"))
+	}
+
+	view, err := ds.Where("amount > 10")
+	if err != nil || view.Count() != 1 {
+		t.Fatalf("calc fields should be usable in Where: %v", err)
+	}
+
+	if err := ds.AddExprField("bad", "qty *"); err == nil {
+		t.Fatal("invalid formulas should be rejected")
+	}
+}
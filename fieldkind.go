@@ -0,0 +1,106 @@
+package dataset
+
+import (
+	"fmt"
+)
+
+type (
+	// TFieldKind 字段值类型。默认 KindAny 不做任何转换;
+	// 声明了类型的字段在写入(AppendRecord/SetByField)时自动转换。
+	TFieldKind uint8
+)
+
+const (
+	KindAny     TFieldKind = iota
+	KindDecimal            // TDecimal,可由数据库驱动返回的字符串/[]byte 解析
+)
+
+func (self TFieldKind) String() string {
+	switch self {
+	case KindDecimal:
+		return "decimal"
+	default:
+		return "any"
+	}
+}
+
+// WithFieldKind 声明字段类型,见 TDataSet.SetFieldKind
+func WithFieldKind(name string, kind TFieldKind) Option {
+	return func(cfg *Config) {
+		cfg.dataset.SetFieldKind(name, kind)
+	}
+}
+
+// SetFieldKind 声明字段类型,并将已有记录中该字段的值转换为对应类型。
+// 任一值无法转换时返回错误且不修改任何数据。
+func (self *TDataSet) SetFieldKind(name string, kind TFieldKind) error {
+	if self.IsFrozen() {
+		return ErrFrozen
+	}
+	if self.parent != nil {
+		err := self.parent.SetFieldKind(name, kind)
+		self.syncView()
+		return err
+	}
+
+	self.Lock()
+	defer self.Unlock()
+
+	if kind == KindAny {
+		delete(self.fieldKinds, name)
+		return nil
+	}
+
+	idx, has := self.fieldsIndex[name]
+	if has {
+		values := make([]any, len(self.Data))
+		for i, rec := range self.Data {
+			v, err := convertKind(kind, rec.get(idx, false))
+			if err != nil {
+				return fmt.Errorf("field < %s > of record %d: %w", name, i, err)
+			}
+			values[i] = v
+		}
+
+		for i, rec := range self.Data {
+			rec = rec.writable()
+			rec.set(idx, values[i], false)
+			rec.invalidateCalc(name)
+		}
+	}
+
+	if self.fieldKinds == nil {
+		self.fieldKinds = make(map[string]TFieldKind)
+	}
+	self.fieldKinds[name] = kind
+	return nil
+}
+
+// FieldKind 返回字段声明的类型,未声明时为 KindAny
+func (self *TDataSet) FieldKind(name string) TFieldKind {
+	if self == nil {
+		return KindAny
+	}
+	return self.owner().fieldKinds[name]
+}
+
+// convertValue 按字段声明的类型转换待写入的值
+func (self *TDataSet) convertValue(field string, value any) (any, error) {
+	kind := self.FieldKind(field)
+	if kind == KindAny {
+		return value, nil
+	}
+	return convertKind(kind, value)
+}
+
+func convertKind(kind TFieldKind, value any) (any, error) {
+	if isNull(value) {
+		return nil, nil
+	}
+
+	switch kind {
+	case KindDecimal:
+		return NewDecimal(value)
+	}
+	return value, nil
+}
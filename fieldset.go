@@ -97,7 +97,11 @@ func (self *TFieldSet) AsInteger() (result int64) {
 		return 0
 	}
 
-	return utils.ToInt64(self.RecSet.GetByField(self.Name, false))
+	value := self.RecSet.GetByField(self.Name, false)
+	if d, ok := value.(TDecimal); ok {
+		return d.Int64()
+	}
+	return utils.ToInt64(value)
 }
 
 // set/get value of field as bool type
@@ -125,5 +129,85 @@ func (self *TFieldSet) AsFloat() (result float64) {
 		return 0.0
 	}
 
-	return utils.ToFloat64(self.RecSet.GetByField(self.Name, false))
+	value := self.RecSet.GetByField(self.Name, false)
+	if d, ok := value.(TDecimal); ok {
+		return d.Float64()
+	}
+	return utils.ToFloat64(value)
+}
+
+// AsDecimal 以定点数读取字段值,支持数值与十进制字符串,无法转换时返回 0
+func (self *TFieldSet) AsDecimal() TDecimal {
+	if self == nil {
+		return TDecimal{}
+	}
+
+	d, _ := NewDecimal(self.RecSet.GetByField(self.Name, false))
+	return d
+}
+
+// column 取游标所在列(字段)在整个数据集中的值,记录不属于数据集时仅包含自身
+func (self *TFieldSet) column() []any {
+	if self == nil || self.RecSet == nil {
+		return nil
+	}
+
+	if self.RecSet.dataset == nil {
+		return []any{self.RecSet.GetByField(self.Name)}
+	}
+	return self.RecSet.dataset.columnValues(self.Name)
+}
+
+// Sum 所在列的合计,空值语义同 TDataSet.Sum
+func (self *TFieldSet) Sum() float64 {
+	return sumOf(self.column())
+}
+
+func (self *TFieldSet) Avg() (float64, bool) {
+	return avgOf(self.column())
+}
+
+func (self *TFieldSet) Min() (float64, bool) {
+	return minOf(self.column())
+}
+
+func (self *TFieldSet) Max() (float64, bool) {
+	return maxOf(self.column())
+}
+
+// Count 所在列非空值的个数
+func (self *TFieldSet) Count() int {
+	return countOf(self.column())
+}
+
+func (self *TFieldSet) SumDecimal() TDecimal {
+	return sumDecimalOf(self.column())
+}
+
+func (self *TFieldSet) AvgDecimal() (TDecimal, bool) {
+	return avgDecimalOf(self.column())
+}
+
+func (self *TFieldSet) MinDecimal() (TDecimal, bool) {
+	return extremeDecimalOf(self.column(), -1)
+}
+
+func (self *TFieldSet) MaxDecimal() (TDecimal, bool) {
+	return extremeDecimalOf(self.column(), 1)
+}
+
+func (self *TFieldSet) CountDistinct() int {
+	return countDistinctOf(self.column())
+}
+
+func (self *TFieldSet) Median() (float64, bool) {
+	return percentileOf(self.column(), 0.5)
+}
+
+func (self *TFieldSet) Percentile(p float64) (float64, bool) {
+	return percentileOf(self.column(), p)
+}
+
+func (self *TFieldSet) StdDev() (float64, bool) {
+	return stdDevOf(self.column())
 }
@@ -0,0 +1,86 @@
+package dataset
+
+import "fmt"
+
+// 实时过滤:设置过滤条件后,游标导航(First/Next/Eof/Record)、Count 与 Range 跳过不满足条件的记录。
+// 聚合(Sum/Avg/CountField 等)同样只计满足条件的记录。
+// 与 Filter/Where 不同,过滤不复制记录也不创建新数据集,Data 仍包含全部记录,
+// 其余方法(Filter/GroupBy/Sort 等)不受影响。
+// 条件在导航时逐条求值,新增或修改的记录无需额外处理即按最新的值判断。
+
+// SetFilter 设置过滤条件,fn 返回 false 的记录在导航中被跳过;fn 为 nil 时取消过滤。
+// 游标随即移动到当前位置起首条满足条件的记录。
+func (self *TDataSet) SetFilter(fn func(rec *TRecordSet) bool) {
+	if self == nil {
+		return
+	}
+	self.filter = fn
+	self.position.Store(int32(self.seek(int(self.position.Load()))))
+}
+
+// SetFilterExpr 以表达式设置过滤条件,语法见 Compile,args 对应占位符。
+// 表达式引用了不存在的字段时返回错误且不改变原有的过滤条件;求值出错的记录视为不满足条件。
+func (self *TDataSet) SetFilterExpr(expr string, args ...any) error {
+	e, err := Compile(expr)
+	if err != nil {
+		return err
+	}
+	if len(args) < e.params {
+		return fmt.Errorf("expression expects %d arguments, got %d", e.params, len(args))
+	}
+
+	binds := make(map[*tColumnRef]tBinding)
+	if err := bindColumns(self, binds, e.root); err != nil {
+		return err
+	}
+	self.SetFilter(func(rec *TRecordSet) bool {
+		ok, err := evalBool(e.root, &tEnv{rec: rec, args: args, binds: binds})
+		return err == nil && ok
+	})
+	return nil
+}
+
+// ClearFilter 取消过滤,游标位置不变
+func (self *TDataSet) ClearFilter() {
+	if self == nil {
+		return
+	}
+	self.filter = nil
+}
+
+// IsFiltered 是否设置了过滤条件
+func (self *TDataSet) IsFiltered() bool {
+	return self != nil && self.filter != nil
+}
+
+// accept 记录是否满足过滤条件,未设置过滤时总是满足
+func (self *TDataSet) accept(rec *TRecordSet) bool {
+	return self.filter == nil || (rec != nil && self.filter(rec))
+}
+
+// seek 返回 pos 起首条满足过滤条件的记录位置,不存在时返回末条记录之后的位置
+func (self *TDataSet) seek(pos int) int {
+	if pos < 0 {
+		pos = 0
+	}
+	if self.filter == nil {
+		return pos
+	}
+	for !self.endAt(pos) && !self.accept(self.recordAt(pos)) {
+		pos++
+	}
+	return pos
+}
+
+// settle 将游标移到当前位置起首条满足过滤条件的记录,使当前记录在被修改或过滤条件改变后仍然满足条件
+func (self *TDataSet) settle() int {
+	pos := int(self.position.Load())
+	if self.filter == nil {
+		return pos
+	}
+	if next := self.seek(pos); next != pos {
+		self.position.Store(int32(next))
+		pos = next
+	}
+	return pos
+}
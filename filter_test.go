@@ -0,0 +1,132 @@
+package dataset
+
+import (
+	"fmt"
+	"testing"
+)
+
+// visible 沿游标导航收集 id
+func visible(ds *TDataSet) string {
+	var ids []any
+	for ds.First(); !ds.Eof(); ds.Next() {
+		ids = append(ids, ds.Record().GetByField("id"))
+	}
+	return fmt.Sprint(ids)
+}
+
+func TestDatasetLiveFilter(t *testing.T) {
+	ds := newFixture(exprFixture)
+	ds.SetFilter(func(rec *TRecordSet) bool {
+		return rec.FieldByName("amount").AsInteger() >= 1500
+	})
+	if !ds.IsFiltered() {
+		t.Fatal("expected filtered")
+	}
+
+	if got := visible(ds); got != "[1 3 4]" {
+		t.Fatalf("expected [1 3 4], got %s", got)
+	}
+	if ds.Count() != 3 || ds.CountField("name") != 3 || len(ds.Data) != 4 {
+		t.Fatalf("filter should only affect navigation, Count and aggregates, got Count %d, Data %d", ds.Count(), len(ds.Data))
+	}
+	if avg, _ := ds.Avg("amount"); avg != ds.Sum("amount")/float64(ds.Count()) {
+		t.Fatalf("aggregates should honour the filter like Count, got avg %v sum %v", avg, ds.Sum("amount"))
+	}
+	columnar := NewDataSet(WithColumnar())
+	columnar.LoadRows([]string{"amount"}, [][]any{{1}, {2}, {3}})
+	columnar.SetFilterExpr("amount >= 2")
+	if columnar.Sum("amount") != 5 {
+		t.Fatalf("columnar aggregates should honour the filter, got %v", columnar.Sum("amount"))
+	}
+
+	var ranged []int
+	ds.Range(func(pos int, rec *TRecordSet) error {
+		ranged = append(ranged, pos)
+		return nil
+	})
+	if fmt.Sprint(ranged) != "[0 2 3]" {
+		t.Fatalf("Range should visit matching records with their positions, got %v", ranged)
+	}
+
+	// 修改与新增的记录按最新的值判断
+	ds.Data[1].SetByField("amount", 1600)
+	ds.Data[3].SetByField("amount", 10)
+	ds.NewRecord(map[string]any{"id": 5, "amount": 5000, "name": "Epsilon"})
+	ds.NewRecord(map[string]any{"id": 6, "amount": 5, "name": "Zeta"})
+	if got := visible(ds); got != "[1 2 3 5]" {
+		t.Fatalf("expected [1 2 3 5] after edits, got %s", got)
+	}
+
+	// 当前记录被改为不满足条件时,游标前进到下一条满足条件的记录
+	ds.First()
+	ds.Record().SetByField("amount", 0)
+	if ds.Eof() || ds.Record().GetByField("id") != 2 {
+		t.Fatalf("cursor should move past the edited record, got %v", ds.Record().GetByField("id"))
+	}
+
+	ds.ClearFilter()
+	if ds.IsFiltered() || ds.Count() != 6 || visible(ds) != "[1 2 3 4 5 6]" {
+		t.Fatalf("ClearFilter should restore all records, got %s", visible(ds))
+	}
+
+	ds.SetFilter(func(rec *TRecordSet) bool { return false })
+	if !ds.Eof() || ds.Count() != 0 || len(ds.Data) != 6 {
+		t.Fatal("nothing should be visible")
+	}
+	// 无匹配时读取当前记录不应向 Data 追加空白行
+	if !ds.SetKeyField("id") || ds.FieldByName("amount") == nil || len(ds.Data) != 6 {
+		t.Fatalf("reading an empty filter result should not add rows, got Data %d", len(ds.Data))
+	}
+	if rec := ds.RecordByKey(6); rec == nil || rec.GetByField("name") != "Zeta" {
+		t.Fatal("key index should be built from all records")
+	}
+	ds.SetFilter(nil)
+	if ds.IsFiltered() || ds.Count() != 6 {
+		t.Fatal("SetFilter(nil) should remove the filter")
+	}
+}
+
+func TestDatasetFilterExpr(t *testing.T) {
+	ds := newFixture(exprFixture)
+	if err := ds.SetFilterExpr(`partner_id.country = ? or amount > ?`, "CN", 2500); err != nil {
+		t.Fatal(err)
+	}
+	if got := visible(ds); got != "[1 2 4]" {
+		t.Fatalf("expected [1 2 4], got %s", got)
+	}
+
+	// 不满足条件的新记录被跳过,修改后满足条件的记录出现
+	ds.NewRecord(map[string]any{"id": 5, "amount": 100, "name": "Epsilon"})
+	if got := visible(ds); got != "[1 2 4]" {
+		t.Fatalf("new record should be hidden, got %s", got)
+	}
+	ds.Data[4].SetByField("partner_id", map[string]any{"country": "CN"})
+	if got := visible(ds); got != "[1 2 4 5]" {
+		t.Fatalf("edited record should be visible, got %s", got)
+	}
+
+	if err := ds.SetFilterExpr("missing > 1"); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+	if err := ds.SetFilterExpr("amount >"); err == nil {
+		t.Fatal("expected syntax error")
+	}
+	if err := ds.SetFilterExpr("amount > ?"); err == nil {
+		t.Fatal("expected error for missing argument")
+	}
+	if got := visible(ds); got != "[1 2 4 5]" {
+		t.Fatalf("failed SetFilterExpr should keep the previous filter, got %s", got)
+	}
+
+	// 过滤不影响派生,聚合只计满足条件的记录
+	if view := ds.Filter("id", []any{3}); view.Count() != 1 {
+		t.Fatal("Filter should see all records")
+	}
+	if sum := ds.Sum("amount"); sum != 5400 {
+		t.Fatalf("aggregates should only see matching records, got %v", sum)
+	}
+	ds.ClearFilter()
+	if sum := ds.Sum("amount"); sum != 7400 {
+		t.Fatalf("aggregates should see all records without a filter, got %v", sum)
+	}
+}
@@ -0,0 +1,198 @@
+package dataset
+
+import (
+	"sync"
+)
+
+type (
+	// tHooks 数据集的变更钩子,见 BeforeInsert 等注册方法
+	tHooks struct {
+		beforeInsert []func(rec *TRecordSet) error
+		afterInsert  []func(rec *TRecordSet)
+		beforeUpdate []func(rec *TRecordSet, field string, oldValue, newValue any) error
+		afterUpdate  []func(rec *TRecordSet, field string, oldValue, newValue any)
+		beforeDelete []func(rec *TRecordSet) error
+		afterDelete  []func(rec *TRecordSet)
+		fieldChange  map[string][]func(rec *TRecordSet, oldValue, newValue any)
+
+		subMu sync.Mutex // 保护 subs,订阅可在其他 goroutine 中取消
+		subs  []*tSubscriber
+
+		log *tChangeLog // 变更日志,见 changelog.go
+
+		holding int      // 事务提交中,After* 钩子与事件暂存至提交成功
+		held    []func() // 暂存的 After* 钩子与事件投递
+	}
+)
+
+// 变更钩子:
+//   - 插入:AppendRecord/NewRecord 逐条触发。BeforeInsert 收到待插入的原记录,可在其上修改值,
+//     返回错误时停止插入并由 AppendRecord 返回该错误(此前已插入的记录保留);
+//     AfterInsert 收到已归属数据集的记录,在本次调用的所有记录插入后触发。
+//   - 更新:已归属数据集的记录 SetByField(以及 EditRecord)时触发。BeforeUpdate 返回错误时
+//     放弃写入,SetByField 返回 false;OnFieldChange 仅在值实际改变时触发。
+//   - 删除:Delete/DeleteRecord 与 Clear 触发。BeforeDelete 返回错误时放弃删除,
+//     Clear 中任一记录被否决则不删除任何记录;AfterDelete 在记录移出数据集后触发,记录的值仍可读取。
+//
+// 钩子在数据集锁外同步执行,可调用数据集方法;After* 钩子之后向 Subscribe 的订阅者投递事件。
+// Transaction 提交时 Before* 钩子随各项修改执行,After* 钩子与事件在全部修改成功后才依次触发。
+// 视图上注册的钩子注册到其根数据集;视图的 Delete/Clear 只改变视图、不删除数据,不触发删除钩子。
+
+// BeforeInsert 注册插入前钩子,返回错误时取消插入
+func (self *TDataSet) BeforeInsert(fn func(rec *TRecordSet) error) {
+	if h := self.ensureHooks(); fn != nil {
+		h.beforeInsert = append(h.beforeInsert, fn)
+	}
+}
+
+// AfterInsert 注册插入后钩子
+func (self *TDataSet) AfterInsert(fn func(rec *TRecordSet)) {
+	if h := self.ensureHooks(); fn != nil {
+		h.afterInsert = append(h.afterInsert, fn)
+	}
+}
+
+// BeforeUpdate 注册字段更新前钩子,返回错误时取消写入
+func (self *TDataSet) BeforeUpdate(fn func(rec *TRecordSet, field string, oldValue, newValue any) error) {
+	if h := self.ensureHooks(); fn != nil {
+		h.beforeUpdate = append(h.beforeUpdate, fn)
+	}
+}
+
+// AfterUpdate 注册字段更新后钩子
+func (self *TDataSet) AfterUpdate(fn func(rec *TRecordSet, field string, oldValue, newValue any)) {
+	if h := self.ensureHooks(); fn != nil {
+		h.afterUpdate = append(h.afterUpdate, fn)
+	}
+}
+
+// BeforeDelete 注册删除前钩子,返回错误时取消删除
+func (self *TDataSet) BeforeDelete(fn func(rec *TRecordSet) error) {
+	if h := self.ensureHooks(); fn != nil {
+		h.beforeDelete = append(h.beforeDelete, fn)
+	}
+}
+
+// AfterDelete 注册删除后钩子
+func (self *TDataSet) AfterDelete(fn func(rec *TRecordSet)) {
+	if h := self.ensureHooks(); fn != nil {
+		h.afterDelete = append(h.afterDelete, fn)
+	}
+}
+
+// OnFieldChange 注册字段值改变钩子,写入相同的值时不触发
+func (self *TDataSet) OnFieldChange(field string, fn func(rec *TRecordSet, oldValue, newValue any)) {
+	h := self.ensureHooks()
+	if field == "" || fn == nil {
+		return
+	}
+	if h.fieldChange == nil {
+		h.fieldChange = make(map[string][]func(*TRecordSet, any, any))
+	}
+	h.fieldChange[field] = append(h.fieldChange[field], fn)
+}
+
+func (self *TDataSet) ensureHooks() *tHooks {
+	root := self.owner()
+	if root.hooks == nil {
+		root.hooks = &tHooks{}
+	}
+	return root.hooks
+}
+
+func (self *tHooks) changeLog() *tChangeLog {
+	if self == nil {
+		return nil
+	}
+	return self.log
+}
+
+func (self *tHooks) fireBeforeInsert(rec *TRecordSet) error {
+	if self == nil {
+		return nil
+	}
+	for _, fn := range self.beforeInsert {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *tHooks) fireAfterInsert(rec *TRecordSet) {
+	if self == nil {
+		return
+	}
+	self.log.record(TChange{Kind: ChangeInsert, Record: rec, Index: rec.index})
+	self.after(func() {
+		for _, fn := range self.afterInsert {
+			fn(rec)
+		}
+		self.publish(ChangeInsert, rec, "", nil, nil)
+	})
+}
+
+func (self *tHooks) fireBeforeUpdate(rec *TRecordSet, field string, oldValue, newValue any) error {
+	if self == nil {
+		return nil
+	}
+	for _, fn := range self.beforeUpdate {
+		if err := fn(rec, field, oldValue, newValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *tHooks) fireAfterUpdate(rec *TRecordSet, field string, oldValue, newValue any) {
+	if self == nil {
+		return
+	}
+	self.log.record(TChange{Kind: ChangeUpdate, Record: rec, Field: field, OldValue: oldValue, NewValue: newValue})
+	self.after(func() {
+		for _, fn := range self.afterUpdate {
+			fn(rec, field, oldValue, newValue)
+		}
+		if fns := self.fieldChange[field]; len(fns) > 0 && compareValue(oldValue, newValue) != 0 {
+			for _, fn := range fns {
+				fn(rec, oldValue, newValue)
+			}
+		}
+		self.publish(ChangeUpdate, rec, field, oldValue, newValue)
+	})
+}
+
+func (self *tHooks) fireBeforeDelete(rec *TRecordSet) error {
+	if self == nil {
+		return nil
+	}
+	for _, fn := range self.beforeDelete {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fireAfterDelete index 为撤销时恢复记录的位置
+func (self *tHooks) fireAfterDelete(rec *TRecordSet, index int) {
+	if self == nil {
+		return
+	}
+	self.log.record(TChange{Kind: ChangeDelete, Record: rec, Index: index})
+	self.after(func() {
+		for _, fn := range self.afterDelete {
+			fn(rec)
+		}
+		self.publish(ChangeDelete, rec, "", nil, nil)
+	})
+}
+
+// after 执行 After* 钩子与事件投递,事务提交中暂存至提交成功
+func (self *tHooks) after(fn func()) {
+	if self.holding > 0 {
+		self.held = append(self.held, fn)
+		return
+	}
+	fn()
+}
@@ -0,0 +1,90 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDatasetHooks(t *testing.T) {
+	ds := newFixture(viewFixture)
+	var log []string
+
+	ds.BeforeInsert(func(rec *TRecordSet) error {
+		if rec.GetByField("cat") == "X" {
+			return errors.New("category X is not allowed")
+		}
+		return nil
+	})
+	ds.AfterInsert(func(rec *TRecordSet) {
+		log = append(log, fmt.Sprintf("insert %v@%d", rec.GetByField("id"), rec.index))
+	})
+	ds.BeforeUpdate(func(rec *TRecordSet, field string, oldValue, newValue any) error {
+		if field == "id" {
+			return errors.New("id is read-only")
+		}
+		return nil
+	})
+	ds.AfterUpdate(func(rec *TRecordSet, field string, oldValue, newValue any) {
+		log = append(log, fmt.Sprintf("update %s %v->%v", field, oldValue, newValue))
+	})
+	ds.OnFieldChange("cat", func(rec *TRecordSet, oldValue, newValue any) {
+		log = append(log, fmt.Sprintf("cat changed %v->%v", oldValue, newValue))
+	})
+	ds.BeforeDelete(func(rec *TRecordSet) error {
+		if rec.GetByField("id") == 1 {
+			return errors.New("record 1 is protected")
+		}
+		return nil
+	})
+	ds.AfterDelete(func(rec *TRecordSet) {
+		log = append(log, fmt.Sprintf("delete %v", rec.GetByField("id")))
+	})
+
+	if err := ds.NewRecord(map[string]any{"id": 4, "cat": "X"}); err == nil || ds.Count() != 3 {
+		t.Fatal("BeforeInsert should veto the insert")
+	}
+	if err := ds.NewRecord(map[string]any{"id": 4, "cat": "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := ds.Data[1]
+	if rec.SetByField("id", 20) || rec.GetByField("id") != 2 {
+		t.Fatal("BeforeUpdate should veto the write")
+	}
+	rec.SetByField("cat", "B") // 值未改变,不触发 OnFieldChange
+	rec.SetByField("cat", "C")
+
+	if ds.Delete(0) || ds.Count() != 4 {
+		t.Fatal("BeforeDelete should veto the delete")
+	}
+	if !ds.DeleteRecord("2") || ds.Count() != 3 {
+		t.Fatal("DeleteRecord should delete by key")
+	}
+	if !ds.EditRecord("3", map[string]any{"cat": "D"}) || ds.EditRecord("99", map[string]any{"cat": "D"}) {
+		t.Fatal("EditRecord should edit by key")
+	}
+
+	// 视图的删除不触发钩子
+	view := ds.Filter("cat", []any{"D"})
+	view.Clear()
+
+	// Clear 中被否决时不删除任何记录
+	ds.Clear()
+	if ds.Count() != 3 {
+		t.Fatal("Clear should be vetoed as a whole")
+	}
+
+	want := []string{
+		"insert 4@3",
+		"update cat B->B",
+		"update cat B->C",
+		"cat changed B->C",
+		"delete 2",
+		"update cat A->D",
+		"cat changed A->D",
+	}
+	if fmt.Sprint(log) != fmt.Sprint(want) {
+		t.Fatalf("unexpected hook log:\n%v\nwant:\n%v", log, want)
+	}
+}
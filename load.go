@@ -0,0 +1,218 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// 批量加载:LoadRows/LoadColumns/AppendValues 直接按数据集的字段索引生成记录,
+// 不为每行创建 map 与记录自身的字段索引,适用于从 database/sql 等来源大量载入数据。
+// 与 AppendRecord 的语义一致:不存在的字段自动新增(WithFieldsChecker 时返回错误),
+// 按字段类型转换值、填充默认值、执行校验规则,插入钩子、变更日志与订阅事件照常触发
+// (一次调用为一个可撤销的步骤)。与 AppendRecord 不同,全为 NULL 的行同样被追加,
+// 加载的行数与来源一致。
+// 某一行出错时停止加载并返回错误,此前的行保留;形状错误(行/列长度不符)时不加载任何行。
+
+// LoadRows 按 fields 的顺序批量追加行,行的值少于 fields 时其余字段为 nil
+func (self *TDataSet) LoadRows(fields []string, rows [][]any) error {
+	for i, row := range rows {
+		if len(row) > len(fields) {
+			return fmt.Errorf("row %d has %d values for %d fields", i, len(row), len(fields))
+		}
+	}
+
+	return self.loadRows(fields, len(rows), func(i, j int) any {
+		if row := rows[i]; j < len(row) {
+			return row[j]
+		}
+		return nil
+	})
+}
+
+// LoadColumns 按列批量追加行,各列长度须一致。新增字段按字段名排序加入。
+func (self *TDataSet) LoadColumns(columns map[string][]any) error {
+	fields := slices.Sorted(maps.Keys(columns))
+	n := -1
+	for _, field := range fields {
+		if n < 0 {
+			n = len(columns[field])
+		} else if len(columns[field]) != n {
+			return fmt.Errorf("column < %s > has %d values, expected %d", field, len(columns[field]), n)
+		}
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	cols := make([][]any, len(fields))
+	for j, field := range fields {
+		cols[j] = columns[field]
+	}
+	return self.loadRows(fields, n, func(i, j int) any {
+		return cols[j][i]
+	})
+}
+
+// AppendValues 按数据集字段顺序(Fields)追加一行
+func (self *TDataSet) AppendValues(values ...any) error {
+	fields := self.owner().fields
+	if len(values) > len(fields) {
+		return fmt.Errorf("%d values for %d fields", len(values), len(fields))
+	}
+
+	fields = slices.Clone(fields[:len(values)])
+	return self.loadRows(fields, 1, func(_, j int) any {
+		return values[j]
+	})
+}
+
+// loadRows 追加 n 行,value(i, j) 为第 i 行 fields[j] 的值
+func (self *TDataSet) loadRows(fields []string, n int, value func(i, j int) any) error {
+	if self.IsFrozen() {
+		return ErrFrozen
+	}
+	if n == 0 {
+		return nil
+	}
+	if self.parent != nil {
+		return self.loadToView(fields, n, value)
+	}
+
+	log := self.changeLog()
+	log.begin()
+	defer log.end()
+
+	if self.hooks != nil && len(self.hooks.beforeInsert) > 0 {
+		return self.loadRecords(fields, n, value)
+	}
+
+	// 字段映射
+	idx := make([]int, len(fields))
+	kinds := make([]TFieldKind, len(fields))
+	for j, field := range fields {
+		i, has := self.fieldsIndex[field]
+		if !has {
+			if self.config.checkFields {
+				return fmt.Errorf("The field name < %v > is not in this dataset! please to set field by < dataset.SetFields >", field)
+			}
+			if i = self.AddField(field); i < 0 {
+				return fmt.Errorf("field < %s >: too many fields", field)
+			}
+		}
+		idx[j] = i
+		kinds[j] = self.FieldKind(field)
+	}
+	self.ensureDefaultFields()
+
+	var (
+		count    = self.FieldCount
+		start    = len(self.Data)
+		validate = self.validateOnWrite()
+		backing  []any // 行式存储时各行共用一块预分配的值数组
+		scratch  []any // 列式存储时值写入列向量,各行复用
+		err      error
+	)
+	if self.store == nil {
+		backing = make([]any, n*count)
+	} else {
+		scratch = make([]any, count)
+	}
+	self.ensureDataOwned()
+	self.Data = slices.Grow(self.Data, n)
+
+	for i := 0; i < n; i++ {
+		values := scratch
+		if backing != nil {
+			values = backing[i*count : (i+1)*count : (i+1)*count]
+		} else {
+			clear(values)
+		}
+
+		for j, k := range idx {
+			v := value(i, j)
+			if kinds[j] != KindAny {
+				if v, err = convertKind(kinds[j], v); err != nil {
+					err = fmt.Errorf("row %d field < %s >: %w", i, fields[j], err)
+					break
+				}
+			}
+			values[k] = v
+		}
+		if err != nil {
+			break
+		}
+
+		_, slots, derr := self.applyDefaults(context.Background(), values)
+		if derr != nil {
+			err = fmt.Errorf("row %d: %w", i, derr)
+			break
+		}
+
+		pos := len(self.Data)
+		if validate {
+			shell := &TRecordSet{dataset: self, index: pos, values: values, fieldsCount: count}
+			if errs := self.validateRecord(shell, pos); len(errs) > 0 {
+				err = errs
+				break
+			}
+		}
+		if err = self.takeSequences(values, slots); err != nil {
+			err = fmt.Errorf("row %d: %w", i, err)
+			break
+		}
+
+		rec := newOwnedRecord(self, pos)
+		self.setValues(rec, values)
+		self.Data = append(self.Data, rec)
+	}
+
+	inserted := self.Data[start:]
+	if err != nil && len(inserted) == 0 {
+		return err
+	}
+	self.position.Store(int32(len(self.Data) - 1))
+
+	// 清除索引
+	if self.RecordsIndex != nil {
+		self.RecordsIndex = nil
+	}
+
+	for _, rec := range slices.Clone(inserted) {
+		self.hooks.fireAfterInsert(rec)
+	}
+	return err
+}
+
+// loadRecords 有 BeforeInsert 钩子时逐行生成记录插入,钩子可在记录上修改值
+func (self *TDataSet) loadRecords(fields []string, n int, value func(i, j int) any) error {
+	for i := 0; i < n; i++ {
+		m := make(map[string]any, len(fields))
+		for j, field := range fields {
+			m[field] = value(i, j)
+		}
+		if err := self.appendRecords(context.Background(), true, NewRecordSet(m)); err != nil {
+			return fmt.Errorf("row %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// loadToView 加载到根数据集并由视图引用新增的记录
+func (self *TDataSet) loadToView(fields []string, n int, value func(i, j int) any) error {
+	root := self.parent
+	start := len(root.Data)
+	err := root.loadRows(fields, n, value)
+
+	self.ensureDataOwned()
+	for _, rec := range root.Data[min(start, len(root.Data)):] {
+		self.Data = append(self.Data, rec)
+	}
+	self.syncView()
+	self.position.Store(int32(len(self.Data) - 1))
+	if self.RecordsIndex != nil {
+		self.RecordsIndex = nil
+	}
+	return err
+}
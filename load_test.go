@@ -0,0 +1,110 @@
+package dataset
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDatasetLoadRows(t *testing.T) {
+	ds := NewDataSet()
+	err := ds.LoadRows([]string{"id", "name", "qty"}, [][]any{
+		{1, "a", 10},
+		{2, "b"},
+		{nil, nil, nil}, // 空行同样保留
+		{3, "c", 30},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Count() != 4 || ds.FieldCount != 3 || ds.Data[1].GetByField("qty") != nil || ds.Data[3].GetByField("name") != "c" {
+		t.Fatalf("unexpected data %v", ds.Data[3].AsMap())
+	}
+	if ds.Data[2].GetByField("id") != nil || ds.Data[0].fieldsIndex != nil || ds.Data[3].index != 3 || ds.Position() != 3 {
+		t.Fatal("records should use the dataset field index")
+	}
+
+	// 已有字段按名称映射,新字段自动新增
+	if err := ds.LoadRows([]string{"qty", "note", "id"}, [][]any{{40, "x", 4}}); err != nil {
+		t.Fatal(err)
+	}
+	if rec := ds.Data[4]; rec.GetByField("id") != 4 || rec.GetByField("qty") != 40 || rec.GetByField("note") != "x" {
+		t.Fatalf("unexpected record %v", rec.AsMap())
+	}
+
+	if err := ds.LoadRows([]string{"id"}, [][]any{{5}, {6, 7}}); err == nil || ds.Count() != 5 {
+		t.Fatal("rows wider than fields should be rejected without loading")
+	}
+
+	// 按字段顺序追加一行
+	if err := ds.AppendValues(7, "g"); err != nil || ds.Data[5].GetByField("name") != "g" || ds.Data[5].GetByField("qty") != nil {
+		t.Fatalf("AppendValues failed: %v", err)
+	}
+	if err := ds.AppendValues(1, 2, 3, 4, 5); err == nil {
+		t.Fatal("too many values should fail")
+	}
+
+	// 逐行经钩子插入时空行同样保留
+	hooked := NewDataSet()
+	hooked.BeforeInsert(func(rec *TRecordSet) error { return nil })
+	if err := hooked.LoadRows([]string{"id"}, [][]any{{1}, {nil}}); err != nil || hooked.Count() != 2 {
+		t.Fatalf("expected 2 records, got %d %v", hooked.Count(), err)
+	}
+}
+
+func TestDatasetLoadColumns(t *testing.T) {
+	ds := NewDataSet(WithColumnar(), WithFieldKind("amount", KindDecimal))
+	err := ds.LoadColumns(map[string][]any{
+		"id":     {1, 2, 3},
+		"amount": {"1.10", "2.20", nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Count() != 3 || ds.SumDecimal("amount").String() != "3.30" || ds.Data[2].GetByField("amount") != nil {
+		t.Fatalf("unexpected sum %v", ds.SumDecimal("amount"))
+	}
+
+	if err := ds.LoadColumns(map[string][]any{"id": {4}, "amount": {}}); err == nil || ds.Count() != 3 {
+		t.Fatal("columns of different length should be rejected")
+	}
+	if err := ds.LoadColumns(map[string][]any{"id": {4}, "amount": {"x"}}); err == nil || ds.Count() != 3 {
+		t.Fatal("conversion errors should be reported")
+	}
+}
+
+func TestDatasetLoadRowsFeatures(t *testing.T) {
+	ds := NewDataSet(WithChangeLog(0), WithKeySequence(NewCounter(100)))
+	ds.AddRule("qty", Min(0))
+	var inserted int
+	ds.AfterInsert(func(rec *TRecordSet) { inserted++ })
+
+	err := ds.LoadRows([]string{"name", "qty"}, [][]any{{"a", 1}, {"b", 2}, {"c", -1}, {"d", 4}})
+	var verrs TValidationErrors
+	if !errors.As(err, &verrs) || verrs[0].Index != 2 {
+		t.Fatalf("expected a validation error at record 2, got %v", err)
+	}
+	if ds.Count() != 2 || ds.Data[1].GetByField("id") != int64(101) || inserted != 2 {
+		t.Fatal("rows before the failing one should be kept with generated keys")
+	}
+	if !ds.Undo() || ds.Count() != 0 {
+		t.Fatal("a load should be a single undo step")
+	}
+
+	// BeforeInsert 钩子可修改值
+	ds.BeforeInsert(func(rec *TRecordSet) error {
+		rec.SetByField("name", "hooked")
+		return nil
+	})
+	view := ds.Filter("name", []any{"none"})
+	if err := view.LoadRows([]string{"name"}, [][]any{{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if view.Count() != 1 || ds.Count() != 1 || view.Data[0].GetByField("name") != "hooked" {
+		t.Fatal("loading into a view should add to its root and run insert hooks")
+	}
+
+	ds.Freeze()
+	if err := ds.AppendValues(1); !errors.Is(err, ErrFrozen) {
+		t.Fatal("loading into a frozen dataset should fail")
+	}
+}
@@ -0,0 +1,311 @@
+package dataset
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/volts-dev/utils"
+)
+
+// ErrInvalidCursor 游标令牌格式错误、被篡改或与排序不符
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type (
+	// tCursor 游标令牌的内容:签发的数据集、排序描述与上一页末条记录的排序键
+	tCursor struct {
+		Scope string         `json:"d"`
+		Order string         `json:"o"`
+		Keys  []tCursorValue `json:"k"`
+	}
+
+	// tCursorValue 带类型标记的排序键,解码后与原值按 compareValue 比较结果一致
+	tCursorValue struct {
+		Type  string `json:"t"`
+		Value string `json:"v,omitempty"`
+	}
+)
+
+var cursorKey = struct {
+	sync.RWMutex
+	key []byte
+}{key: randomCursorKey()}
+
+func randomCursorKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// SetCursorKey 设置游标令牌的签名密钥。默认密钥在进程启动时随机生成,
+// 令牌仅在本进程内有效;多实例部署或需要令牌在重启后仍然有效时,应设置相同的密钥。
+// key 为空时重新生成随机密钥。
+func SetCursorKey(key []byte) {
+	cursorKey.Lock()
+	defer cursorKey.Unlock()
+	if len(key) == 0 {
+		cursorKey.key = randomCursorKey()
+		return
+	}
+	cursorKey.key = slices.Clone(key)
+}
+
+func cursorMAC(payload []byte) []byte {
+	cursorKey.RLock()
+	defer cursorKey.RUnlock()
+	mac := hmac.New(sha256.New, cursorKey.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Page 返回跳过 offset 条后最多 limit 条记录组成的数据集及记录总数,
+// limit <= 0 时返回其后的全部记录。设置了过滤条件时只计满足条件的记录(见 filter.go)。
+// 结果同 Filter 默认为引用本数据集记录的视图,WithCopyOnDerive 时为深复制。
+func (self *TDataSet) Page(offset, limit int) (*TDataSet, int) {
+	if self == nil {
+		return nil, 0
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	res := self.deriveDataSet()
+	total := 0
+	self.Range(func(_ int, rec *TRecordSet) error {
+		if total >= offset && (limit <= 0 || total < offset+limit) {
+			res.addDerived(rec)
+		}
+		total++
+		return nil
+	})
+	res.First()
+	return res, total
+}
+
+// After 按键集分页:返回按 order 排序后位于 token 所指记录之后的最多 limit 条记录,
+// 以及下一页的令牌(没有更多记录时为空)。token 为空时返回第一页,例如:
+//
+//	page, next, err := ds.After(req.Cursor, "date desc, id", 20)
+//
+// order 的格式同 SortBy;设置了 KeyField 且 order 中未包含时追加主键升序,使排序键唯一。
+// 未设置 KeyField 时 order 必须能唯一确定记录,存在排序键相同的记录时返回错误(否则与上一页
+// 末条记录并列的记录会被跳过)。
+// 令牌记录末条记录的排序键而非位置,翻页期间插入或删除记录不会造成重复或遗漏。
+// 令牌经 HMAC 签名(见 SetCursorKey)并绑定签发它的数据集:设置了 Name 时为同名的数据集
+// (如每次请求重新加载的同一模型),否则仅为该数据集实例及其视图。
+// 格式错误、被篡改、与 order 不符或来自其他数据集时返回 ErrInvalidCursor。
+// 本数据集不被排序;结果同 Page。
+func (self *TDataSet) After(token string, order string, limit int) (*TDataSet, string, error) {
+	if self == nil {
+		return nil, "", nil
+	}
+
+	fields := parseOrder(order)
+	if self.KeyField != "" && !slices.ContainsFunc(fields, func(f sortField) bool { return f.name == self.KeyField }) {
+		fields = append(fields, sortField{name: self.KeyField})
+	}
+	if len(fields) == 0 {
+		return nil, "", fmt.Errorf("keyset pagination requires an order or a key field")
+	}
+	orderKey := formatOrder(fields)
+	scope := self.cursorScope()
+
+	var after []any
+	if token != "" {
+		cursor, err := decodeCursor(token)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.Scope != scope {
+			return nil, "", fmt.Errorf("%w: cursor was issued for another dataset", ErrInvalidCursor)
+		}
+		if cursor.Order != orderKey || len(cursor.Keys) != len(fields) {
+			return nil, "", fmt.Errorf("%w: cursor was issued for order %q", ErrInvalidCursor, cursor.Order)
+		}
+		after = make([]any, len(cursor.Keys))
+		for i, key := range cursor.Keys {
+			v, err := key.decode()
+			if err != nil {
+				return nil, "", err
+			}
+			after[i] = v
+		}
+	}
+
+	var records []*TRecordSet
+	if err := self.Range(func(_ int, rec *TRecordSet) error {
+		records = append(records, rec)
+		return nil
+	}); err != nil {
+		return nil, "", err
+	}
+	slices.SortStableFunc(records, func(a, b *TRecordSet) int {
+		return compareRecords(a, b, fields)
+	})
+	if self.KeyField == "" {
+		for i := 1; i < len(records); i++ {
+			if compareRecords(records[i-1], records[i], fields) == 0 {
+				return nil, "", fmt.Errorf("order %q does not identify records uniquely, set KeyField or add a unique field", orderKey)
+			}
+		}
+	}
+	if after != nil {
+		start, _ := slices.BinarySearchFunc(records, after, func(rec *TRecordSet, keys []any) int {
+			if compareKeys(rec, keys, fields) > 0 {
+				return 1
+			}
+			return -1
+		})
+		records = records[start:]
+	}
+
+	next := ""
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+		var err error
+		if next, err = encodeCursor(scope, orderKey, records[limit-1], fields); err != nil {
+			return nil, "", err
+		}
+	}
+
+	res := self.deriveDataSet()
+	for _, rec := range records {
+		res.addDerived(rec)
+	}
+	res.First()
+	return res, next, nil
+}
+
+// formatOrder 规范化的排序描述,用于校验令牌与请求的排序一致
+func formatOrder(fields []sortField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field.name
+		if field.desc {
+			parts[i] += " desc"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// compareKeys 按排序字段比较记录与游标的排序键
+func compareKeys(rec *TRecordSet, keys []any, fields []sortField) int {
+	for i, field := range fields {
+		c := compareValue(rec.GetByField(field.name), keys[i])
+		if c != 0 {
+			if field.desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// cursorScope 令牌绑定的数据集标识:根数据集的 Name,未命名时为实例的随机标识
+func (self *TDataSet) cursorScope() string {
+	root := self.owner()
+	if root.Name != "" {
+		return "name:" + root.Name
+	}
+
+	root.scopeOnce.Do(func() {
+		id := make([]byte, 12)
+		rand.Read(id)
+		root.scopeID = "id:" + base64.RawURLEncoding.EncodeToString(id)
+	})
+	return root.scopeID
+}
+
+func encodeCursor(scope, order string, rec *TRecordSet, fields []sortField) (string, error) {
+	cursor := tCursor{Scope: scope, Order: order, Keys: make([]tCursorValue, len(fields))}
+	for i, field := range fields {
+		cursor.Keys[i] = newCursorValue(rec.GetByField(field.name))
+	}
+
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(payload, cursorMAC(payload)...)), nil
+}
+
+func decodeCursor(token string) (*tCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) <= sha256.Size {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, sum := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if !hmac.Equal(sum, cursorMAC(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor tCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func newCursorValue(v any) tCursorValue {
+	if n, ok := toInteger(v); ok {
+		return tCursorValue{Type: "i", Value: strconv.FormatInt(n, 10)}
+	}
+	switch x := v.(type) {
+	case nil:
+		return tCursorValue{Type: "n"}
+	case uint, uint8, uint16, uint32, uint64:
+		return tCursorValue{Type: "u", Value: utils.ToString(x)}
+	case float32:
+		return tCursorValue{Type: "f", Value: strconv.FormatFloat(float64(x), 'g', -1, 64)}
+	case float64:
+		return tCursorValue{Type: "f", Value: strconv.FormatFloat(x, 'g', -1, 64)}
+	case bool:
+		return tCursorValue{Type: "b", Value: strconv.FormatBool(x)}
+	case time.Time:
+		return tCursorValue{Type: "t", Value: x.Format(time.RFC3339Nano)}
+	case TDecimal:
+		return tCursorValue{Type: "d", Value: x.String()}
+	case string:
+		return tCursorValue{Type: "s", Value: x}
+	}
+	// 其他类型在 compareValue 中按字符串比较
+	return tCursorValue{Type: "s", Value: utils.ToString(v)}
+}
+
+func (self tCursorValue) decode() (v any, err error) {
+	switch self.Type {
+	case "n":
+		return nil, nil
+	case "i":
+		v, err = strconv.ParseInt(self.Value, 10, 64)
+	case "u":
+		v, err = strconv.ParseUint(self.Value, 10, 64)
+	case "f":
+		v, err = strconv.ParseFloat(self.Value, 64)
+	case "b":
+		v, err = strconv.ParseBool(self.Value)
+	case "t":
+		v, err = time.Parse(time.RFC3339Nano, self.Value)
+	case "d":
+		v, err = ParseDecimal(self.Value)
+	case "s":
+		return self.Value, nil
+	default:
+		err = fmt.Errorf("unknown key type %q", self.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return v, nil
+}
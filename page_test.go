@@ -0,0 +1,173 @@
+package dataset
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDatasetPage(t *testing.T) {
+	ds := newFixture(pageFixture)
+
+	cases := []struct {
+		offset, limit int
+		want          string
+	}{
+		{0, 3, "[1 2 3]"},
+		{3, 3, "[4 5 6]"},
+		{9, 3, "[10]"},
+		{12, 3, "[]"},
+		{-1, 2, "[1 2]"},
+		{8, 0, "[9 10]"},
+	}
+	for _, c := range cases {
+		page, total := ds.Page(c.offset, c.limit)
+		if total != 10 {
+			t.Fatalf("expected total 10, got %d", total)
+		}
+		if got := fmt.Sprint(page.Keys("id")); got != c.want {
+			t.Fatalf("Page(%d, %d): expected %s, got %s", c.offset, c.limit, c.want, got)
+		}
+	}
+
+	page, _ := ds.Page(0, 3)
+	if !page.IsView() || page.Position() != 0 {
+		t.Fatal("Page should return a view positioned on its first record")
+	}
+
+	ds.SetFilter(func(rec *TRecordSet) bool { return rec.GetByField("id").(int)%2 == 0 })
+	page, total := ds.Page(1, 2)
+	if total != 5 || fmt.Sprint(page.Keys("id")) != "[4 6]" {
+		t.Fatalf("Page should honour the live filter, got %d %v", total, page.Keys("id"))
+	}
+}
+
+func TestDatasetAfter(t *testing.T) {
+	ds := newFixture(pageFixture)
+
+	var pages []string
+	token := ""
+	for {
+		page, next, err := ds.After(token, "score desc", 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, fmt.Sprint(page.Keys("id")))
+		if next == "" {
+			break
+		}
+		token = next
+
+		// 翻页期间插入与删除记录不影响后续页
+		if len(pages) == 1 {
+			ds.NewRecord(map[string]any{"id": 11, "score": 3})
+			ds.NewRecord(map[string]any{"id": 12, "score": 0})
+			ds.Delete(0)
+		}
+	}
+	// score: 1→3 2→2 3→1 4→0 5→3 6→2 7→1 8→0 9→3 10→2
+	if got := fmt.Sprint(pages); got != "[[1 5 9 2] [6 10 3 7] [4 8 12]]" {
+		t.Fatalf("unexpected pages %s", got)
+	}
+	if ds.Data[0].GetByField("id") != 2 {
+		t.Fatal("After should not sort the dataset")
+	}
+
+	page, next, err := ds.After("", "score", 0)
+	if err != nil || next != "" || page.Count() != 11 {
+		t.Fatalf("limit 0 should return all records, got %d %q %v", page.Count(), next, err)
+	}
+}
+
+func TestDatasetAfterInvalidCursor(t *testing.T) {
+	ds := newFixture(pageFixture)
+	_, token, err := ds.After("", "score", 2)
+	if err != nil || token == "" {
+		t.Fatalf("expected next token, got %q %v", token, err)
+	}
+
+	data, _ := base64.RawURLEncoding.DecodeString(token)
+	data[5] ^= 1
+	tampered := base64.RawURLEncoding.EncodeToString(data)
+
+	for _, c := range []struct{ token, order string }{
+		{tampered, "score"},
+		{"not a token", "score"},
+		{token[:10], "score"},
+		{token, "score desc"},
+	} {
+		if _, _, err := ds.After(c.token, c.order, 2); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor for %q/%s, got %v", c.token, c.order, err)
+		}
+	}
+
+	// 更换密钥后旧令牌失效
+	SetCursorKey([]byte("secret"))
+	defer SetCursorKey(nil)
+	if _, _, err := ds.After(token, "score", 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("token signed with old key should be rejected, got %v", err)
+	}
+	_, token, _ = ds.After("", "score", 2)
+	if page, _, err := ds.After(token, "score", 2); err != nil || fmt.Sprint(page.Keys("id")) != "[3 7]" {
+		t.Fatalf("expected [3 7], got %v %v", page, err)
+	}
+
+	if _, _, err := NewDataSet().After("", "", 2); err == nil {
+		t.Fatal("expected error without order and key field")
+	}
+}
+
+func TestDatasetAfterUniqueness(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"g": 1, "n": 1},
+		map[string]any{"g": 1, "n": 2},
+		map[string]any{"g": 1, "n": 3},
+		map[string]any{"g": 1, "n": 4},
+	))
+	if _, _, err := ds.After("", "g", 2); err == nil {
+		t.Fatal("expected error for a non-unique order without key field")
+	}
+
+	var seen []any
+	token := ""
+	for {
+		page, next, err := ds.After(token, "g, n", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, page.Keys("n")...)
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	if fmt.Sprint(seen) != "[1 2 3 4]" {
+		t.Fatalf("expected every record once, got %v", seen)
+	}
+}
+
+func TestDatasetAfterScope(t *testing.T) {
+	a, b := newFixture(pageFixture), newFixture(pageFixture)
+	_, token, err := a.After("", "score", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := b.After(token, "score", 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("token of another unnamed dataset should be rejected, got %v", err)
+	}
+	view, _ := a.Page(0, 0)
+	if _, _, err := view.After(token, "score", 2); err != nil {
+		t.Fatalf("views should accept their root dataset's tokens, got %v", err)
+	}
+
+	a.Name, b.Name = "res.partner", "res.partner"
+	_, token, _ = a.After("", "score", 2)
+	if _, _, err := b.After(token, "score", 2); err != nil {
+		t.Fatalf("datasets with the same name should share tokens, got %v", err)
+	}
+	b.Name = "res.users"
+	if _, _, err := b.After(token, "score", 2); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("token of another model should be rejected, got %v", err)
+	}
+}
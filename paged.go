@@ -0,0 +1,446 @@
+package dataset
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ErrClosed 由 DataProvider 提供记录的数据集已被 Close
+var ErrClosed = errors.New("dataset is closed")
+
+// errStopScan scan 的回调提前结束遍历
+var errStopScan = errors.New("stop scan")
+
+const (
+	// DefaultPageSize NewDataSetFromProvider 每页的记录数
+	DefaultPageSize = 100
+	// DefaultPageWindow NewDataSetFromProvider 最多同时缓存的页数
+	DefaultPageWindow = 4
+)
+
+type (
+	// DataProvider 分页提供记录的数据源,见 NewDataSetFromProvider
+	DataProvider interface {
+		// Fetch 返回跳过 offset 条后最多 limit 条记录,少于 limit 条表示已到末尾。
+		// 预读时会在后台 goroutine 中调用,可能与其他 Fetch/Count 同时执行。
+		Fetch(ctx context.Context, offset, limit int) (*TDataSet, error)
+	}
+
+	// DataCounter DataProvider 可选实现的接口,提供记录总数,
+	// 未实现时 Count 需要逐页读取到末尾
+	DataCounter interface {
+		Count(ctx context.Context) (int, error)
+	}
+
+	// tPagedSource 按页读取并缓存 DataProvider 的记录,超出窗口时淘汰最久未使用的页
+	tPagedSource struct {
+		provider DataProvider
+		ctx      context.Context
+		cancel   context.CancelFunc
+		size     int
+		window   int
+		prefetch bool
+
+		mu    sync.Mutex
+		pages map[int]*tPage
+		lru   *list.List // 最近使用的页在前
+		total int        // 记录总数,未知时为 -1
+		err   error      // 首个读取错误
+	}
+
+	tPage struct {
+		index int
+		ds    *TDataSet
+		err   error
+		ready chan struct{} // 读取完成后关闭
+		elem  *list.Element
+	}
+)
+
+// WithPageSize NewDataSetFromProvider 每页读取 size 条记录,<= 0 时为 DefaultPageSize
+func WithPageSize(size int) Option {
+	return func(cfg *Config) {
+		cfg.pageSize = size
+	}
+}
+
+// WithPageWindow NewDataSetFromProvider 最多同时缓存 pages 页,<= 0 时为 DefaultPageWindow。
+// 启用预读时至少为 2,以免预读的页淘汰正在读取的页。
+func WithPageWindow(pages int) Option {
+	return func(cfg *Config) {
+		cfg.pageWindow = pages
+	}
+}
+
+// WithPrefetch NewDataSetFromProvider 是否在读取某页时于后台预读下一页,默认启用
+func WithPrefetch(enabled bool) Option {
+	return func(cfg *Config) {
+		cfg.noPrefetch = !enabled
+	}
+}
+
+// NewDataSetFromProvider 创建由 provider 按需分页提供记录的数据集,用于无法全部载入内存的大表。
+// 创建时同步读取第一页,字段结构与 KeyField 取自第一页,读取失败时返回错误。
+//
+// 游标导航(First/Next/Eof/Record)、Range、Count 以及 Filter/Where/GroupBy/聚合/ValueBy/Keys/
+// RecordByKey/Page/After 等读取方法经 provider 逐页读取记录,
+// 内存中最多保留 WithPageWindow 页,超出时淘汰最久未使用的页;读取某页时在后台预读下一页(见 WithPrefetch)。
+// RecordByKey 不建立索引,逐页查找。Filter/GroupBy 等派生结果引用读到的记录,不受页淘汰影响。
+// 读取时使用 WithLoadContext 的 ctx。读取出错时视为到达末尾,错误由 Err 返回(Range/Where 等返回错误的方法同时返回)。
+//
+// 数据集被冻结,Data 为空,写入方法返回 ErrFrozen 或不做任何操作;页中的记录应视为只读,
+// 修改在页被淘汰后丢失。实时过滤条件(见 filter.go)照常生效。
+// 数据源变化后调用 Refresh 丢弃缓存的页;不再使用时调用 Close 取消进行中的读取。
+func NewDataSetFromProvider(provider DataProvider, opts ...Option) (*TDataSet, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("provider is nil")
+	}
+
+	ds := NewDataSet(opts...)
+	cfg := ds.config
+	src := &tPagedSource{
+		provider: provider,
+		ctx:      cfg.loadCtx,
+		size:     cfg.pageSize,
+		window:   cfg.pageWindow,
+		prefetch: !cfg.noPrefetch,
+		pages:    make(map[int]*tPage),
+		lru:      list.New(),
+		total:    -1,
+	}
+	if src.ctx == nil {
+		src.ctx = context.Background()
+	}
+	src.ctx, src.cancel = context.WithCancel(src.ctx)
+	if src.size <= 0 {
+		src.size = DefaultPageSize
+	}
+	if src.window <= 0 {
+		src.window = DefaultPageWindow
+	}
+	if src.prefetch && src.window < 2 {
+		src.window = 2
+	}
+
+	page := src.load(0)
+	<-page.ready
+	if page.err != nil {
+		src.cancel()
+		return nil, page.err
+	}
+	ds.SetFields(page.ds.Fields()...)
+	ds.KeyField = page.ds.KeyField
+	ds.source = src
+	ds.Freeze()
+	ds.First()
+	return ds, nil
+}
+
+// IsPaged 是否由 DataProvider 提供记录
+func (self *TDataSet) IsPaged() bool {
+	return self != nil && self.source != nil
+}
+
+// Err 从 DataProvider 读取记录时发生的首个错误,见 NewDataSetFromProvider
+func (self *TDataSet) Err() error {
+	if self == nil || self.source == nil {
+		return nil
+	}
+
+	self.source.mu.Lock()
+	defer self.source.mu.Unlock()
+	return self.source.err
+}
+
+// Refresh 丢弃缓存的页、记录总数与错误,之后按需重新读取,游标回到首条记录。Close 后不做任何操作。
+func (self *TDataSet) Refresh() {
+	if self == nil || self.source == nil || self.source.ctx.Err() != nil {
+		return
+	}
+
+	src := self.source
+	src.mu.Lock()
+	src.pages = make(map[int]*tPage)
+	src.lru.Init()
+	src.total = -1
+	src.err = nil
+	src.mu.Unlock()
+	self.First()
+}
+
+// Close 取消进行中(含后台预读)的读取并丢弃缓存的页,之后不再读取,Err 返回 ErrClosed。
+// 不由 DataProvider 提供记录时不做任何操作。
+func (self *TDataSet) Close() error {
+	if self == nil || self.source == nil {
+		return nil
+	}
+
+	src := self.source
+	src.cancel()
+	src.mu.Lock()
+	src.pages = make(map[int]*tPage)
+	src.lru.Init()
+	src.err = ErrClosed
+	src.mu.Unlock()
+	return nil
+}
+
+// scan 依次访问全部记录(不考虑过滤条件),fn 返回 errStopScan 时提前结束;
+// 由 DataProvider 提供记录时逐页读取,读取出错时返回该错误
+func (self *TDataSet) scan(fn func(pos int, rec *TRecordSet) error) error {
+	if self == nil {
+		return nil
+	}
+
+	var err error
+	if self.source == nil {
+		for i, rec := range self.Data {
+			if err = fn(i, rec); err != nil {
+				break
+			}
+		}
+	} else {
+		for pos := 0; ; pos++ {
+			rec := self.source.get(pos)
+			if rec == nil {
+				err = self.Err()
+				break
+			}
+			if err = fn(pos, rec); err != nil {
+				break
+			}
+		}
+	}
+	if err == errStopScan {
+		return nil
+	}
+	return err
+}
+
+// records 全部记录(不考虑过滤条件)
+func (self *TDataSet) records() ([]*TRecordSet, error) {
+	if self.source == nil {
+		return slices.Clone(self.Data), nil
+	}
+
+	var res []*TRecordSet
+	err := self.scan(func(_ int, rec *TRecordSet) error {
+		res = append(res, rec)
+		return nil
+	})
+	return res, err
+}
+
+// recordByKeyPaged 逐页查找 KeyField(或 keyField)等于 key 的记录
+func (self *TDataSet) recordByKeyPaged(key any, keyField ...string) *TRecordSet {
+	field := self.KeyField
+	if len(keyField) > 0 && field == "" {
+		field = keyField[0]
+	}
+	if field == "" {
+		return nil
+	}
+
+	var res *TRecordSet
+	self.scan(func(_ int, rec *TRecordSet) error {
+		if sameKey(rec.GetByField(field), key) {
+			res = rec
+			return errStopScan
+		}
+		return nil
+	})
+	return res
+}
+
+// keysPaged 同 Keys,逐页读取
+func (self *TDataSet) keysPaged(fieldName ...string) []any {
+	field := "id"
+	if len(fieldName) > 0 {
+		field = fieldName[0]
+	} else if self.KeyField != "" {
+		field = self.KeyField
+	}
+
+	var res []any
+	self.scan(func(_ int, rec *TRecordSet) error {
+		if value := rec.GetByField(field); value != nil && !isBlank(value) {
+			res = append(res, value)
+		}
+		return nil
+	})
+	return res
+}
+
+// sameKey 两个值作为主键是否相同,规则同 RecordsIndex 的 map 键
+func sameKey(a, b any) bool {
+	a, b = mapKey(a), mapKey(b)
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// recordAt 第 pos 条记录,超出范围时返回 nil
+func (self *TDataSet) recordAt(pos int) *TRecordSet {
+	if self.source != nil {
+		return self.source.get(pos)
+	}
+	if pos < 0 || pos >= len(self.Data) {
+		return nil
+	}
+	return self.Data[pos]
+}
+
+// endAt pos 是否已超出最后一条记录
+func (self *TDataSet) endAt(pos int) bool {
+	if self.source != nil {
+		return self.source.get(pos) == nil
+	}
+	return pos >= len(self.Data)
+}
+
+// countPaged 同 Count。未设置过滤条件时优先使用 DataCounter
+func (self *TDataSet) countPaged() int {
+	if self.filter == nil {
+		return self.source.count()
+	}
+
+	count := 0
+	self.Range(func(pos int, rec *TRecordSet) error {
+		count++
+		return nil
+	})
+	return count
+}
+
+// load 返回第 index 页,未缓存时在后台开始读取;调用方等待 ready 后再访问页的内容
+func (self *tPagedSource) load(index int) *tPage {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if page, has := self.pages[index]; has {
+		self.lru.MoveToFront(page.elem)
+		return page
+	}
+
+	page := &tPage{index: index, ready: make(chan struct{})}
+	page.elem = self.lru.PushFront(page)
+	self.pages[index] = page
+	for self.lru.Len() > self.window {
+		old := self.lru.Remove(self.lru.Back()).(*tPage)
+		delete(self.pages, old.index)
+	}
+
+	go self.fetch(page)
+	return page
+}
+
+func (self *tPagedSource) fetch(page *tPage) {
+	defer close(page.ready)
+
+	ds, err := self.provider.Fetch(self.ctx, page.index*self.size, self.size)
+	if err == nil && ds == nil {
+		ds = NewDataSet()
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	page.ds, page.err = ds, err
+	if self.pages[page.index] != page {
+		return // 已被淘汰或 Refresh
+	}
+	if err != nil {
+		// 不缓存失败的页,Refresh 后重新读取
+		self.lru.Remove(page.elem)
+		delete(self.pages, page.index)
+		return
+	}
+	if len(ds.Data) < self.size {
+		self.total = page.index*self.size + len(ds.Data)
+	}
+}
+
+// get 第 pos 条记录,需要时读取其所在的页并预读下一页;超出末尾或读取出错时返回 nil
+func (self *tPagedSource) get(pos int) *TRecordSet {
+	if pos < 0 {
+		return nil
+	}
+
+	self.mu.Lock()
+	total, failed := self.total, self.err != nil
+	self.mu.Unlock()
+	if failed || (total >= 0 && pos >= total) {
+		return nil
+	}
+
+	index := pos / self.size
+	page := self.load(index)
+	<-page.ready
+	if page.err != nil {
+		self.fail(page.err)
+		return nil
+	}
+
+	n := min(len(page.ds.Data), self.size)
+	if self.prefetch && n == self.size && (total < 0 || (index+1)*self.size < total) {
+		self.load(index + 1)
+	}
+	if pos%self.size >= n {
+		return nil
+	}
+	return page.ds.Data[pos%self.size]
+}
+
+// count 记录总数:已知时直接返回,否则由 DataCounter 提供或逐页读取到末尾
+func (self *tPagedSource) count() int {
+	if self.ctx.Err() != nil {
+		return 0 // 已 Close
+	}
+
+	self.mu.Lock()
+	total := self.total
+	self.mu.Unlock()
+	if total >= 0 {
+		return total
+	}
+
+	if counter, ok := self.provider.(DataCounter); ok {
+		n, err := counter.Count(self.ctx)
+		if err != nil {
+			self.fail(err)
+			return 0
+		}
+		self.mu.Lock()
+		self.total = n
+		self.mu.Unlock()
+		return n
+	}
+
+	for index := 0; ; index++ {
+		page := self.load(index)
+		<-page.ready
+		if page.err != nil {
+			self.fail(page.err)
+			return index * self.size
+		}
+		if n := len(page.ds.Data); n < self.size {
+			return index*self.size + n
+		}
+	}
+}
+
+func (self *tPagedSource) fail(err error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.err == nil {
+		self.err = err
+	}
+}
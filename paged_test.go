@@ -0,0 +1,256 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testProvider 提供 id 为 1..total 的记录,记录每次读取的 offset
+type testProvider struct {
+	total   int
+	fails   map[int]bool // 读取这些 offset 时返回错误
+	counted bool
+
+	mu      sync.Mutex
+	offsets []int
+	fetched chan int // 非 nil 时每次读取后发送 offset
+}
+
+func (self *testProvider) Fetch(ctx context.Context, offset, limit int) (*TDataSet, error) {
+	self.mu.Lock()
+	self.offsets = append(self.offsets, offset)
+	self.mu.Unlock()
+	if self.fetched != nil {
+		defer func() { self.fetched <- offset }()
+	}
+
+	if self.fails[offset] {
+		return nil, errors.New("backend unavailable")
+	}
+	ds := NewDataSet()
+	ds.SetFields("id", "name")
+	for i := offset + 1; i <= min(offset+limit, self.total); i++ {
+		ds.NewRecord(map[string]any{"id": i, "name": fmt.Sprintf("row %d", i)})
+	}
+	ds.KeyField = "id"
+	return ds, nil
+}
+
+func (self *testProvider) fetches() []int {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return append([]int(nil), self.offsets...)
+}
+
+type countingProvider struct {
+	*testProvider
+}
+
+func (self countingProvider) Count(ctx context.Context) (int, error) {
+	self.mu.Lock()
+	self.counted = true
+	self.mu.Unlock()
+	return self.total, nil
+}
+
+func TestDatasetProviderNavigation(t *testing.T) {
+	provider := &testProvider{total: 25}
+	ds, err := NewDataSetFromProvider(provider, WithPageSize(10), WithPageWindow(2), WithPrefetch(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ds.IsPaged() || !ds.IsFrozen() || len(ds.Data) != 0 || ds.KeyField != "id" || fmt.Sprint(ds.Fields()) != "[id name]" {
+		t.Fatal("expected a frozen paged dataset with the first page's structure")
+	}
+
+	var ids []any
+	for ds.First(); !ds.Eof(); ds.Next() {
+		ids = append(ids, ds.Record().GetByField("id"))
+	}
+	if len(ids) != 25 || ids[0] != 1 || ids[24] != 25 {
+		t.Fatalf("unexpected records %v", ids)
+	}
+	if got := fmt.Sprint(provider.fetches()); got != "[0 10 20]" {
+		t.Fatalf("each page should be fetched once, got %s", got)
+	}
+	if ds.Count() != 25 {
+		t.Fatalf("expected count 25, got %d", ds.Count())
+	}
+
+	// 窗口只保留两页,回到首页时重新读取
+	ds.First()
+	if ds.Record().GetByField("id") != 1 {
+		t.Fatal("expected first record")
+	}
+	if got := fmt.Sprint(provider.fetches()); got != "[0 10 20 0]" {
+		t.Fatalf("evicted page should be fetched again, got %s", got)
+	}
+	ds.source.mu.Lock()
+	cached := len(ds.source.pages)
+	ds.source.mu.Unlock()
+	if cached > 2 {
+		t.Fatalf("window should hold at most 2 pages, got %d", cached)
+	}
+
+	var positions []int
+	ds.Range(func(pos int, rec *TRecordSet) error {
+		positions = append(positions, pos)
+		return nil
+	})
+	if len(positions) != 25 || positions[24] != 24 {
+		t.Fatalf("Range should visit every record, got %v", positions)
+	}
+
+	ds.SetFilter(func(rec *TRecordSet) bool { return rec.GetByField("id").(int)%10 == 0 })
+	ids = nil
+	for ds.First(); !ds.Eof(); ds.Next() {
+		ids = append(ids, ds.Record().GetByField("id"))
+	}
+	if fmt.Sprint(ids) != "[10 20]" || ds.Count() != 2 {
+		t.Fatalf("live filter should apply to paged records, got %v", ids)
+	}
+}
+
+func TestDatasetProviderPrefetch(t *testing.T) {
+	provider := &testProvider{total: 30, fetched: make(chan int, 100)}
+	ds, err := NewDataSetFromProvider(countingProvider{provider}, WithPageSize(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-provider.fetched // 第一页
+
+	ds.First()
+	if ds.Record().GetByField("id") != 1 {
+		t.Fatal("expected first record")
+	}
+	select {
+	case offset := <-provider.fetched:
+		if offset != 10 {
+			t.Fatalf("expected prefetch of offset 10, got %d", offset)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("next page was not prefetched")
+	}
+
+	if ds.Count() != 30 || !provider.counted {
+		t.Fatal("Count should use DataCounter")
+	}
+	for i := 0; i < 10; i++ {
+		ds.Next()
+	}
+	if ds.Record().GetByField("id") != 11 {
+		t.Fatal("expected record 11 from the prefetched page")
+	}
+	if got := fmt.Sprint(provider.fetches()[:2]); got != "[0 10]" {
+		t.Fatalf("prefetched page should not be fetched again, got %s", got)
+	}
+}
+
+func TestDatasetProviderError(t *testing.T) {
+	provider := &testProvider{total: 25}
+	provider.fails = map[int]bool{10: true}
+	ds, err := NewDataSetFromProvider(provider, WithPageSize(10), WithPrefetch(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for ds.First(); !ds.Eof(); ds.Next() {
+		n++
+	}
+	if n != 10 || ds.Err() == nil {
+		t.Fatalf("navigation should stop at the failing page, got %d records, err %v", n, ds.Err())
+	}
+	if err := ds.Range(func(pos int, rec *TRecordSet) error { return nil }); err == nil {
+		t.Fatal("Range should report the fetch error")
+	}
+
+	provider.fails = nil
+	ds.Refresh()
+	if ds.Err() != nil || ds.Count() != 25 {
+		t.Fatalf("Refresh should clear the error, got %v %d", ds.Err(), ds.Count())
+	}
+
+	failing := &testProvider{total: 5, fails: map[int]bool{0: true}}
+	if _, err := NewDataSetFromProvider(failing); err == nil {
+		t.Fatal("expected error when the first page fails")
+	}
+	if _, err := NewDataSetFromProvider(nil); err == nil {
+		t.Fatal("expected error for nil provider")
+	}
+}
+
+func TestDatasetProviderReadMethods(t *testing.T) {
+	provider := &testProvider{total: 25}
+	ds, err := NewDataSetFromProvider(provider, WithPageSize(10), WithPageWindow(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ds.IsEmpty() || ds.Count() != 25 {
+		t.Fatalf("expected 25 records, got empty=%v count=%d", ds.IsEmpty(), ds.Count())
+	}
+	if len(ds.ValueBy("id")) != 25 || len(ds.Keys()) != 25 || len(ds.Keys("name")) != 25 {
+		t.Fatal("ValueBy/Keys should read every page")
+	}
+	if rec := ds.RecordByKey(17); rec == nil || rec.GetByField("name") != "row 17" {
+		t.Fatal("RecordByKey should find records on later pages")
+	}
+	if ds.RecordByKey(99) != nil {
+		t.Fatal("RecordByKey should return nil for a missing key")
+	}
+	if rec := ds.RecordByField("name", "row 23"); rec == nil || rec.GetByField("id") != 23 {
+		t.Fatal("RecordByField should find records on later pages")
+	}
+	if sum := ds.Sum("id"); sum != 325 {
+		t.Fatalf("expected sum 325, got %v", sum)
+	}
+	if groups := ds.GroupBy("id"); len(groups) != 25 {
+		t.Fatalf("expected 25 groups, got %d", len(groups))
+	}
+	if view := ds.Filter("id", []any{3, 13, 23}); view.Count() != 3 {
+		t.Fatalf("expected 3 filtered records, got %d", view.Count())
+	}
+	if view, err := ds.Where("id > 20"); err != nil || view.Count() != 5 {
+		t.Fatalf("expected 5 records from Where, got %v", err)
+	}
+	if page, total := ds.Page(20, 10); total != 25 || page.Count() != 5 {
+		t.Fatalf("expected last page of 5 out of 25, got %d/%d", page.Count(), total)
+	}
+
+	ds.SetFilter(func(rec *TRecordSet) bool { return false })
+	if !ds.IsEmpty() {
+		t.Fatal("IsEmpty should honour the live filter")
+	}
+}
+
+func TestDatasetProviderClose(t *testing.T) {
+	provider := &testProvider{total: 25}
+	ds, err := NewDataSetFromProvider(provider, WithPageSize(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds.First()
+	ds.Record() // 开始预读第二页
+
+	if err := ds.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(ds.Err(), ErrClosed) || !ds.Eof() || ds.Count() != 0 {
+		t.Fatalf("closed dataset should have no records, got err %v", ds.Err())
+	}
+	if _, err := ds.Where("id > 0"); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+	ds.Refresh()
+	if !errors.Is(ds.Err(), ErrClosed) {
+		t.Fatal("Refresh should not reopen a closed dataset")
+	}
+	if ds.source.ctx.Err() == nil {
+		t.Fatal("Close should cancel the provider context")
+	}
+}
@@ -0,0 +1,205 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/volts-dev/utils"
+)
+
+const (
+	// Melt 生成的键/值字段名
+	MeltVariableField = "variable"
+	MeltValueField    = "value"
+)
+
+// Pivot 交叉表:按 rowFields 分组成行,colField 的每个不同值生成一列,
+// 单元格为该行该列所有 valueField 值经 agg 聚合的结果,例如:
+//
+//	ds.Pivot([]string{"partner_id"}, "month", "amount", AggSum)
+//
+// 行按首次出现的顺序排列,生成的列按 colField 值排序,列名为值的字符串形式。
+// colField 为 NULL 的记录被忽略(同 GroupBy);某行某列没有记录时单元格为 nil。
+// 新数据集保留 rowFields 的字段格式化器。
+// 参数无效、生成的列名与 rowFields 重名或字段总数超过 255 时返回错误。
+func (self *TDataSet) Pivot(rowFields []string, colField, valueField string, agg TAggregate) (*TDataSet, error) {
+	if self == nil {
+		return nil, nil
+	}
+	if len(rowFields) == 0 || colField == "" || valueField == "" || agg == nil {
+		return nil, fmt.Errorf("pivot requires row fields, a column field, a value field and an aggregate")
+	}
+
+	type (
+		pivotRow struct {
+			keys  []any
+			cells map[string][]any
+		}
+	)
+
+	var (
+		rows    []*pivotRow
+		rowIdx  = make(map[string]*pivotRow)
+		cols    = make(map[string]any) // 列名 -> 原始值(用于排序)
+		keyVals = make([]any, len(rowFields))
+	)
+	err := self.scan(func(_ int, rec *TRecordSet) error {
+		colValue := rec.GetByField(colField)
+		if isNull(colValue) {
+			return nil
+		}
+
+		for i, field := range rowFields {
+			keyVals[i] = rec.GetByField(field)
+		}
+		key := groupKey(keyVals)
+
+		row := rowIdx[key]
+		if row == nil {
+			row = &pivotRow{
+				keys:  slices.Clone(keyVals),
+				cells: make(map[string][]any),
+			}
+			rowIdx[key] = row
+			rows = append(rows, row)
+		}
+
+		col := utils.ToString(colValue)
+		if _, has := cols[col]; !has {
+			cols[col] = colValue
+		}
+		row.cells[col] = append(row.cells[col], rec.GetByField(valueField))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	colNames := make([]string, 0, len(cols))
+	for col := range cols {
+		colNames = append(colNames, col)
+	}
+	slices.SortFunc(colNames, func(a, b string) int {
+		return compareValue(cols[a], cols[b])
+	})
+
+	fields := append(slices.Clone(rowFields), colNames...)
+	if err := checkFieldNames(fields); err != nil {
+		return nil, fmt.Errorf("pivot on < %s >: %w", colField, err)
+	}
+
+	res := self.derive(rowFields)
+	res.SetFields(fields...)
+	for _, row := range rows {
+		values := make([]any, 0, len(rowFields)+len(colNames))
+		values = append(values, row.keys...)
+		for _, col := range colNames {
+			if cell, has := row.cells[col]; has {
+				values = append(values, agg(cell))
+			} else {
+				values = append(values, nil)
+			}
+		}
+		res.appendValues(values)
+	}
+	res.First()
+
+	return res, nil
+}
+
+// Melt 逆透视:每条记录的每个 valueFields 字段展开为一行,
+// 行由 idFields、MeltVariableField(原字段名)与 MeltValueField(原值)组成。
+// valueFields 为空时展开除 idFields 以外的所有字段。新数据集保留 idFields 的字段格式化器。
+// idFields 与 MeltVariableField/MeltValueField 重名或字段总数超过 255 时返回错误。
+func (self *TDataSet) Melt(idFields []string, valueFields []string) (*TDataSet, error) {
+	if self == nil {
+		return nil, nil
+	}
+	fields := append(slices.Clone(idFields), MeltVariableField, MeltValueField)
+	if err := checkFieldNames(fields); err != nil {
+		return nil, fmt.Errorf("melt: %w", err)
+	}
+
+	if len(valueFields) == 0 {
+		for _, field := range self.Fields() {
+			if !slices.Contains(idFields, field) {
+				valueFields = append(valueFields, field)
+			}
+		}
+	}
+
+	res := self.derive(idFields)
+	res.SetFields(fields...)
+	err := self.scan(func(_ int, rec *TRecordSet) error {
+		for _, field := range valueFields {
+			values := make([]any, 0, len(idFields)+2)
+			for _, id := range idFields {
+				values = append(values, rec.GetByField(id))
+			}
+			values = append(values, field, rec.GetByField(field))
+			res.appendValues(values)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.First()
+
+	return res, nil
+}
+
+// checkFieldNames 检查生成的字段名不重复且不超过字段数上限(见 SetFields)
+func checkFieldNames(fields []string) error {
+	if len(fields) > 255 {
+		return fmt.Errorf("%d fields exceed the limit of 255", len(fields))
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if seen[field] {
+			return fmt.Errorf("duplicate field < %s >", field)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+// derive 创建派生数据集,仅复制 fields 的字段格式化器
+func (self *TDataSet) derive(fields []string) *TDataSet {
+	res := NewDataSet()
+	for _, field := range fields {
+		if format, has := self.fieldFormater[field]; has {
+			res.SetFieldFormater(field, format)
+		}
+	}
+	return res
+}
+
+// appendValues 按数据集字段顺序追加一行,values 长度须与字段数一致
+func (self *TDataSet) appendValues(values []any) {
+	rec := NewRecordSet()
+	rec.dataset = self
+	rec.gen = self.gen
+	rec.index = len(self.Data)
+	self.setValues(rec, values)
+	rec.fieldsIndex = nil
+	rec.fieldsCount = self.FieldCount
+	self.Data = append(self.Data, rec)
+}
+
+// groupKey 生成多字段分组键,数值按数值比较(1 与 int64(1) 同组)
+func groupKey(values []any) string {
+	var sb strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteByte(0)
+		}
+		if v == nil {
+			sb.WriteString("<nil>")
+			continue
+		}
+		fmt.Fprintf(&sb, "%#v", distinctKey(v))
+	}
+	return sb.String()
+}
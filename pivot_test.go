@@ -0,0 +1,89 @@
+package dataset
+
+import (
+	"testing"
+)
+
+func TestDatasetPivot(t *testing.T) {
+	ds := newFixture(invoiceFixture)
+
+	pv, err := ds.Pivot([]string{"partner_id"}, "month", "amount", AggSum)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := pv.Fields()
+	if len(fields) != 3 || fields[0] != "partner_id" || fields[1] != "2026-01" || fields[2] != "2026-02" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	if pv.Count() != 2 {
+		t.Fatalf("expected 2 rows, got %d", pv.Count())
+	}
+
+	row := pv.RecordByField("partner_id", int64(1))
+	if row.GetByField("2026-01") != 7.0 || row.GetByField("2026-02") != 13.0 {
+		t.Errorf("unexpected cells for partner 1: %v", row.AsMap())
+	}
+
+	row = pv.RecordByField("partner_id", int64(2))
+	if row.GetByField("2026-02") != nil {
+		t.Errorf("missing cell should be nil, got %v", row.GetByField("2026-02"))
+	}
+
+	// 保留行字段格式化器
+	if m := row.AsMap(); m["partner_id"] != "2" {
+		t.Errorf("expected formatted partner_id, got %v", m["partner_id"])
+	}
+
+	if _, err := ds.Pivot(nil, "month", "amount", AggSum); err == nil {
+		t.Error("Pivot without row fields should fail")
+	}
+}
+
+func TestDatasetPivotFieldNames(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"k": 1, "col": "k", "x": 1},
+		map[string]any{"k": 2, "col": "x", "x": 2},
+	))
+	if _, err := ds.Pivot([]string{"k"}, "col", "x", AggSum); err == nil {
+		t.Fatal("a column named like a row field should fail")
+	}
+
+	wide := NewDataSet()
+	for i := 0; i < 300; i++ {
+		wide.NewRecord(map[string]any{"k": 1, "col": i, "x": i})
+	}
+	if _, err := wide.Pivot([]string{"k"}, "col", "x", AggSum); err == nil {
+		t.Fatal("more than 255 columns should fail")
+	}
+
+	if _, err := ds.Melt([]string{"k", MeltValueField}, nil); err == nil {
+		t.Fatal("an id field named like the value field should fail")
+	}
+}
+
+func TestDatasetMelt(t *testing.T) {
+	ds := newFixture(invoiceFixture)
+	pv, _ := ds.Pivot([]string{"partner_id"}, "month", "amount", AggSum)
+
+	melted, err := pv.Melt([]string{"partner_id"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if melted.Count() != 4 {
+		t.Fatalf("expected 4 rows, got %d", melted.Count())
+	}
+
+	fields := melted.Fields()
+	if len(fields) != 3 || fields[1] != MeltVariableField || fields[2] != MeltValueField {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	rec := melted.Data[1]
+	if rec.GetByField("partner_id") != int64(1) || rec.GetByField(MeltVariableField) != "2026-02" || rec.GetByField(MeltValueField) != 13.0 {
+		t.Errorf("unexpected melted row %v", rec.AsMap())
+	}
+	if m := rec.AsMap(); m["partner_id"] != "1" {
+		t.Errorf("expected formatted partner_id, got %v", m["partner_id"])
+	}
+}
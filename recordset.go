@@ -3,7 +3,6 @@ package dataset
 import (
 	"encoding/json"
 	"reflect"
-	"sync"
 
 	structmap "github.com/mitchellh/mapstructure"
 	"github.com/volts-dev/utils"
@@ -16,21 +15,21 @@ type (
 		ClassicValues []interface{} // 存储经典字段值
 		fieldsIndex   map[string]int
 		fieldsCount   int
-		index         int // the index of dataset.data
+		index         int            // the index of dataset.data
+		calcCache     map[string]any // 计算字段缓存
+		gen           uint64         // 归入数据集时数据集的 gen,见 snapshot.go
+		cowNext       *TRecordSet    // 写时复制产生的新记录
+		store         *tColumnStore  // 列式存储,非 nil 时值存于 store 的 slot 行而非 values
+		slot          int
 	}
 )
 
-var recordSetPool = sync.Pool{
-	New: func() interface{} {
-		return &TRecordSet{
-			index: -1,
-		}
-	},
-}
-
+// !NOTE! 记录不做池化复用。Record()/Range 回调/RecordsIndex/Data[i] 返回的指针只是借用,
+// 无法得知调用方何时不再使用;记录一旦被复用,仍持有旧指针的一方会读写另一条记录的值。
+// 因此记录只在不再被引用时由 GC 回收,Clear/Delete 不重置记录。
 func NewRecordSet(record ...map[string]interface{}) *TRecordSet {
-	recset := recordSetPool.Get().(*TRecordSet)
-	recset.Reset()
+	recset := &TRecordSet{}
+	recset.reset()
 
 	if len(record) == 0 {
 		return recset
@@ -48,6 +47,16 @@ func NewRecordSet(record ...map[string]interface{}) *TRecordSet {
 	return recset
 }
 
+// newOwnedRecord 创建归属 dataset 的空记录,不创建记录自身的字段索引
+func newOwnedRecord(dataset *TDataSet, index int) *TRecordSet {
+	return &TRecordSet{
+		dataset:     dataset,
+		gen:         dataset.gen,
+		index:       index,
+		fieldsCount: dataset.FieldCount,
+	}
+}
+
 func (self *TRecordSet) get(index int, classic bool) interface{} {
 	if index < 0 || index >= self.fieldsCount {
 		return nil
@@ -60,6 +69,10 @@ func (self *TRecordSet) get(index int, classic bool) interface{} {
 		return self.ClassicValues[index]
 	}
 
+	if self.store != nil {
+		return self.store.get(self.slot, index)
+	}
+
 	if index >= len(self.values) {
 		return nil
 	}
@@ -81,6 +94,8 @@ func (self *TRecordSet) set(index int, value interface{}, classic bool) bool {
 			self.ClassicValues = newVals
 		}
 		self.ClassicValues[index] = value
+	} else if self.store != nil {
+		self.store.set(self.slot, index, value)
 	} else {
 		if index >= len(self.values) {
 			// Grow values
@@ -101,6 +116,7 @@ func (self *TRecordSet) set(index int, value interface{}, classic bool) bool {
 
 // 重置记录字段索引
 func (self *TRecordSet) resetByFields(fields ...string) {
+	self.releaseSlot()
 	self.values = make([]interface{}, len(fields))
 	self.ClassicValues = make([]interface{}, 0)
 
@@ -132,29 +148,43 @@ func (self *TRecordSet) getFieldsIndex() map[string]int {
 
 // reset all data to blank
 func (self *TRecordSet) Reset() {
+	self = self.latest()
+	if self.dataset.IsFrozen() {
+		return
+	}
+	self.writable().reset()
+}
+
+func (self *TRecordSet) reset() {
 	self.dataset = nil
+	self.gen = 0
+	self.cowNext = nil
 	self.index = -1
 	self.fieldsIndex = nil // reset fieldsIndex explicitly
+	self.calcCache = nil
 	self.resetByFields()
 }
 
+// Free 清空不属于任何数据集的记录的值,以便尽早释放其引用的数据。
+// 属于数据集(包括已被删除)的记录仍可能被视图、变更日志或调用方借用,Free 不做任何操作,
+// 由 GC 在其不再被引用时回收。
 func (self *TRecordSet) Free() {
-	self.Reset()
-	// Optionally clear references to allow GC, though Reset already clears values if resetByFields drops things.
+	self = self.latest()
+	if self == nil || self.dataset != nil {
+		return
+	}
+
+	self.reset()
 	self.fieldsIndex = nil
 	self.values = nil
 	self.ClassicValues = nil
 	self.fieldsCount = 0
-	// !NOTE! 不要把 record 还回 recordSetPool。
-	// GroupBy/Filter/AppendRecord 会让同一个 *TRecordSet 指针被多个 dataset 共享,
-	// 而 Clear()/Delete() 会对仍被引用(或读路径仍在遍历)的 record 调用 Free();
-	// 一旦还回池子,Get() 会把它发给另一个 goroutine 并就地改写 values/fieldsIndex,
-	// 造成 use-after-free 数据竞争 -> 堆/栈损坏 -> runtime.(*lfstack).pop "unexpected return pc"。
-	// 池化仅是分配优化,在指针被共享的前提下本质不安全,故禁用回收。勿回退。
 }
 
 func (self *TRecordSet) Fields(fields ...string) []string {
-	if fields != nil {
+	self = self.latest()
+	if fields != nil && !self.dataset.IsFrozen() {
+		self = self.writable()
 		self.resetByFields(fields...)
 	}
 
@@ -169,16 +199,19 @@ func (self *TRecordSet) Fields(fields ...string) []string {
 
 // the record length
 func (self *TRecordSet) Length() int {
+	self = self.latest()
 	return self.fieldsCount
 }
 
 func (self *TRecordSet) SetDataset(dataset *TDataSet) {
+	self = self.writable()
 	self.dataset = dataset
 	if self.fieldsCount == 0 {
 	}
 }
 
 func (self *TRecordSet) GetFieldIndex(name string) int {
+	self = self.latest()
 	fieldsIdx := self.getFieldsIndex()
 	if fieldsIdx == nil {
 		return -1
@@ -192,6 +225,7 @@ func (self *TRecordSet) GetFieldIndex(name string) int {
 }
 
 func (self *TRecordSet) GetByIndex(index int, classic ...bool) interface{} {
+	self = self.latest()
 	var isclassic bool
 	if len(classic) > 0 {
 		isclassic = classic[0]
@@ -200,11 +234,8 @@ func (self *TRecordSet) GetByIndex(index int, classic ...bool) interface{} {
 }
 
 func (self *TRecordSet) GetByField(name string, classic ...bool) interface{} {
+	self = self.latest()
 	fieldsIdx := self.getFieldsIndex()
-	if fieldsIdx == nil {
-		return nil
-	}
-
 	if index, ok := fieldsIdx[name]; ok {
 		var isclassic bool
 		if len(classic) > 0 {
@@ -214,26 +245,73 @@ func (self *TRecordSet) GetByField(name string, classic ...bool) interface{} {
 		return self.get(index, isclassic)
 	}
 
+	// 计算字段
+	if self.dataset != nil && self.dataset.calcFields != nil {
+		if field, ok := self.dataset.calcFields[name]; ok {
+			return self.getCalc(field)
+		}
+	}
+
 	return nil
 }
 
 func (self *TRecordSet) IsEmpty() bool {
+	self = self.latest()
 	return self == nil || self.getFieldsIndex() == nil || self.fieldsCount == 0 //|| self.isEmpty
 }
 
 // !NOTE! 该函数支持动态添加字段
 // 字段被纳入Dataset.Fields
 func (self *TRecordSet) SetByField(field string, value interface{}, classic ...bool) bool {
+	self = self.latest()
+	if self.dataset.IsFrozen() {
+		return false
+	}
+
 	var isclassic bool
 	if len(classic) > 0 {
 		isclassic = classic[0]
 	}
 
+	// 计算字段只读
+	if self.dataset.IsCalcField(field) {
+		return false
+	}
+
 	// 权限检查
 	if self.dataset != nil && self.dataset.config.checkFields && !self.dataset.HasField(field) {
 		return false
 	}
 
+	if self.dataset != nil {
+		v, err := self.dataset.convertValue(field, value)
+		if err != nil {
+			return false
+		}
+		value = v
+	}
+
+	// 校验与变更钩子,仅对已在数据集中的记录执行
+	if self.dataset != nil && self.index >= 0 && !isclassic && self.dataset.validateOnWrite() {
+		if errs := self.dataset.validateUpdate(self, field, value); len(errs) > 0 {
+			return false
+		}
+	}
+
+	var (
+		hooks    *tHooks
+		oldValue any
+	)
+	if self.dataset != nil && self.index >= 0 && !isclassic && self.dataset.hooks != nil {
+		hooks = self.dataset.hooks
+		oldValue = self.GetByField(field, isclassic)
+		if err := hooks.fireBeforeUpdate(self, field, oldValue, value); err != nil {
+			return false
+		}
+	}
+
+	self = self.writable() // 被快照共享时写入副本
+
 	fieldsIdx := self.getFieldsIndex()
 	if fieldsIdx == nil {
 		self.fieldsIndex = make(map[string]int)
@@ -263,6 +341,8 @@ func (self *TRecordSet) SetByField(field string, value interface{}, classic ...b
 	if !self.set(index, value, isclassic) {
 		return false
 	}
+	self.invalidateCalc(field)
+	hooks.fireAfterUpdate(self, field, oldValue, value)
 
 	// 插入新记录到dataset
 	if self.dataset != nil && self.index == -1 {
@@ -282,6 +362,7 @@ func (self *TRecordSet) SetByField(field string, value interface{}, classic ...b
 }
 
 func (self *TRecordSet) FieldByIndex(idx int) *TFieldSet {
+	self = self.latest()
 	var fieldName string
 	var value int
 	fieldsIdx := self.getFieldsIndex()
@@ -296,6 +377,7 @@ func (self *TRecordSet) FieldByIndex(idx int) *TFieldSet {
 
 // 获取某个
 func (self *TRecordSet) FieldByName(name string) *TFieldSet {
+	self = self.latest()
 	fieldsIdx := self.getFieldsIndex()
 	if fieldsIdx != nil {
 		if idx, has := fieldsIdx[name]; has {
@@ -308,11 +390,15 @@ func (self *TRecordSet) FieldByName(name string) *TFieldSet {
 	if fieldsIdx != nil {
 		_, field.IsValid = fieldsIdx[name]
 	}
+	if !field.IsValid && self.dataset.IsCalcField(name) {
+		field.IsValid = true
+	}
 	return field
 }
 
 // convert to a string map
 func (self *TRecordSet) AsStrMap() map[string]string {
+	self = self.latest()
 	m := make(map[string]string)
 	fieldsIdx := self.getFieldsIndex()
 	for field := range fieldsIdx {
@@ -344,6 +430,7 @@ func isScalarValue(v any) bool {
 }
 
 func (self *TRecordSet) AsMap() map[string]interface{} {
+	self = self.latest()
 	m := make(map[string]interface{})
 	fieldsIdx := self.getFieldsIndex()
 	for field := range fieldsIdx {
@@ -364,6 +451,17 @@ func (self *TRecordSet) AsMap() map[string]interface{} {
 		m[field] = v
 	}
 
+	// 计算字段
+	if self.dataset != nil {
+		for name, field := range self.dataset.calcFields {
+			v := self.getCalc(field)
+			if format, ok := self.dataset.fieldFormater[name]; ok && v != nil && isScalarValue(v) {
+				v = format(v)
+			}
+			m[name] = v
+		}
+	}
+
 	return m
 }
 
@@ -398,6 +496,7 @@ func (self *TRecordSet) AsStruct(target interface{}, classic ...bool) error {
 }
 
 func (self *TRecordSet) MergeToMap(target map[string]string) (res map[string]string) {
+	self = self.latest()
 	fieldsIdx := self.getFieldsIndex()
 	for field := range fieldsIdx {
 		target[field] = utils.ToString(self.GetByField(field))
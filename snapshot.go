@@ -0,0 +1,219 @@
+package dataset
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrFrozen 对已冻结的数据集执行修改操作时返回
+var ErrFrozen = errors.New("dataset is frozen")
+
+type (
+	// TSnapshot 数据集某一时刻的只读快照,由 TDataSet.Snapshot 创建。
+	//
+	// 快照与源数据集共享记录存储,创建代价为 O(1)(仅复制字段结构);源数据集之后的
+	// AppendRecord/SetByField/Delete/SortBy 等修改按写时复制处理,不会影响快照。
+	// 快照的所有方法可被多个 goroutine 同时调用,也可与源数据集的修改并发进行
+	// (源数据集自身仍须遵守 TDataSet 的并发约定,或使用 TConcurrentDataSet)。
+	//
+	// Record/Range 返回的记录是只读副本:每次调用生成新的 *TRecordSet,与快照共享字段值,
+	// 对其 SetByField 返回 false。值为 map/slice 等复合值时不得原地修改。
+	TSnapshot struct {
+		ds   *TDataSet     // 冻结的字段结构,记录副本归属于它
+		data []*TRecordSet // 与源数据集共享的记录,只读
+
+		keyOnce sync.Once
+		keys    map[any]int
+	}
+)
+
+// 写时复制:
+//   - Snapshot 使数据集(视图时为其根数据集)的 gen 加一,此前归属它的记录(rec.gen < gen)
+//     视为被快照共享,不再原地修改:写入时复制出新记录替换 Data 中的位置,旧记录经
+//     cowNext 指向新记录,TRecordSet 的公开方法均转到最新记录上执行,
+//     因此调用方持有的记录指针与视图仍然有效;快照只读取旧记录的值,不受影响。
+//   - 被共享的 Data 切片在下一次结构修改(追加/删除/排序)前整体复制(dataShared)。
+
+// Snapshot 创建当前数据的只读快照,见 TSnapshot
+func (self *TDataSet) Snapshot() *TSnapshot {
+	if self == nil {
+		return nil
+	}
+
+	self.owner().gen++
+	self.dataShared = true
+
+	ds := self.cloneSchema()
+	ds.Name = self.Name
+	ds.frozen.Store(true)
+	return &TSnapshot{
+		ds:   ds,
+		data: self.Data[:len(self.Data):len(self.Data)],
+	}
+}
+
+// Freeze 冻结数据集,此后所有修改操作均被拒绝:返回 error 的方法返回 ErrFrozen,
+// 返回 bool 的方法(SetByField/Delete 等)返回 false,AddField 返回 -1,其余不做任何操作。
+// 冻结不可撤销。冻结视图只限制视图自身的修改,不影响其根数据集。
+func (self *TDataSet) Freeze() {
+	if self != nil {
+		self.frozen.Store(true)
+	}
+}
+
+// IsFrozen 是否已冻结
+func (self *TDataSet) IsFrozen() bool {
+	return self != nil && self.frozen.Load()
+}
+
+// ensureDataOwned 修改 Data 前复制与快照共享的切片
+func (self *TDataSet) ensureDataOwned() {
+	if self.dataShared {
+		self.Data = slices.Clone(self.Data)
+		self.dataShared = false
+	}
+}
+
+// cowRecord 复制被快照共享的记录 rec,替换其在 Data 中的位置并返回副本
+func (self *TDataSet) cowRecord(rec *TRecordSet) *TRecordSet {
+	cp := rec.clone(self, rec.index, false)
+
+	self.ensureDataOwned()
+	idx := rec.index
+	if idx < 0 || idx >= len(self.Data) || self.Data[idx] != rec {
+		idx = slices.Index(self.Data, rec)
+	}
+	if idx >= 0 {
+		self.Data[idx] = cp
+		cp.index = idx
+	}
+
+	rec.cowNext = cp
+	return cp
+}
+
+// isShared 记录是否可能被快照引用
+func (self *TRecordSet) isShared() bool {
+	return self.dataset != nil && self.gen < self.dataset.gen
+}
+
+// latest 返回写时复制后的最新记录
+func (self *TRecordSet) latest() *TRecordSet {
+	for self != nil && self.cowNext != nil {
+		self = self.cowNext
+	}
+	return self
+}
+
+// writable 返回可原地修改的最新记录,被快照共享时先复制
+func (self *TRecordSet) writable() *TRecordSet {
+	rec := self.latest()
+	if rec != nil && rec.isShared() {
+		return rec.dataset.cowRecord(rec)
+	}
+	return rec
+}
+
+// Count 记录数
+func (self *TSnapshot) Count() int {
+	if self == nil {
+		return 0
+	}
+	return len(self.data)
+}
+
+// Fields 快照时刻的字段列表副本
+func (self *TSnapshot) Fields() []string {
+	if self == nil {
+		return nil
+	}
+	return slices.Clone(self.ds.fields)
+}
+
+// HasField 快照时刻是否存在该字段(含计算字段)
+func (self *TSnapshot) HasField(name string) bool {
+	return self != nil && self.ds.HasField(name)
+}
+
+// Record 第 idx 条记录的只读副本,越界时返回 nil
+func (self *TSnapshot) Record(idx int) *TRecordSet {
+	if self == nil || idx < 0 || idx >= len(self.data) {
+		return nil
+	}
+
+	src := self.data[idx]
+	return &TRecordSet{
+		dataset:       self.ds,
+		index:         idx,
+		values:        src.values,
+		store:         src.store,
+		slot:          src.slot,
+		ClassicValues: src.ClassicValues,
+		fieldsCount:   src.fieldsCount,
+	}
+}
+
+// Get 第 idx 条记录的字段值,越界时返回 nil
+func (self *TSnapshot) Get(idx int, field string) any {
+	return self.Record(idx).GetByField(field)
+}
+
+// Range 依次遍历记录的只读副本
+func (self *TSnapshot) Range(fn func(pos int, record *TRecordSet) error) error {
+	if self == nil {
+		return nil
+	}
+
+	for i := range self.data {
+		if err := fn(i, self.Record(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordByKey 按快照时刻的 KeyField 查找记录,未找到时返回 nil
+func (self *TSnapshot) RecordByKey(key any) *TRecordSet {
+	if self == nil || self.ds.KeyField == "" {
+		return nil
+	}
+
+	self.keyOnce.Do(func() {
+		self.keys = make(map[any]int, len(self.data))
+		for i := range self.data {
+			if value := self.Get(i, self.ds.KeyField); !isNull(value) {
+				self.keys[mapKey(value)] = i
+			}
+		}
+	})
+
+	if idx, has := self.keys[mapKey(key)]; has {
+		return self.Record(idx)
+	}
+	return nil
+}
+
+// Aggregate 以 agg 聚合字段所有值,如 Aggregate("amount", AggSum)
+func (self *TSnapshot) Aggregate(field string, agg TAggregate) any {
+	values := make([]any, self.Count())
+	for i := range values {
+		values[i] = self.Get(i, field)
+	}
+	return agg(values)
+}
+
+// DataSet 复制快照内容为独立、可修改的数据集
+func (self *TSnapshot) DataSet() *TDataSet {
+	if self == nil {
+		return nil
+	}
+
+	res := self.ds.cloneSchema()
+	res.Name = self.ds.Name
+	for i := range self.data {
+		res.addDerived(self.Record(i))
+	}
+	res.First()
+	return res
+}
@@ -0,0 +1,148 @@
+package dataset
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestDatasetSnapshot(t *testing.T) {
+	ds := newFixture(viewFixture)
+	ds.SetKeyField("id")
+	rec := ds.Data[0]
+	view := ds.Filter("cat", []any{"A"})
+
+	snap := ds.Snapshot()
+	if snap.Count() != 3 || snap.Get(0, "cat") != "A" {
+		t.Fatalf("unexpected snapshot content: count=%d", snap.Count())
+	}
+
+	// 快照之后的修改不影响快照
+	rec.SetByField("cat", "Z")
+	rec.SetByField("note", "new")
+	ds.NewRecord(map[string]any{"id": 4, "cat": "B"})
+	ds.Delete(1)
+	ds.SortBy("id desc")
+
+	if snap.Count() != 3 || snap.Get(0, "cat") != "A" || snap.Get(1, "id") != 2 {
+		t.Fatal("snapshot must not see later writes")
+	}
+	if snap.HasField("note") || snap.Get(0, "note") != nil {
+		t.Fatal("snapshot must keep the schema of its creation time")
+	}
+	if r := snap.RecordByKey(3); r == nil || r.GetByField("cat") != "A" {
+		t.Fatal("snapshot lookup by key failed")
+	}
+	if snap.Record(0).SetByField("cat", "X") || snap.Get(0, "cat") != "A" {
+		t.Fatal("snapshot records must be read-only")
+	}
+
+	// 旧记录指针与视图仍指向最新数据
+	if rec.GetByField("cat") != "Z" || rec.GetByField("note") != "new" {
+		t.Fatal("record pointers taken before the snapshot should see later writes")
+	}
+	if view.Data[0].GetByField("cat") != "Z" {
+		t.Fatal("views should see writes made after a snapshot")
+	}
+	if ds.Count() != 3 || ds.Data[2].GetByField("cat") != "Z" || ds.Data[2].index != 2 {
+		t.Fatal("unexpected source content after copy-on-write")
+	}
+
+	// 快照可转为独立数据集
+	cp := snap.DataSet()
+	cp.Data[0].SetByField("cat", "C")
+	if cp.Count() != 3 || snap.Get(0, "cat") != "A" {
+		t.Fatal("DataSet should return an independent copy")
+	}
+}
+
+func TestDatasetSnapshotCalcField(t *testing.T) {
+	ds := newFixture(calcFixture)
+	snap := ds.Snapshot()
+	before := snap.Get(0, "amount")
+
+	ds.Data[0].SetByField("qty", 100)
+	if snap.Get(0, "amount") != before {
+		t.Fatalf("calc fields of a snapshot must use snapshot values, got %v want %v", snap.Get(0, "amount"), before)
+	}
+	if ds.Data[0].GetByField("amount") == before {
+		t.Fatal("source calc field should be recomputed")
+	}
+}
+
+func TestDatasetSnapshotAfterClear(t *testing.T) {
+	ds := newFixture(viewFixture)
+	snap := ds.Snapshot()
+	ds.Clear()
+
+	// 被快照共享的记录不得被回收复用
+	for i := 0; i < 10; i++ {
+		ds.NewRecord(map[string]any{"id": 100 + i, "cat": "X"})
+	}
+	if snap.Get(0, "id") != 1 || snap.Get(2, "cat") != "A" {
+		t.Fatal("records referenced by a snapshot must not be reused")
+	}
+}
+
+func TestDatasetFreeze(t *testing.T) {
+	ds := newFixture(viewFixture)
+	ds.Freeze()
+	if !ds.IsFrozen() {
+		t.Fatal("expected frozen dataset")
+	}
+
+	if err := ds.NewRecord(map[string]any{"id": 9}); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("expected ErrFrozen, got %v", err)
+	}
+	if ds.Data[0].SetByField("cat", "Z") || ds.Delete(0) || ds.AddField("x") != -1 {
+		t.Fatal("mutations of a frozen dataset must fail")
+	}
+	if err := ds.SetFieldKind("id", KindDecimal); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("expected ErrFrozen, got %v", err)
+	}
+	ds.SortBy("id desc")
+	ds.Clear()
+	if ds.Count() != 3 || ds.Data[0].GetByField("cat") != "A" || ds.Data[0].GetByField("id") != 1 {
+		t.Fatal("frozen dataset was modified")
+	}
+
+	// 读操作与派生照常
+	if ds.Filter("cat", []any{"A"}).Count() != 2 {
+		t.Fatal("frozen dataset should still be readable")
+	}
+}
+
+func TestDatasetSnapshotConcurrent(t *testing.T) {
+	cds := NewConcurrent(newFixture(viewFixture))
+	var wg sync.WaitGroup
+
+	for n := 0; n < 4; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				snap := cds.Snapshot()
+				count := snap.Count()
+				for j := 0; j < 10; j++ {
+					snap.Range(func(pos int, rec *TRecordSet) error {
+						rec.AsMap()
+						return nil
+					})
+					if snap.Count() != count {
+						t.Error("snapshot count changed")
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 200; i++ {
+		cds.NewRecord(map[string]any{"id": 10 + i, "cat": "C"})
+		cds.Set(0, "cat", i)
+		if i%3 == 0 {
+			cds.Delete(1)
+		}
+	}
+	wg.Wait()
+}
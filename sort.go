@@ -0,0 +1,197 @@
+package dataset
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/volts-dev/utils"
+)
+
+type (
+	sortField struct {
+		name string
+		desc bool
+	}
+)
+
+// parseOrder 解析排序描述,格式同 ORM 的 order:"amount desc", "id"
+func parseOrder(orders ...string) []sortField {
+	var res []sortField
+	for _, order := range orders {
+		for _, part := range strings.Split(order, ",") {
+			items := strings.Fields(part)
+			if len(items) == 0 {
+				continue
+			}
+
+			field := sortField{name: items[0]}
+			if len(items) > 1 && strings.EqualFold(items[1], "desc") {
+				field.desc = true
+			}
+			res = append(res, field)
+		}
+	}
+	return res
+}
+
+// SortBy 按字段稳定排序记录,支持计算字段。
+// 每项格式为 "字段名 [asc|desc]",多个字段依次比较,例如:
+//
+//	ds.SortBy("partner_id", "amount desc")
+//
+// 排序后游标回到首条记录。
+func (self *TDataSet) SortBy(orders ...string) {
+	fields := parseOrder(orders...)
+	if len(fields) == 0 {
+		return
+	}
+
+	self.Sort(func(a, b *TRecordSet) int {
+		return compareRecords(a, b, fields)
+	})
+}
+
+// Sort 以自定义比较函数稳定排序记录,fn 返回值约定同 slices.SortStableFunc
+func (self *TDataSet) Sort(fn func(a, b *TRecordSet) int) {
+	if self == nil || fn == nil || self.IsFrozen() {
+		return
+	}
+
+	self.Lock()
+	self.ensureDataOwned()
+	slices.SortStableFunc(self.Data, fn)
+	for i, rec := range self.Data {
+		if rec.dataset == self {
+			rec.index = i
+		}
+	}
+	self.Unlock()
+
+	self.First()
+}
+
+func compareRecords(a, b *TRecordSet, fields []sortField) int {
+	for _, field := range fields {
+		c := compareValue(a.GetByField(field.name), b.GetByField(field.name))
+		if c != 0 {
+			if field.desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValue 比较两个字段值,nil 最小;数值类型之间按数值比较,
+// 其余同类型按自然顺序,类型不同时退化为字符串比较。
+func compareValue(a, b any) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	// 定点数与其他数值精确比较
+	if x, ok := a.(TDecimal); ok {
+		if y, err := NewDecimal(b); err == nil {
+			return x.Cmp(y)
+		}
+	} else if y, ok := b.(TDecimal); ok {
+		if x, err := NewDecimal(a); err == nil {
+			return x.Cmp(y)
+		}
+	}
+
+	// 整数之间直接比较,避免大整数(雪花 id)转 float64 丢精度
+	if x, ok := toInteger(a); ok {
+		if y, ok := toInteger(b); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			default:
+				return 1
+			}
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+
+	return strings.Compare(utils.ToString(a), utils.ToString(b))
+}
+
+// toNumber 将数值类型转为 float64,非数值类型返回 false
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// toInteger 将有符号整数类型转为 int64,其他类型返回 false
+func toInteger(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,50 @@
+package dataset
+
+import (
+	"testing"
+)
+
+func TestDatasetSortBy(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"id": int64(1), "cat": "B", "val": 10},
+		map[string]any{"id": int64(2), "cat": "A", "val": 20},
+		map[string]any{"id": int64(3), "cat": "B", "val": 30},
+		map[string]any{"id": int64(4), "cat": nil, "val": 40},
+	))
+
+	ds.SortBy("cat", "val desc")
+
+	want := []int64{4, 2, 3, 1}
+	for i, rec := range ds.Data {
+		if rec.GetByField("id") != want[i] {
+			t.Fatalf("pos %d: expected id %d, got %v", i, want[i], rec.GetByField("id"))
+		}
+		if rec.index != i {
+			t.Fatalf("pos %d: record index not updated (%d)", i, rec.index)
+		}
+	}
+	if ds.Position() != 0 {
+		t.Fatalf("expected cursor reset to 0, got %d", ds.Position())
+	}
+}
+
+func TestCompareValue(t *testing.T) {
+	cases := []struct {
+		a, b any
+		want int
+	}{
+		{nil, 1, -1},
+		{1, nil, 1},
+		{1, 2.5, -1},
+		{int64(1) << 60, int64(1)<<60 + 1, -1},
+		{"b", "a", 1},
+		{true, false, 1},
+		{3, "3", 0},
+	}
+
+	for _, c := range cases {
+		if got := compareValue(c.a, c.b); got != c.want {
+			t.Errorf("compareValue(%v, %v) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
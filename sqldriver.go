@@ -0,0 +1,333 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// DriverName database/sql 驱动名,见 RegisterTable
+const DriverName = "dataset"
+
+type (
+	// tSQLDatabase 一个 DSN 对应的表集合,语句逐条串行执行
+	tSQLDatabase struct {
+		mu     sync.Mutex
+		tables map[string]*TDataSet
+	}
+
+	tSQLDriver struct{}
+
+	tSQLConn struct {
+		db *tSQLDatabase
+		tx *tSQLTx
+	}
+
+	// tSQLTx 事务:首次写入某个表前在其上创建保存点,提交时释放,回滚时回滚到保存点。
+	// 为此启用了变更日志的表在事务结束时停用,使日志不随后续写入增长。
+	tSQLTx struct {
+		conn   *tSQLConn
+		points map[*TDataSet]TSavepoint
+		temp   map[*TDataSet]bool
+	}
+
+	tSQLStmt struct {
+		conn   *tSQLConn
+		stmt   any
+		params int
+	}
+
+	tSQLRows struct {
+		ds  *TDataSet
+		pos int
+	}
+)
+
+var sqlDatabases = struct {
+	sync.Mutex
+	m map[string]*tSQLDatabase
+}{m: make(map[string]*tSQLDatabase)}
+
+func init() {
+	sql.Register(DriverName, tSQLDriver{})
+}
+
+// RegisterTable 将 ds 注册为数据库 db 中名为 table 的表,之后可经
+// sql.Open(DriverName, db) 以 SQL 访问,用于以内存数据代替真实数据库进行测试:
+//
+//	dataset.RegisterTable("fixtures", "orders", orders)
+//	db, _ := sql.Open(dataset.DriverName, "fixtures")
+//	rows, _ := db.Query("SELECT state, COUNT(*) FROM orders WHERE amount > $1 GROUP BY state", 100)
+//
+// 支持的语句:
+//   - SELECT 列/表达式/*/聚合(COUNT SUM MIN MAX AVG)[AS 别名] FROM 表 [WHERE] [GROUP BY]
+//     [ORDER BY 表达式/别名/列序号 [ASC|DESC]] [LIMIT n] [OFFSET n];
+//   - INSERT INTO 表 [(列, ...)] VALUES (...), ...;
+//   - UPDATE 表 SET 列 = 表达式, ... [WHERE];
+//   - DELETE FROM 表 [WHERE]。
+//
+// 表达式支持 AND/OR/NOT、比较、IS [NOT] NULL、[NOT] IN、[NOT] BETWEEN、[NOT] LIKE、
+// + - * / %、|| 与字符串/数值/日期函数(见 Compile),占位符为 ? 或 $n。
+// 不支持 JOIN、子查询与 HAVING。
+//
+// 同一 db 的语句串行执行;写入直接修改 ds(触发钩子与校验规则),其他代码同时直接访问 ds 时需自行同步。
+// 事务以变更日志的保存点实现(表未启用变更日志时在事务中首次写入时临时启用,事务结束时停用),
+// 回滚时撤销事务中的写入,但没有隔离性。
+// 同名的表被替换,ds 为 nil 时等同 UnregisterTable。
+func RegisterTable(db, table string, ds *TDataSet) {
+	if ds == nil {
+		UnregisterTable(db, table)
+		return
+	}
+
+	sqlDatabases.Lock()
+	d, has := sqlDatabases.m[db]
+	if !has {
+		d = &tSQLDatabase{tables: make(map[string]*TDataSet)}
+		sqlDatabases.m[db] = d
+	}
+	sqlDatabases.Unlock()
+
+	d.mu.Lock()
+	d.tables[table] = ds
+	d.mu.Unlock()
+}
+
+// UnregisterTable 移除数据库 db 中的表
+func UnregisterTable(db, table string) {
+	sqlDatabases.Lock()
+	d, has := sqlDatabases.m[db]
+	sqlDatabases.Unlock()
+	if !has {
+		return
+	}
+
+	d.mu.Lock()
+	delete(d.tables, table)
+	d.mu.Unlock()
+}
+
+func (tSQLDriver) Open(name string) (driver.Conn, error) {
+	sqlDatabases.Lock()
+	defer sqlDatabases.Unlock()
+
+	d, has := sqlDatabases.m[name]
+	if !has {
+		d = &tSQLDatabase{tables: make(map[string]*TDataSet)}
+		sqlDatabases.m[name] = d
+	}
+	return &tSQLConn{db: d}, nil
+}
+
+func (self *tSQLConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, params, err := parseSQL(query)
+	if err != nil {
+		return nil, err
+	}
+	return &tSQLStmt{conn: self, stmt: stmt, params: params}, nil
+}
+
+func (self *tSQLConn) Close() error {
+	if self.tx != nil {
+		return self.tx.Rollback()
+	}
+	return nil
+}
+
+func (self *tSQLConn) Begin() (driver.Tx, error) {
+	return self.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (self *tSQLConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if self.tx != nil {
+		return nil, errors.New("transaction already in progress")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("read-only transactions are not supported")
+	}
+	self.tx = &tSQLTx{conn: self, points: make(map[*TDataSet]TSavepoint), temp: make(map[*TDataSet]bool)}
+	return self.tx, nil
+}
+
+func (self *tSQLTx) Commit() error {
+	return self.finish(false)
+}
+
+func (self *tSQLTx) Rollback() error {
+	return self.finish(true)
+}
+
+func (self *tSQLTx) finish(rollback bool) error {
+	db := self.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if self.conn.tx != self {
+		return driver.ErrBadConn
+	}
+	self.conn.tx = nil
+
+	var errs []error
+	for ds, sp := range self.points {
+		if rollback {
+			errs = append(errs, ds.RollbackTo(sp))
+		}
+		errs = append(errs, ds.ReleaseSavepoint(sp))
+		if self.temp[ds] {
+			ds.disableChangeLog()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (self *tSQLStmt) Close() error {
+	return nil
+}
+
+func (self *tSQLStmt) NumInput() int {
+	return self.params
+}
+
+func (self *tSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	n, _, err := self.run(args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(n), nil
+}
+
+// Query 执行语句,非 SELECT 语句返回无列的空结果
+func (self *tSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	_, res, err := self.run(args)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		res = NewDataSet()
+	}
+	return &tSQLRows{ds: res}, nil
+}
+
+func (self *tSQLStmt) run(values []driver.Value) (int64, *TDataSet, error) {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+
+	db := self.conn.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var table string
+	switch stmt := self.stmt.(type) {
+	case *tSelectStmt:
+		var ds *TDataSet
+		if stmt.table != "" {
+			var err error
+			if ds, err = lookupTable(db.tables, stmt.table); err != nil {
+				return 0, nil, err
+			}
+		}
+		res, err := execSelect(context.Background(), stmt, ds, args)
+		return 0, res, err
+	case *tInsertStmt:
+		table = stmt.table
+	case *tUpdateStmt:
+		table = stmt.table
+	case *tDeleteStmt:
+		table = stmt.table
+	}
+
+	ds, err := lookupTable(db.tables, table)
+	if err != nil {
+		return 0, nil, err
+	}
+	if tx := self.conn.tx; tx != nil {
+		if _, has := tx.points[ds]; !has {
+			tx.temp[ds] = ds.changeLog() == nil
+			tx.points[ds] = ds.Savepoint()
+		}
+	}
+
+	var n int64
+	switch stmt := self.stmt.(type) {
+	case *tInsertStmt:
+		n, err = execInsert(stmt, ds, args)
+	case *tUpdateStmt:
+		n, err = execUpdate(stmt, ds, args)
+	case *tDeleteStmt:
+		n, err = execDelete(stmt, ds, args)
+	}
+	return n, nil, err
+}
+
+func (self *tSQLRows) Columns() []string {
+	return self.ds.Fields()
+}
+
+func (self *tSQLRows) Close() error {
+	return nil
+}
+
+func (self *tSQLRows) Next(dest []driver.Value) error {
+	if self.pos >= len(self.ds.Data) {
+		return io.EOF
+	}
+	rec := self.ds.Data[self.pos]
+	self.pos++
+	for i := range dest {
+		v, err := driverValue(rec.GetByIndex(i))
+		if err != nil {
+			return fmt.Errorf("column < %s >: %w", self.ds.Fields()[i], err)
+		}
+		dest[i] = v
+	}
+	return nil
+}
+
+// ColumnTypeDatabaseTypeName 由列中首个非空值推断的类型名,全为 NULL 时为空
+func (self *tSQLRows) ColumnTypeDatabaseTypeName(index int) string {
+	for _, rec := range self.ds.Data {
+		switch rec.GetByIndex(index).(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return "BIGINT"
+		case float32, float64:
+			return "DOUBLE"
+		case TDecimal:
+			return "NUMERIC"
+		case bool:
+			return "BOOLEAN"
+		case time.Time:
+			return "TIMESTAMP"
+		case []byte:
+			return "BYTEA"
+		default:
+			return "TEXT"
+		}
+	}
+	return ""
+}
+
+// driverValue 转为 driver.Value:整数为 int64,浮点为 float64,TDecimal 为字符串,
+// map/切片等其他类型编码为 JSON 字符串
+func driverValue(v any) (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if dv, err := driver.DefaultParameterConverter.ConvertValue(v); err == nil {
+		return dv, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,157 @@
+package dataset
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestSQLDriverQuery(t *testing.T) {
+	RegisterTable("driver-query", "invoice", newFixture(sqlInvoiceFixture))
+	defer UnregisterTable("driver-query", "invoice")
+
+	db, err := sql.Open(DriverName, "driver-query")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT partner_id, SUM(amount) AS total FROM invoice WHERE state = $1 GROUP BY partner_id ORDER BY total DESC LIMIT 2", "posted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols, _ := rows.Columns()
+	if len(cols) != 2 || cols[0] != "partner_id" || cols[1] != "total" {
+		t.Fatalf("unexpected columns %v", cols)
+	}
+	types, _ := rows.ColumnTypes()
+	if types[1].DatabaseTypeName() != "BIGINT" {
+		t.Fatalf("unexpected column type %q", types[1].DatabaseTypeName())
+	}
+
+	var got [][2]int64
+	for rows.Next() {
+		var partner, total int64
+		if err := rows.Scan(&partner, &total); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, [2]int64{partner, total})
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != [2]int64{10, 400} || got[1] != [2]int64{30, 200} {
+		t.Fatalf("unexpected rows %v", got)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM INVOICE WHERE amount IS NULL").Scan(&count); err != nil || count != 1 {
+		t.Fatalf("count=%d err=%v", count, err)
+	}
+
+	// 结果可经 NewDataSetFromRows 读回数据集
+	rows, _ = db.Query("SELECT * FROM invoice WHERE id IN (?, ?)", 1, 3)
+	res, err := NewDataSetFromRows(rows)
+	if err != nil || res.Count() != 2 || res.KeyField != "id" {
+		t.Fatalf("unexpected dataset from rows: %v", err)
+	}
+
+	if _, err := db.Query("SELECT * FROM missing"); !errors.Is(err, ErrUnknownTable) {
+		t.Fatalf("expected ErrUnknownTable, got %v", err)
+	}
+	var syntax *TSyntaxError
+	if _, err := db.Query("SELEC 1"); !errors.As(err, &syntax) {
+		t.Fatalf("expected a syntax error, got %v", err)
+	}
+}
+
+func TestSQLDriverExec(t *testing.T) {
+	ds := newFixture(sqlInvoiceFixture)
+	RegisterTable("driver-exec", "invoice", ds)
+	defer UnregisterTable("driver-exec", "invoice")
+
+	db, _ := sql.Open(DriverName, "driver-exec")
+	defer db.Close()
+
+	res, err := db.Exec("INSERT INTO invoice (id, partner_id, state, amount) VALUES (?, ?, ?, ?)", 6, 40, "draft", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 || ds.Count() != 6 {
+		t.Fatalf("insert affected %d rows, dataset has %d", n, ds.Count())
+	}
+
+	res, err = db.Exec("UPDATE invoice SET state = 'posted' WHERE state = 'draft'")
+	if n, _ := res.RowsAffected(); err != nil || n != 2 {
+		t.Fatalf("update: n=%d err=%v", n, err)
+	}
+	res, err = db.Exec("DELETE FROM invoice WHERE partner_id = ?", 20)
+	if n, _ := res.RowsAffected(); err != nil || n != 2 || ds.Count() != 4 {
+		t.Fatalf("delete: n=%d err=%v", n, err)
+	}
+
+	// 事务回滚撤销写入
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("DELETE FROM invoice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO invoice (id) VALUES (99)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	var ids int
+	db.QueryRow("SELECT COUNT(*) FROM invoice WHERE id = 99").Scan(&ids)
+	if ds.Count() != 4 || ids != 0 {
+		t.Fatalf("rollback should restore the table, got %d records", ds.Count())
+	}
+	// 回滚后的主键查找不应追加空白行
+	tx, _ = db.Begin()
+	tx.Exec("INSERT INTO invoice (id) VALUES (98)")
+	tx.Rollback()
+	var total int
+	ds.RecordByKey(1, "id")
+	if err := db.QueryRow("SELECT COUNT(*) FROM invoice").Scan(&total); err != nil || total != 4 || len(ds.Data) != 4 {
+		t.Fatalf("rollback should leave 4 rows, got COUNT(*) %d, Data %d", total, len(ds.Data))
+	}
+
+	tx, _ = db.Begin()
+	tx.Exec("UPDATE invoice SET amount = 0 WHERE id = 1")
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	var amount int
+	if err := db.QueryRow("SELECT amount FROM invoice WHERE id = 1").Scan(&amount); err != nil || amount != 0 {
+		t.Fatalf("committed update lost: amount=%d err=%v", amount, err)
+	}
+
+	if ds.changeLog() != nil {
+		t.Fatal("change log enabled by a transaction should be disabled when it finishes")
+	}
+	logged := newFixture(sqlInvoiceFixture)
+	logged.EnableChangeLog(0)
+	RegisterTable("driver-exec", "logged", logged)
+	defer UnregisterTable("driver-exec", "logged")
+	tx, _ = db.Begin()
+	tx.Exec("DELETE FROM logged WHERE id = 1")
+	tx.Commit()
+	if !logged.CanUndo() {
+		t.Fatal("a change log enabled before the transaction should be kept")
+	}
+
+	// 全为 NULL 的行同样插入
+	res, err = db.Exec("INSERT INTO invoice (id, amount) VALUES (NULL, NULL)")
+	if n, _ := res.RowsAffected(); err != nil || n != 1 || ds.Count() != 5 {
+		t.Fatalf("insert of NULL values: n=%d err=%v", n, err)
+	}
+
+	// 被钩子否决的写入返回 ErrWriteRejected
+	ds.BeforeDelete(func(rec *TRecordSet) error { return errors.New("locked") })
+	if _, err := db.Exec("DELETE FROM invoice WHERE id = 1"); !errors.Is(err, ErrWriteRejected) {
+		t.Fatalf("expected ErrWriteRejected, got %v", err)
+	}
+}
@@ -0,0 +1,521 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownTable 语句引用的表未注册
+var ErrUnknownTable = errors.New("unknown table")
+
+type (
+	// tOutput 查询结果的一列
+	tOutput struct {
+		name string
+		expr tExpr
+	}
+
+	// tSelectPlan 绑定到表的查询
+	tSelectPlan struct {
+		stmt       *tSelectStmt
+		table      *TDataSet
+		outputs    []tOutput
+		fields     []string // 结果字段名
+		groupBy    []tExpr  // 列序号与别名已替换为结果列表达式
+		orderBy    []tExpr
+		aggregated bool
+		columns    map[*tColumnRef]tBinding
+	}
+
+	// tResultRow 查询结果的一行及其排序键
+	tResultRow struct {
+		values []any
+		keys   []any
+	}
+)
+
+// 语句执行:SELECT 返回新建的数据集,INSERT/UPDATE/DELETE 按普通写入修改表
+// (触发钩子与校验规则,写入失败时已修改的行保留)并返回受影响的行数。
+// 列名与表名先精确匹配,再不区分大小写匹配。
+
+// lookupTable 按名称查找表
+func lookupTable(tables map[string]*TDataSet, name string) (*TDataSet, error) {
+	if ds, has := tables[name]; has {
+		return ds, nil
+	}
+	for key, ds := range tables {
+		if strings.EqualFold(key, name) {
+			return ds, nil
+		}
+	}
+	return nil, fmt.Errorf("%w < %s >", ErrUnknownTable, name)
+}
+
+// resolveField 列名对应的字段名(含计算字段)
+func resolveField(ds *TDataSet, name string) (string, bool) {
+	if ds == nil {
+		return "", false
+	}
+	if ds.HasField(name) {
+		return name, true
+	}
+	for _, field := range append(slices.Clone(ds.Fields()), ds.CalcFields()...) {
+		if strings.EqualFold(field, name) {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// bindColumns 解析表达式中的列引用,列不存在时返回错误。
+// 首段不是列名但带有路径时视为表名前缀:t.col 绑定到列 col。
+func bindColumns(ds *TDataSet, binds map[*tColumnRef]tBinding, exprs ...tExpr) error {
+	for _, expr := range exprs {
+		err := walkExpr(expr, func(e tExpr) error {
+			col, ok := e.(*tColumnRef)
+			if !ok {
+				return nil
+			}
+			if field, has := resolveField(ds, col.name); has {
+				binds[col] = tBinding{field: field, path: col.path}
+				return nil
+			}
+			if len(col.path) > 0 {
+				if field, has := resolveField(ds, col.path[0]); has {
+					binds[col] = tBinding{field: field, path: col.path[1:]}
+					return nil
+				}
+			}
+			return fmt.Errorf("unknown column < %s > at position %d", col.name, col.pos)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchRecords 满足 where 的记录,where 为 nil 时为全部记录
+func matchRecords(ds *TDataSet, where tExpr, env *tEnv) ([]*TRecordSet, error) {
+	if where == nil {
+		return ds.records()
+	}
+	if hasAggregate(where) {
+		return nil, fmt.Errorf("aggregate functions are not allowed in WHERE")
+	}
+
+	var res []*TRecordSet
+	err := ds.scan(func(i int, rec *TRecordSet) error {
+		if err := checkContext(env.ctx, i); err != nil {
+			return err
+		}
+		env.rec = rec
+		ok, err := evalBool(where, env)
+		if ok {
+			res = append(res, rec)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	env.rec = nil
+	return res, nil
+}
+
+// planSelect 解析查询的结果列、分组与排序表达式并绑定列名,table 为 nil 时(无 FROM)对空行求值一次。
+//
+// 含 GROUP BY 或聚合函数时按分组输出,未分组的列取分组中首条记录的值;
+// ORDER BY/GROUP BY 可引用结果列名/别名或以 1 起始的列序号,排序时 NULL 排在最前(同 SortBy)。
+func planSelect(stmt *tSelectStmt, table *TDataSet) (*tSelectPlan, error) {
+	plan := &tSelectPlan{stmt: stmt, table: table, columns: make(map[*tColumnRef]tBinding)}
+
+	// 结果列
+	for i, item := range stmt.items {
+		if item.star {
+			if table == nil {
+				return nil, fmt.Errorf("SELECT * requires a FROM clause")
+			}
+			for _, field := range table.Fields() {
+				plan.outputs = append(plan.outputs, tOutput{name: field, expr: &tColumnRef{name: field}})
+			}
+			continue
+		}
+
+		name := item.alias
+		if name == "" {
+			switch e := item.expr.(type) {
+			case *tColumnRef:
+				name = e.name
+				if len(e.path) > 0 {
+					name = e.path[len(e.path)-1]
+				} else if field, has := resolveField(table, e.name); has {
+					name = field
+				}
+			case *tCall:
+				name = strings.ToLower(e.name)
+			default:
+				name = "expr" + strconv.Itoa(i+1)
+			}
+		}
+		plan.outputs = append(plan.outputs, tOutput{name: name, expr: item.expr})
+	}
+
+	plan.groupBy = make([]tExpr, len(stmt.groupBy))
+	for i, expr := range stmt.groupBy {
+		plan.groupBy[i] = outputRef(plan.outputs, expr)
+	}
+	plan.orderBy = make([]tExpr, len(stmt.orderBy))
+	for i, item := range stmt.orderBy {
+		plan.orderBy[i] = outputRef(plan.outputs, item.expr)
+	}
+
+	exprs := append(slices.Clone(plan.groupBy), plan.orderBy...)
+	exprs = append(exprs, stmt.where)
+	for _, out := range plan.outputs {
+		exprs = append(exprs, out.expr)
+	}
+	if err := bindColumns(table, plan.columns, exprs...); err != nil {
+		return nil, err
+	}
+	for _, expr := range plan.groupBy {
+		if hasAggregate(expr) {
+			return nil, fmt.Errorf("aggregate functions are not allowed in GROUP BY")
+		}
+	}
+
+	plan.aggregated = len(plan.groupBy) > 0
+	for _, expr := range exprs {
+		plan.aggregated = plan.aggregated || hasAggregate(expr)
+	}
+
+	// 结果字段名,重名的列加序号后缀
+	seen := make(map[string]bool, len(plan.outputs))
+	for _, out := range plan.outputs {
+		name := out.name
+		for n := 2; seen[name]; n++ {
+			name = out.name + "_" + strconv.Itoa(n)
+		}
+		seen[name] = true
+		plan.fields = append(plan.fields, name)
+	}
+	return plan, nil
+}
+
+// execSelect 执行查询,结果为新建的数据集
+func execSelect(ctx context.Context, stmt *tSelectStmt, table *TDataSet, args []any) (*TDataSet, error) {
+	plan, err := planSelect(stmt, table)
+	if err != nil {
+		return nil, err
+	}
+	return plan.run(ctx, args)
+}
+
+func (self *tSelectPlan) run(ctx context.Context, args []any) (*TDataSet, error) {
+	env := &tEnv{ctx: ctx, args: args, binds: self.columns}
+
+	// 输入行
+	var records []*TRecordSet
+	if self.table != nil {
+		var err error
+		if records, err = matchRecords(self.table, self.stmt.where, env); err != nil {
+			return nil, err
+		}
+	} else {
+		records = []*TRecordSet{nil}
+		if self.stmt.where != nil {
+			ok, err := evalBool(self.stmt.where, env)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				records = nil
+			}
+		}
+	}
+
+	// 求值环境:每条记录一个,聚合时每个分组一个
+	var envs []*tEnv
+	if self.aggregated {
+		groups, err := groupRecords(records, self.groupBy, env)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			e := &tEnv{ctx: ctx, group: group, args: args, binds: self.columns}
+			if len(group) > 0 {
+				e.rec = group[0]
+			}
+			envs = append(envs, e)
+		}
+	} else {
+		for _, rec := range records {
+			envs = append(envs, &tEnv{ctx: ctx, rec: rec, args: args, binds: self.columns})
+		}
+	}
+
+	rows := make([]tResultRow, 0, len(envs))
+	for n, e := range envs {
+		if err := checkContext(ctx, n); err != nil {
+			return nil, err
+		}
+		row := tResultRow{values: make([]any, len(self.outputs)), keys: make([]any, len(self.orderBy))}
+		for i, out := range self.outputs {
+			v, err := out.expr.eval(e)
+			if err != nil {
+				return nil, err
+			}
+			row.values[i] = v
+		}
+		for i, expr := range self.orderBy {
+			v, err := expr.eval(e)
+			if err != nil {
+				return nil, err
+			}
+			row.keys[i] = v
+		}
+		rows = append(rows, row)
+	}
+
+	if len(self.orderBy) > 0 {
+		slices.SortStableFunc(rows, func(a, b tResultRow) int {
+			for i, item := range self.stmt.orderBy {
+				if c := compareValue(a.keys[i], b.keys[i]); c != 0 {
+					if item.desc {
+						return -c
+					}
+					return c
+				}
+			}
+			return 0
+		})
+	}
+
+	offset, err := evalCount(self.stmt.offset, args, "OFFSET")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := evalCount(self.stmt.limit, args, "LIMIT")
+	if err != nil {
+		return nil, err
+	}
+	rows = rows[min(offset, len(rows)):]
+	if self.stmt.limit != nil && limit < len(rows) {
+		rows = rows[:limit]
+	}
+
+	res := NewDataSet()
+	res.SetFields(self.fields...)
+	for _, row := range rows {
+		res.appendValues(row.values)
+	}
+	res.First()
+	return res, nil
+}
+
+// checkContext 每处理 1024 行检查一次 ctx 是否已取消
+func checkContext(ctx context.Context, i int) error {
+	if ctx == nil || i&1023 != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// outputRef ORDER BY/GROUP BY 中的列序号或结果列名替换为对应的结果列表达式
+func outputRef(outputs []tOutput, expr tExpr) tExpr {
+	switch e := expr.(type) {
+	case *tLiteral:
+		if n, ok := e.value.(int64); ok && n >= 1 && int(n) <= len(outputs) {
+			return outputs[n-1].expr
+		}
+	case *tColumnRef:
+		for _, out := range outputs {
+			if out.name == e.name {
+				return out.expr
+			}
+		}
+		for _, out := range outputs {
+			if strings.EqualFold(out.name, e.name) {
+				return out.expr
+			}
+		}
+	}
+	return expr
+}
+
+// groupRecords 按分组表达式的值分组,保持各组首次出现的顺序;无分组表达式时全部记录为一组
+func groupRecords(records []*TRecordSet, groupBy []tExpr, env *tEnv) ([][]*TRecordSet, error) {
+	if len(groupBy) == 0 {
+		return [][]*TRecordSet{append(make([]*TRecordSet, 0, len(records)), records...)}, nil
+	}
+
+	var (
+		groups [][]*TRecordSet
+		index  = make(map[string]int)
+		values = make([]any, len(groupBy))
+	)
+	for n, rec := range records {
+		if err := checkContext(env.ctx, n); err != nil {
+			return nil, err
+		}
+		env.rec = rec
+		for i, expr := range groupBy {
+			v, err := expr.eval(env)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+
+		key := groupKey(values)
+		if i, has := index[key]; has {
+			groups[i] = append(groups[i], rec)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, []*TRecordSet{rec})
+	}
+	env.rec = nil
+	return groups, nil
+}
+
+// evalCount LIMIT/OFFSET 的值,须为非负整数
+func evalCount(expr tExpr, args []any, clause string) (int, error) {
+	if expr == nil {
+		return 0, nil
+	}
+	v, err := expr.eval(&tEnv{args: args})
+	if err != nil {
+		return 0, err
+	}
+	n, ok := toInteger(v)
+	if !ok {
+		if f, isNum := toFloat(v); isNum && f == float64(int64(f)) {
+			n, ok = int64(f), true
+		}
+	}
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %v", clause, v)
+	}
+	return int(n), nil
+}
+
+// execInsert 插入行,列名未指定时按表的字段顺序;不存在的列同 LoadRows 自动新增字段
+func execInsert(stmt *tInsertStmt, table *TDataSet, args []any) (int64, error) {
+	columns := stmt.columns
+	if len(columns) == 0 {
+		columns = table.Fields()
+	}
+	fields := make([]string, len(columns))
+	for i, col := range columns {
+		fields[i] = col
+		if field, has := resolveField(table, col); has {
+			fields[i] = field
+		}
+	}
+
+	env := &tEnv{args: args}
+	rows := make([][]any, len(stmt.rows))
+	for i, exprs := range stmt.rows {
+		if len(exprs) != len(fields) {
+			return 0, fmt.Errorf("INSERT row %d has %d values for %d columns", i+1, len(exprs), len(fields))
+		}
+		if err := bindColumns(nil, nil, exprs...); err != nil {
+			return 0, err
+		}
+
+		rows[i] = make([]any, len(exprs))
+		for j, expr := range exprs {
+			v, err := expr.eval(env)
+			if err != nil {
+				return 0, err
+			}
+			rows[i][j] = v
+		}
+	}
+
+	before := len(table.Data)
+	err := table.LoadRows(fields, rows)
+	return int64(len(table.Data) - before), err
+}
+
+// execUpdate 更新满足条件的记录,各赋值表达式以更新前的值求值
+func execUpdate(stmt *tUpdateStmt, table *TDataSet, args []any) (int64, error) {
+	env := &tEnv{args: args, binds: make(map[*tColumnRef]tBinding)}
+	fields := make([]string, len(stmt.sets))
+	exprs := []tExpr{stmt.where}
+	for i, set := range stmt.sets {
+		field, has := resolveField(table, set.column)
+		if !has || table.IsCalcField(field) {
+			return 0, fmt.Errorf("unknown column < %s >", set.column)
+		}
+		fields[i] = field
+		exprs = append(exprs, set.expr)
+	}
+	if err := bindColumns(table, env.binds, exprs...); err != nil {
+		return 0, err
+	}
+	for _, set := range stmt.sets {
+		if hasAggregate(set.expr) {
+			return 0, fmt.Errorf("aggregate functions are not allowed in UPDATE")
+		}
+	}
+
+	records, err := matchRecords(table, stmt.where, env)
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([][]any, len(records))
+	for i, rec := range records {
+		env.rec = rec
+		values[i] = make([]any, len(stmt.sets))
+		for j, set := range stmt.sets {
+			if values[i][j], err = set.expr.eval(env); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	for i, rec := range records {
+		for j, field := range fields {
+			if !rec.SetByField(field, values[i][j]) {
+				return int64(i), fmt.Errorf("update < %s >: %w", field, ErrWriteRejected)
+			}
+		}
+	}
+	return int64(len(records)), nil
+}
+
+// execDelete 删除满足条件的记录
+func execDelete(stmt *tDeleteStmt, table *TDataSet, args []any) (int64, error) {
+	env := &tEnv{args: args, binds: make(map[*tColumnRef]tBinding)}
+	if err := bindColumns(table, env.binds, stmt.where); err != nil {
+		return 0, err
+	}
+	records, err := matchRecords(table, stmt.where, env)
+	if err != nil {
+		return 0, err
+	}
+
+	// 从后向前删除,前面记录的位置不受影响
+	matched := make(map[*TRecordSet]bool, len(records))
+	for _, rec := range records {
+		matched[rec] = true
+	}
+	var count int64
+	for pos := len(table.Data) - 1; pos >= 0; pos-- {
+		if pos >= len(table.Data) || !matched[table.Data[pos]] {
+			continue
+		}
+		if !table.Delete(pos) {
+			return count, fmt.Errorf("delete: %w", ErrWriteRejected)
+		}
+		count++
+	}
+	return count, nil
+}
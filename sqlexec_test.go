@@ -0,0 +1,128 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// selectSQL 执行查询并以 "a,b;a,b" 形式返回结果
+func selectSQL(t *testing.T, ds *TDataSet, query string, args ...any) string {
+	t.Helper()
+	stmt, _, err := parseSQL(query)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	sel := stmt.(*tSelectStmt)
+	if sel.table == "" {
+		ds = nil
+	}
+	res, err := execSelect(context.Background(), sel, ds, args)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+
+	rows := make([]string, 0, len(res.Data))
+	for _, rec := range res.Data {
+		cols := make([]string, len(res.Fields()))
+		for i := range cols {
+			cols[i] = fmt.Sprint(rec.GetByIndex(i))
+		}
+		rows = append(rows, strings.Join(cols, ","))
+	}
+	return strings.Join(rows, ";")
+}
+
+func TestSQLSelect(t *testing.T) {
+	ds := newFixture(sqlInvoiceFixture)
+
+	cases := []struct {
+		query string
+		args  []any
+		want  string
+	}{
+		{"SELECT id FROM invoice WHERE state = 'posted' AND amount > 150 ORDER BY id", nil, "3;4"},
+		{"SELECT id, amount FROM invoice ORDER BY amount DESC LIMIT 2", nil, "3,300;4,200"},
+		{"SELECT id FROM invoice ORDER BY id LIMIT ? OFFSET ?", []any{2, 1}, "2;3"},
+		{"SELECT ID FROM invoice WHERE Amount IS NULL", nil, "5"},
+		{"SELECT partner_id, sum(amount) FROM invoice WHERE state = 'posted' GROUP BY partner_id ORDER BY 2 DESC", nil, "10,400;30,200;20,<nil>"},
+		{"SELECT state, COUNT(*) AS n, COUNT(amount), MIN(amount), MAX(amount) FROM invoice GROUP BY state ORDER BY n", nil, "draft,1,1,50,50;posted,4,3,100,300"},
+		{"SELECT COUNT(*), SUM(amount), AVG(amount) FROM invoice WHERE id > 100", nil, "0,<nil>,<nil>"},
+		{"SELECT COUNT(*) FROM invoice", nil, "5"},
+		{"SELECT id * 10 AS x FROM invoice WHERE id < 3 ORDER BY x DESC", nil, "20;10"},
+		{"SELECT 1 + 1", nil, "2"},
+		{"SELECT i.id, upper(state) FROM invoice i WHERE invoice.id = 1", nil, "1,POSTED"},
+	}
+	for _, c := range cases {
+		if got := selectSQL(t, ds, c.query, c.args...); got != c.want {
+			t.Fatalf("%s: expected %q, got %q", c.query, c.want, got)
+		}
+	}
+
+	stmt, _, _ := parseSQL("SELECT sum(amount), sum(amount) AS s FROM invoice")
+	res, _ := execSelect(context.Background(), stmt.(*tSelectStmt), ds, nil)
+	if fmt.Sprint(res.Fields()) != "[sum s]" {
+		t.Fatalf("unexpected result fields %v", res.Fields())
+	}
+	if v, ok := res.Data[0].GetByIndex(0).(int64); !ok || v != 650 {
+		t.Fatalf("SUM of integers should be int64, got %#v", res.Data[0].GetByIndex(0))
+	}
+
+	for _, query := range []string{
+		"SELECT nope FROM invoice",
+		"SELECT id FROM invoice WHERE COUNT(*) > 1",
+		"SELECT id FROM invoice LIMIT -1",
+	} {
+		stmt, _, err := parseSQL(query)
+		if err == nil {
+			_, err = execSelect(context.Background(), stmt.(*tSelectStmt), ds, nil)
+		}
+		if err == nil {
+			t.Fatalf("%s: expected an error", query)
+		}
+	}
+}
+
+func TestSQLWrite(t *testing.T) {
+	ds := newFixture(sqlInvoiceFixture)
+	exec := func(query string, args ...any) (int64, error) {
+		stmt, _, err := parseSQL(query)
+		if err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		switch s := stmt.(type) {
+		case *tInsertStmt:
+			return execInsert(s, ds, args)
+		case *tUpdateStmt:
+			return execUpdate(s, ds, args)
+		default:
+			return execDelete(s.(*tDeleteStmt), ds, args)
+		}
+	}
+
+	if n, err := exec("INSERT INTO invoice (id, state, amount, memo) VALUES (6, 'draft', ?, 'new'), (7, 'draft', 1, NULL)", 70); err != nil || n != 2 {
+		t.Fatalf("insert: n=%d err=%v", n, err)
+	}
+	if !ds.HasField("memo") || ds.Count() != 7 {
+		t.Fatal("insert should append rows and add unknown columns")
+	}
+
+	// 赋值以更新前的值求值
+	if n, err := exec("UPDATE invoice SET amount = amount + id, id = id * 100 WHERE state = 'draft'"); err != nil || n != 3 {
+		t.Fatalf("update: n=%d err=%v", n, err)
+	}
+	if got := selectSQL(t, ds, "SELECT id, amount FROM invoice WHERE state = 'draft' ORDER BY id"); got != "200,52;600,76;700,8" {
+		t.Fatalf("unexpected rows after update %q", got)
+	}
+	if _, err := exec("UPDATE invoice SET nope = 1"); err == nil {
+		t.Fatal("updating an unknown column should fail")
+	}
+
+	if n, err := exec("DELETE FROM invoice WHERE amount < 100 OR amount IS NULL"); err != nil || n != 4 {
+		t.Fatalf("delete: n=%d err=%v", n, err)
+	}
+	if got := selectSQL(t, ds, "SELECT id FROM invoice"); got != "1;3;4" {
+		t.Fatalf("unexpected rows after delete %q", got)
+	}
+}
@@ -0,0 +1,875 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/volts-dev/utils"
+)
+
+type (
+	// tExpr 表达式节点。NULL 以 nil 表示,逻辑运算按 SQL 三值逻辑(nil 为 UNKNOWN)。
+	tExpr interface {
+		eval(env *tEnv) (any, error)
+	}
+
+	// tEnv 表达式求值环境
+	tEnv struct {
+		ctx   context.Context          // 取消信号,可为 nil
+		rec   *TRecordSet              // 当前记录,无记录时字段值为 nil
+		group []*TRecordSet            // 聚合时当前分组的全部记录
+		args  []any                    // 占位符参数
+		binds map[*tColumnRef]tBinding // 列引用绑定的字段,未绑定的列按名称查找
+	}
+
+	// tBinding 列引用解析后的字段名与取值路径
+	tBinding struct {
+		field string
+		path  []string
+	}
+
+	tLiteral struct {
+		value any
+	}
+
+	tParam struct {
+		index int
+		pos   int
+	}
+
+	// tColumnRef 列引用,path 为值中的取值路径:partner_id.country 取 partner_id 字段值
+	// (map、记录或结构体)中的 country。SQL 中列名不存在时首段视为表名前缀(t.col)。
+	tColumnRef struct {
+		name string
+		path []string
+		pos  int
+	}
+
+	tUnary struct {
+		op  string // - 或 NOT
+		x   tExpr
+		pos int
+	}
+
+	tBinary struct {
+		op          string // OR AND = != < <= > >= + - * / % ||
+		left, right tExpr
+		pos         int
+	}
+
+	tIsNull struct {
+		x   tExpr
+		not bool
+	}
+
+	tIn struct {
+		x    tExpr
+		list []tExpr
+		not  bool
+	}
+
+	tBetween struct {
+		x         tExpr
+		low, high tExpr
+		not       bool
+	}
+
+	tLike struct {
+		x, pattern tExpr
+		not        bool
+	}
+
+	tCall struct {
+		name string // 大写函数名
+		args []tExpr
+		star bool // COUNT(*)
+		pos  int
+	}
+
+	// tFunc 标量函数
+	tFunc struct {
+		minArgs, maxArgs int  // maxArgs < 0 表示不限
+		nulls            bool // 参数可为 NULL
+		fn               func(args []any) (any, error)
+	}
+)
+
+// scalarFuncs 标量函数(函数名不区分大小写),除 nulls 为 true 的函数外参数含 NULL 时结果为 NULL。
+// 日期函数的参数可为 time.Time 或可解析的日期字符串(2006-01-02、2006-01-02 15:04:05、RFC3339)。
+var scalarFuncs = map[string]tFunc{
+	// 字符串
+	"LOWER":       {minArgs: 1, maxArgs: 1, fn: func(args []any) (any, error) { return strings.ToLower(utils.ToString(args[0])), nil }},
+	"UPPER":       {minArgs: 1, maxArgs: 1, fn: func(args []any) (any, error) { return strings.ToUpper(utils.ToString(args[0])), nil }},
+	"TRIM":        {minArgs: 1, maxArgs: 1, fn: func(args []any) (any, error) { return strings.TrimSpace(utils.ToString(args[0])), nil }},
+	"LENGTH":      {minArgs: 1, maxArgs: 1, fn: funcLength},
+	"LEN":         {minArgs: 1, maxArgs: 1, fn: funcLength},
+	"SUBSTR":      {minArgs: 2, maxArgs: 3, fn: funcSubstr},
+	"REPLACE":     {minArgs: 3, maxArgs: 3, fn: funcReplace},
+	"CONTAINS":    {minArgs: 2, maxArgs: 2, fn: stringTest(strings.Contains)},
+	"STARTS_WITH": {minArgs: 2, maxArgs: 2, fn: stringTest(strings.HasPrefix)},
+	"ENDS_WITH":   {minArgs: 2, maxArgs: 2, fn: stringTest(strings.HasSuffix)},
+	"CONCAT":      {minArgs: 1, maxArgs: -1, nulls: true, fn: funcConcat},
+	"COALESCE":    {minArgs: 1, maxArgs: -1, nulls: true, fn: funcCoalesce},
+
+	// 数值
+	"ABS":   {minArgs: 1, maxArgs: 1, fn: funcAbs},
+	"ROUND": {minArgs: 1, maxArgs: 2, fn: funcRound},
+	"FLOOR": {minArgs: 1, maxArgs: 1, fn: floatFunc(math.Floor)},
+	"CEIL":  {minArgs: 1, maxArgs: 1, fn: floatFunc(math.Ceil)},
+
+	// 日期
+	"NOW":          {fn: func([]any) (any, error) { return time.Now(), nil }},
+	"TODAY":        {fn: func([]any) (any, error) { return truncateDay(time.Now()), nil }},
+	"DATE":         {minArgs: 1, maxArgs: 1, fn: timeFunc(func(t time.Time) any { return truncateDay(t) })},
+	"YEAR":         {minArgs: 1, maxArgs: 1, fn: timeFunc(func(t time.Time) any { return int64(t.Year()) })},
+	"MONTH":        {minArgs: 1, maxArgs: 1, fn: timeFunc(func(t time.Time) any { return int64(t.Month()) })},
+	"DAY":          {minArgs: 1, maxArgs: 1, fn: timeFunc(func(t time.Time) any { return int64(t.Day()) })},
+	"HOUR":         {minArgs: 1, maxArgs: 1, fn: timeFunc(func(t time.Time) any { return int64(t.Hour()) })},
+	"MINUTE":       {minArgs: 1, maxArgs: 1, fn: timeFunc(func(t time.Time) any { return int64(t.Minute()) })},
+	"WEEKDAY":      {minArgs: 1, maxArgs: 1, fn: timeFunc(func(t time.Time) any { return int64(t.Weekday()) })}, // 0 为星期日
+	"ADD_DAYS":     {minArgs: 2, maxArgs: 2, fn: funcAddDays},
+	"ADD_MONTHS":   {minArgs: 2, maxArgs: 2, fn: funcAddMonths},
+	"DAYS_BETWEEN": {minArgs: 2, maxArgs: 2, fn: funcDaysBetween},
+	"FORMAT_DATE":  {minArgs: 2, maxArgs: 2, fn: funcFormatDate}, // Go 布局,如 FORMAT_DATE(d, "2006-01")
+}
+
+// isAggregate 是否为聚合函数
+func isAggregate(name string) bool {
+	switch name {
+	case "COUNT", "SUM", "MIN", "MAX", "AVG":
+		return true
+	}
+	return false
+}
+
+// checkCall 检查函数名与参数个数
+func checkCall(call *tCall) error {
+	if isAggregate(call.name) {
+		if call.star && call.name != "COUNT" {
+			return &TSyntaxError{Pos: call.pos, Msg: fmt.Sprintf("%s(*) is not supported", call.name)}
+		}
+		if !call.star && len(call.args) != 1 {
+			return &TSyntaxError{Pos: call.pos, Msg: fmt.Sprintf("%s takes exactly one argument", call.name)}
+		}
+		return nil
+	}
+
+	fn, has := scalarFuncs[call.name]
+	if !has {
+		return &TSyntaxError{Pos: call.pos, Msg: fmt.Sprintf("unknown function %s", call.name)}
+	}
+	if call.star || len(call.args) < fn.minArgs || (fn.maxArgs >= 0 && len(call.args) > fn.maxArgs) {
+		return &TSyntaxError{Pos: call.pos, Msg: fmt.Sprintf("wrong number of arguments to %s", call.name)}
+	}
+	return nil
+}
+
+// walkExpr 先序遍历表达式树,fn 返回错误时停止
+func walkExpr(expr tExpr, fn func(tExpr) error) error {
+	if expr == nil {
+		return nil
+	}
+	if err := fn(expr); err != nil {
+		return err
+	}
+
+	var children []tExpr
+	switch e := expr.(type) {
+	case *tUnary:
+		children = []tExpr{e.x}
+	case *tBinary:
+		children = []tExpr{e.left, e.right}
+	case *tIsNull:
+		children = []tExpr{e.x}
+	case *tIn:
+		children = append([]tExpr{e.x}, e.list...)
+	case *tBetween:
+		children = []tExpr{e.x, e.low, e.high}
+	case *tLike:
+		children = []tExpr{e.x, e.pattern}
+	case *tCall:
+		children = e.args
+	}
+	for _, child := range children {
+		if err := walkExpr(child, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasAggregate 表达式中是否含有聚合函数
+func hasAggregate(expr tExpr) bool {
+	found := false
+	walkExpr(expr, func(e tExpr) error {
+		if call, ok := e.(*tCall); ok && isAggregate(call.name) {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
+// evalBool 求值为条件,NULL 与 false 均不成立
+func evalBool(expr tExpr, env *tEnv) (bool, error) {
+	v, err := expr.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, known := truth(v)
+	return known && b, nil
+}
+
+// truth 值的真假,nil 为 UNKNOWN;数值非零为真,字符串按 strconv.ParseBool
+func truth(v any) (b bool, known bool) {
+	switch x := v.(type) {
+	case nil:
+		return false, false
+	case bool:
+		return x, true
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(x))
+		return b, true
+	case TDecimal:
+		return !x.IsZero(), true
+	}
+	if n, ok := toNumber(v); ok {
+		return n != 0, true
+	}
+	return true, true
+}
+
+func (self *tLiteral) eval(*tEnv) (any, error) {
+	return self.value, nil
+}
+
+func (self *tParam) eval(env *tEnv) (any, error) {
+	if self.index >= len(env.args) {
+		return nil, fmt.Errorf("missing value for parameter %d at position %d", self.index+1, self.pos)
+	}
+	return env.args[self.index], nil
+}
+
+func (self *tColumnRef) eval(env *tEnv) (any, error) {
+	if env.rec == nil {
+		return nil, nil
+	}
+
+	bind, has := env.binds[self]
+	if !has {
+		bind = tBinding{field: self.name, path: self.path}
+		if ds := env.rec.latest().dataset; ds != nil && !ds.HasField(self.name) {
+			if field, ok := resolveField(ds, self.name); ok {
+				bind.field = field
+			}
+		}
+	}
+
+	v := env.rec.GetByField(bind.field)
+	for _, key := range bind.path {
+		if v = member(v, key); v == nil {
+			break
+		}
+	}
+	return v, nil
+}
+
+// member 取 map 中的键或记录、结构体中的字段,不存在时为 nil
+func member(v any, key string) any {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		return x[key]
+	case map[string]string:
+		if s, has := x[key]; has {
+			return s
+		}
+		return nil
+	case *TRecordSet:
+		return x.GetByField(key)
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		if item := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key())); item.IsValid() && item.CanInterface() {
+			return item.Interface()
+		}
+	case reflect.Struct:
+		f := rv.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, key) })
+		if f.IsValid() && f.CanInterface() {
+			return f.Interface()
+		}
+	}
+	return nil
+}
+
+func (self *tUnary) eval(env *tEnv) (any, error) {
+	v, err := self.x.eval(env)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	if self.op == "NOT" {
+		b, _ := truth(v)
+		return !b, nil
+	}
+	if d, ok := v.(TDecimal); ok {
+		return d.Neg(), nil
+	}
+	if n, ok := toInteger(v); ok {
+		return -n, nil
+	}
+	if f, ok := toFloat(v); ok {
+		return -f, nil
+	}
+	return nil, fmt.Errorf("cannot negate %v at position %d", v, self.pos)
+}
+
+func (self *tBinary) eval(env *tEnv) (any, error) {
+	left, err := self.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// AND/OR 短路:FALSE AND x 为 FALSE,TRUE OR x 为 TRUE
+	switch self.op {
+	case "AND", "OR":
+		l, lknown := truth(left)
+		if lknown && l == (self.op == "OR") {
+			return l, nil
+		}
+		right, err := self.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, rknown := truth(right)
+		switch {
+		case rknown && r == (self.op == "OR"):
+			return r, nil
+		case !lknown || !rknown:
+			return nil, nil
+		}
+		return r, nil
+	}
+
+	right, err := self.right.eval(env)
+	if err != nil || left == nil || right == nil {
+		return nil, err
+	}
+
+	switch self.op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		c := sqlCompare(left, right)
+		switch self.op {
+		case "=":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	case "||":
+		return utils.ToString(left) + utils.ToString(right), nil
+	}
+
+	res, err := arith(self.op, left, right)
+	if err != nil {
+		return nil, fmt.Errorf("%w at position %d", err, self.pos)
+	}
+	return res, nil
+}
+
+func (self *tIsNull) eval(env *tEnv) (any, error) {
+	v, err := self.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != self.not, nil
+}
+
+func (self *tIn) eval(env *tEnv) (any, error) {
+	v, err := self.x.eval(env)
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	unknown := false
+	for _, item := range self.list {
+		x, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if x == nil {
+			unknown = true
+			continue
+		}
+		if sqlCompare(v, x) == 0 {
+			return !self.not, nil
+		}
+	}
+	if unknown {
+		return nil, nil
+	}
+	return self.not, nil
+}
+
+func (self *tBetween) eval(env *tEnv) (any, error) {
+	v, err := self.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	low, err := self.low.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	high, err := self.high.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || low == nil || high == nil {
+		return nil, nil
+	}
+	in := sqlCompare(v, low) >= 0 && sqlCompare(v, high) <= 0
+	return in != self.not, nil
+}
+
+func (self *tLike) eval(env *tEnv) (any, error) {
+	v, err := self.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := self.pattern.eval(env)
+	if err != nil || v == nil || pattern == nil {
+		return nil, err
+	}
+	return likeMatch([]rune(utils.ToString(v)), []rune(utils.ToString(pattern))) != self.not, nil
+}
+
+func (self *tCall) eval(env *tEnv) (any, error) {
+	if isAggregate(self.name) {
+		return self.aggregate(env)
+	}
+
+	fn := scalarFuncs[self.name]
+	args := make([]any, len(self.args))
+	for i, arg := range self.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil && !fn.nulls {
+			return nil, nil
+		}
+		args[i] = v
+	}
+
+	res, err := fn.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s at position %d: %w", self.name, self.pos, err)
+	}
+	return res, nil
+}
+
+// aggregate 在当前分组上计算聚合函数,NULL 值不参与计算
+func (self *tCall) aggregate(env *tEnv) (any, error) {
+	if env.group == nil {
+		return nil, fmt.Errorf("aggregate function %s is not allowed here (position %d)", self.name, self.pos)
+	}
+	if self.star {
+		return int64(len(env.group)), nil
+	}
+
+	values := make([]any, 0, len(env.group))
+	sub := &tEnv{args: env.args, binds: env.binds}
+	for _, rec := range env.group {
+		sub.rec = rec
+		v, err := self.args[0].eval(sub)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			values = append(values, v)
+		}
+	}
+
+	switch self.name {
+	case "COUNT":
+		return int64(len(values)), nil
+	case "SUM":
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return sqlSum(values), nil
+	case "AVG":
+		if len(values) == 0 {
+			return nil, nil
+		}
+		if hasDecimal(values) {
+			avg, _ := avgDecimalOf(values)
+			return avg, nil
+		}
+		avg, _ := avgOf(values)
+		return avg, nil
+	default: // MIN/MAX 保持原值类型
+		var res any
+		for _, v := range values {
+			c := sqlCompare(v, res)
+			if res == nil || (self.name == "MIN" && c < 0) || (self.name == "MAX" && c > 0) {
+				res = v
+			}
+		}
+		return res, nil
+	}
+}
+
+// sqlSum 整数求和为 int64,含 TDecimal 时为 TDecimal,否则为 float64
+func sqlSum(values []any) any {
+	if hasDecimal(values) {
+		return sumDecimalOf(values)
+	}
+
+	var sum int64
+	for _, v := range values {
+		n, ok := toInteger(v)
+		if !ok {
+			return sumOf(values)
+		}
+		sum += n
+	}
+	return sum
+}
+
+// sqlCompare 比较两个非空值,时间与字符串比较时按时间解析字符串
+func sqlCompare(a, b any) int {
+	switch x := a.(type) {
+	case time.Time:
+		if s, ok := b.(string); ok {
+			if t, ok := parseTime(s); ok {
+				return x.Compare(t)
+			}
+		}
+	case string:
+		if t, ok := b.(time.Time); ok {
+			if s, ok := parseTime(x); ok {
+				return s.Compare(t)
+			}
+		}
+	}
+	return compareValue(a, b)
+}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// arith 算术运算:整数之间为 int64(/ 为整除),含 TDecimal 时为精确的 TDecimal,其余数值为 float64;
+// 两个字符串相加为连接
+func arith(op string, a, b any) (any, error) {
+	if x, ok := a.(string); ok && op == "+" {
+		if y, ok := b.(string); ok {
+			return x + y, nil
+		}
+	}
+
+	_, ad := a.(TDecimal)
+	_, bd := b.(TDecimal)
+	if ad || bd {
+		x, err := NewDecimal(a)
+		if err != nil {
+			return nil, fmt.Errorf("cannot apply %s to %v", op, a)
+		}
+		y, err := NewDecimal(b)
+		if err != nil {
+			return nil, fmt.Errorf("cannot apply %s to %v", op, b)
+		}
+		switch op {
+		case "+":
+			return x.Add(y), nil
+		case "-":
+			return x.Sub(y), nil
+		case "*":
+			return x.Mul(y), nil
+		case "/":
+			if y.IsZero() {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return x.Div(y), nil
+		}
+		return nil, fmt.Errorf("cannot apply %s to decimals", op)
+	}
+
+	if x, ok := toInteger(a); ok {
+		if y, ok := toInteger(b); ok {
+			switch op {
+			case "+":
+				return x + y, nil
+			case "-":
+				return x - y, nil
+			case "*":
+				return x * y, nil
+			}
+			if y == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			if op == "/" {
+				return x / y, nil
+			}
+			return x % y, nil
+		}
+	}
+
+	x, ok := toNumber(a)
+	if !ok {
+		return nil, fmt.Errorf("cannot apply %s to %v", op, a)
+	}
+	y, ok := toNumber(b)
+	if !ok {
+		return nil, fmt.Errorf("cannot apply %s to %v", op, b)
+	}
+	switch op {
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return x / y, nil
+	}
+	return nil, fmt.Errorf("cannot apply %s to floats", op)
+}
+
+// likeMatch LIKE 匹配,% 匹配任意个字符,_ 匹配一个字符,区分大小写
+func likeMatch(s, p []rune) bool {
+	// 贪婪匹配,遇到不符时回溯到最近的 %
+	si, pi := 0, 0
+	star, mark := -1, 0
+	for si < len(s) {
+		switch {
+		case pi < len(p) && (p[pi] == '_' || p[pi] == s[si]):
+			si++
+			pi++
+		case pi < len(p) && p[pi] == '%':
+			star, mark = pi, si
+			pi++
+		case star >= 0:
+			mark++
+			si, pi = mark, star+1
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '%' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+func funcLength(args []any) (any, error) {
+	return int64(utf8.RuneCountInString(utils.ToString(args[0]))), nil
+}
+
+// funcSubstr SUBSTR(s, start[, length]),start 从 1 开始,按字符计
+func funcSubstr(args []any) (any, error) {
+	s := []rune(utils.ToString(args[0]))
+	start, ok := toInteger(args[1])
+	if !ok {
+		return nil, fmt.Errorf("start must be an integer")
+	}
+	from := min(max(int(start)-1, 0), len(s))
+	to := len(s)
+	if len(args) > 2 {
+		n, ok := toInteger(args[2])
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("length must be a non-negative integer")
+		}
+		to = min(from+int(n), len(s))
+	}
+	return string(s[from:to]), nil
+}
+
+func funcReplace(args []any) (any, error) {
+	return strings.ReplaceAll(utils.ToString(args[0]), utils.ToString(args[1]), utils.ToString(args[2])), nil
+}
+
+func stringTest(test func(s, sub string) bool) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		return test(utils.ToString(args[0]), utils.ToString(args[1])), nil
+	}
+}
+
+// funcConcat 连接字符串,忽略 NULL
+func funcConcat(args []any) (any, error) {
+	var sb strings.Builder
+	for _, v := range args {
+		if v != nil {
+			sb.WriteString(utils.ToString(v))
+		}
+	}
+	return sb.String(), nil
+}
+
+// funcCoalesce 第一个非 NULL 参数
+func funcCoalesce(args []any) (any, error) {
+	for _, v := range args {
+		if v != nil {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+func funcAbs(args []any) (any, error) {
+	if compareValue(args[0], int64(0)) < 0 {
+		return arith("*", args[0], int64(-1))
+	}
+	return args[0], nil
+}
+
+// funcRound ROUND(x[, n]) 四舍五入到 n 位小数,TDecimal 保持精确,整数不变
+func funcRound(args []any) (any, error) {
+	var places int64
+	if len(args) > 1 {
+		n, ok := toInteger(args[1])
+		if !ok {
+			return nil, fmt.Errorf("decimal places must be an integer")
+		}
+		places = n
+	}
+
+	switch x := args[0].(type) {
+	case TDecimal:
+		return x.Round(int32(places)), nil
+	}
+	if n, ok := toInteger(args[0]); ok {
+		return n, nil
+	}
+	f, ok := toFloat(args[0])
+	if !ok {
+		return nil, fmt.Errorf("cannot round %v", args[0])
+	}
+	scale := math.Pow(10, float64(places))
+	return math.Round(f*scale) / scale, nil
+}
+
+func floatFunc(fn func(float64) float64) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		if n, ok := toInteger(args[0]); ok {
+			return n, nil
+		}
+		f, ok := toFloat(args[0])
+		if !ok {
+			return nil, fmt.Errorf("%v is not a number", args[0])
+		}
+		return fn(f), nil
+	}
+}
+
+// toTime 将 time.Time 或日期字符串转为时间
+func toTime(v any) (time.Time, error) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, nil
+	case *time.Time:
+		if x != nil {
+			return *x, nil
+		}
+	case string:
+		if t, ok := parseTime(x); ok {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%v is not a date", v)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func timeFunc(fn func(time.Time) any) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		t, err := toTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return fn(t), nil
+	}
+}
+
+func funcAddDays(args []any) (any, error) {
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, ok := toInteger(args[1])
+	if !ok {
+		return nil, fmt.Errorf("days must be an integer")
+	}
+	return t.AddDate(0, 0, int(n)), nil
+}
+
+func funcAddMonths(args []any) (any, error) {
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, ok := toInteger(args[1])
+	if !ok {
+		return nil, fmt.Errorf("months must be an integer")
+	}
+	return t.AddDate(0, int(n), 0), nil
+}
+
+// funcDaysBetween DAYS_BETWEEN(a, b) 为 b 与 a 相差的整天数(按日期计,不足一天不计)
+func funcDaysBetween(args []any) (any, error) {
+	a, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := toTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int64(db.Sub(da).Hours() / 24), nil
+}
+
+func funcFormatDate(args []any) (any, error) {
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return t.Format(utils.ToString(args[1])), nil
+}
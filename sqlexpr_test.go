@@ -0,0 +1,97 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+)
+
+// evalSQL 解析并对 rec 求值 SELECT 的第一个表达式
+func evalSQL(t *testing.T, expr string, rec *TRecordSet, args ...any) any {
+	t.Helper()
+	stmt, _, err := parseSQL("SELECT " + expr)
+	if err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+	v, err := stmt.(*tSelectStmt).items[0].expr.eval(&tEnv{rec: rec, args: args})
+	if err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+	return v
+}
+
+func TestSQLExprEval(t *testing.T) {
+	rec := NewRecordSet(map[string]any{"qty": 3, "price": 2.5, "name": "Widget", "note": nil,
+		"amount": MustDecimal("10.10"), "at": time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)})
+	defer rec.Free()
+
+	cases := []struct {
+		expr string
+		want any
+	}{
+		{"qty * 2 + 1", int64(7)},
+		{"7 / 2", int64(3)},
+		{"7 % 4", int64(3)},
+		{"qty * price", 7.5},
+		{"-qty", int64(-3)},
+		{"amount + 1", MustDecimal("11.10")},
+		{"name || '!'", "Widget!"},
+		{"qty BETWEEN 1 AND 3", true},
+		{"qty NOT IN (1, 2)", true},
+		{"name LIKE 'W_dg%'", true},
+		{"name LIKE 'w%'", false},
+		{"note IS NULL AND name IS NOT NULL", true},
+		{"at > '2026-02-28'", true},
+		{"LOWER(name) = 'widget' AND LENGTH(name) = 6", true},
+		{"COALESCE(note, name)", "Widget"},
+		{"ABS(-2)", int64(2)},
+		{"qty = ?", true},
+		// 三值逻辑
+		{"note = 1", nil},
+		{"note = 1 OR qty = 3", true},
+		{"note = 1 AND qty = 3", nil},
+		{"note = 1 AND qty = 4", false},
+		{"NOT (note = 1)", nil},
+		{"qty IN (1, NULL)", nil},
+		{"note + 1", nil},
+	}
+	for _, c := range cases {
+		got := evalSQL(t, c.expr, rec, int64(3))
+		if d, ok := c.want.(TDecimal); ok {
+			if g, ok := got.(TDecimal); !ok || !g.Equal(d) {
+				t.Fatalf("%s: expected %v, got %v", c.expr, c.want, got)
+			}
+			continue
+		}
+		if got != c.want {
+			t.Fatalf("%s: expected %#v, got %#v", c.expr, c.want, got)
+		}
+	}
+
+	stmt, _, _ := parseSQL("SELECT qty / 0")
+	if _, err := stmt.(*tSelectStmt).items[0].expr.eval(&tEnv{rec: rec}); err == nil {
+		t.Fatal("division by zero should fail")
+	}
+}
+
+func TestSQLLike(t *testing.T) {
+	cases := []struct {
+		s, p string
+		want bool
+	}{
+		{"abc", "abc", true},
+		{"abc", "a%", true},
+		{"abc", "%c", true},
+		{"abc", "%b%", true},
+		{"abc", "a_c", true},
+		{"abc", "a_", false},
+		{"", "%", true},
+		{"aXbXc", "a%b%c", true},
+		{"aXbX", "a%b%c", false},
+		{"中文字", "中_字", true},
+	}
+	for _, c := range cases {
+		if got := likeMatch([]rune(c.s), []rune(c.p)); got != c.want {
+			t.Fatalf("%q LIKE %q: expected %v", c.s, c.p, c.want)
+		}
+	}
+}
@@ -0,0 +1,900 @@
+package dataset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type (
+	tTokenKind uint8
+
+	tToken struct {
+		kind tTokenKind
+		text string // 标识符/关键字原文,字符串与带引号标识符为去除引号后的内容
+		pos  int    // 在语句中的字节位置,用于错误信息
+	}
+
+	// TSyntaxError SQL/表达式语法错误,Pos 为出错处在源文本中的字节位置(从 0 开始),
+	// Near 为该处起的一小段源文本
+	TSyntaxError struct {
+		Pos  int
+		Near string
+		Msg  string
+	}
+
+	tParser struct {
+		src    string
+		tokens []tToken
+		pos    int
+		params int  // ? 占位符个数或 $n 中最大的 n
+		expr   bool // 表达式模式,见 Compile
+	}
+
+	// SQL 语句
+	tSelectItem struct {
+		expr  tExpr
+		alias string
+		star  bool // *
+	}
+
+	tOrderItem struct {
+		expr tExpr
+		desc bool
+	}
+
+	tSelectStmt struct {
+		items   []tSelectItem
+		table   string
+		where   tExpr
+		groupBy []tExpr
+		orderBy []tOrderItem
+		limit   tExpr
+		offset  tExpr
+	}
+
+	tInsertStmt struct {
+		table   string
+		columns []string
+		rows    [][]tExpr
+	}
+
+	tAssign struct {
+		column string
+		expr   tExpr
+	}
+
+	tUpdateStmt struct {
+		table string
+		sets  []tAssign
+		where tExpr
+	}
+
+	tDeleteStmt struct {
+		table string
+		where tExpr
+	}
+)
+
+const (
+	tokEOF tTokenKind = iota
+	tokIdent
+	tokQuoted // "ident" 或 `ident`
+	tokString
+	tokNumber
+	tokParam
+	tokOp
+)
+
+func (self *TSyntaxError) Error() string {
+	if self.Near == "" {
+		return fmt.Sprintf("syntax error at position %d: %s", self.Pos, self.Msg)
+	}
+	return fmt.Sprintf("syntax error at position %d near %q: %s", self.Pos, self.Near, self.Msg)
+}
+
+// withNear 为语法错误补充出错处的源文本
+func withNear(err error, src string) error {
+	syntax, ok := err.(*TSyntaxError)
+	if !ok || syntax.Near != "" || syntax.Pos >= len(src) {
+		return err
+	}
+
+	near, n := src[syntax.Pos:], 0
+	for i := range near {
+		if n == 16 {
+			near = near[:i] + "..."
+			break
+		}
+		n++
+	}
+	syntax.Near = near
+	return syntax
+}
+
+// tokenize 词法分析,字符串使用单引号(连续两个单引号表示单引号本身),标识符可用双引号或反引号引用,
+// 占位符为 ? 或 $n,-- 开始的行注释被忽略。表达式模式下双引号也用于字符串。
+func tokenize(src string, expr bool) ([]tToken, error) {
+	var tokens []tToken
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case strings.HasPrefix(src[i:], "--"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(src) {
+				r, size = utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, tToken{kind: tokIdent, text: src[start:i], pos: start})
+
+		case r >= '0' && r <= '9' || (r == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9'):
+			i = scanNumber(src, i)
+			tokens = append(tokens, tToken{kind: tokNumber, text: src[start:i], pos: start})
+
+		case r == '\'' || r == '"' || r == '`':
+			text, end, ok := scanQuoted(src, i, src[i])
+			if !ok {
+				return nil, &TSyntaxError{Pos: start, Msg: "unterminated quoted text"}
+			}
+			kind := tokQuoted
+			if r == '\'' || (r == '"' && expr) {
+				kind = tokString
+			}
+			tokens = append(tokens, tToken{kind: kind, text: text, pos: start})
+			i = end
+
+		case r == '?':
+			tokens = append(tokens, tToken{kind: tokParam, text: "?", pos: start})
+			i++
+
+		case r == '$':
+			i++
+			for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+				i++
+			}
+			if i == start+1 {
+				return nil, &TSyntaxError{Pos: start, Msg: "expected parameter number after $"}
+			}
+			tokens = append(tokens, tToken{kind: tokParam, text: src[start:i], pos: start})
+
+		default:
+			op := ""
+			for _, candidate := range []string{"<=", ">=", "<>", "!=", "==", "||", "&&", "=", "<", ">", "!", "(", ")", ",", "*", "+", "-", "/", "%", ".", ";"} {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &TSyntaxError{Pos: start, Msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, tToken{kind: tokOp, text: op, pos: start})
+			i += len(op)
+		}
+	}
+	return append(tokens, tToken{kind: tokEOF, pos: len(src)}), nil
+}
+
+func scanNumber(src string, i int) int {
+	digits := func() {
+		for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+			i++
+		}
+	}
+	digits()
+	if i < len(src) && src[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
+
+// scanQuoted 读取以 q 包围的文本,连续两个 q 表示 q 本身
+func scanQuoted(src string, i int, q byte) (string, int, bool) {
+	var sb strings.Builder
+	for i++; i < len(src); i++ {
+		if src[i] != q {
+			sb.WriteByte(src[i])
+			continue
+		}
+		if i+1 < len(src) && src[i+1] == q {
+			sb.WriteByte(q)
+			i++
+			continue
+		}
+		return sb.String(), i + 1, true
+	}
+	return "", i, false
+}
+
+func newParser(src string, expr bool) (*tParser, error) {
+	tokens, err := tokenize(src, expr)
+	if err != nil {
+		return nil, err
+	}
+	return &tParser{src: src, tokens: tokens, expr: expr}, nil
+}
+
+// parseSQL 解析一条 SELECT/INSERT/UPDATE/DELETE 语句,返回语句与占位符个数
+func parseSQL(src string) (stmt any, params int, err error) {
+	defer func() {
+		err = withNear(err, src)
+	}()
+
+	p, err := newParser(src, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case p.isKeyword("SELECT"):
+		stmt, err = p.parseSelect()
+	case p.isKeyword("INSERT"):
+		stmt, err = p.parseInsert()
+	case p.isKeyword("UPDATE"):
+		stmt, err = p.parseUpdate()
+	case p.isKeyword("DELETE"):
+		stmt, err = p.parseDelete()
+	default:
+		return nil, 0, p.errorf("expected SELECT, INSERT, UPDATE or DELETE")
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	p.acceptOp(";")
+	if p.peek().kind != tokEOF {
+		return nil, 0, p.errorf("unexpected %s", p.describe())
+	}
+	return stmt, p.params, nil
+}
+
+func (self *tParser) parseSelect() (*tSelectStmt, error) {
+	self.next() // SELECT
+	stmt := &tSelectStmt{}
+	for {
+		var item tSelectItem
+		if self.acceptOp("*") {
+			item.star = true
+		} else {
+			expr, err := self.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item.expr = expr
+			if item.alias, err = self.parseAlias(); err != nil {
+				return nil, err
+			}
+		}
+		stmt.items = append(stmt.items, item)
+		if !self.acceptOp(",") {
+			break
+		}
+	}
+
+	if self.acceptKeyword("FROM") {
+		name, err := self.parseTableName()
+		if err != nil {
+			return nil, err
+		}
+		stmt.table = name
+		if _, err := self.parseAlias(); err != nil { // 表别名,列名前缀不区分表
+			return nil, err
+		}
+	}
+
+	var err error
+	if self.acceptKeyword("WHERE") {
+		if stmt.where, err = self.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if self.acceptKeyword("GROUP") {
+		if err := self.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			expr, err := self.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			stmt.groupBy = append(stmt.groupBy, expr)
+			if !self.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if self.acceptKeyword("ORDER") {
+		if err := self.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			expr, err := self.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := tOrderItem{expr: expr}
+			if self.acceptKeyword("DESC") {
+				item.desc = true
+			} else {
+				self.acceptKeyword("ASC")
+			}
+			stmt.orderBy = append(stmt.orderBy, item)
+			if !self.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if self.acceptKeyword("LIMIT") {
+		if stmt.limit, err = self.parsePrimary(); err != nil {
+			return nil, err
+		}
+	}
+	if self.acceptKeyword("OFFSET") {
+		if stmt.offset, err = self.parsePrimary(); err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+func (self *tParser) parseAlias() (string, error) {
+	if self.acceptKeyword("AS") {
+		return self.parseIdent()
+	}
+	if tok := self.peek(); tok.kind == tokQuoted || (tok.kind == tokIdent && !self.reserved(tok.text)) {
+		return self.parseIdent()
+	}
+	return "", nil
+}
+
+func (self *tParser) parseInsert() (*tInsertStmt, error) {
+	self.next() // INSERT
+	if err := self.expectKeyword("INTO"); err != nil {
+		return nil, err
+	}
+	name, err := self.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &tInsertStmt{table: name}
+	if self.acceptOp("(") {
+		for {
+			col, err := self.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+			stmt.columns = append(stmt.columns, col)
+			if !self.acceptOp(",") {
+				break
+			}
+		}
+		if err := self.expectOp(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := self.expectKeyword("VALUES"); err != nil {
+		return nil, err
+	}
+	for {
+		if err := self.expectOp("("); err != nil {
+			return nil, err
+		}
+		var row []tExpr
+		for {
+			expr, err := self.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, expr)
+			if !self.acceptOp(",") {
+				break
+			}
+		}
+		if err := self.expectOp(")"); err != nil {
+			return nil, err
+		}
+		stmt.rows = append(stmt.rows, row)
+		if !self.acceptOp(",") {
+			break
+		}
+	}
+	return stmt, nil
+}
+
+func (self *tParser) parseUpdate() (*tUpdateStmt, error) {
+	self.next() // UPDATE
+	name, err := self.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+	if err := self.expectKeyword("SET"); err != nil {
+		return nil, err
+	}
+
+	stmt := &tUpdateStmt{table: name}
+	for {
+		col, err := self.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		if err := self.expectOp("="); err != nil {
+			return nil, err
+		}
+		expr, err := self.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.sets = append(stmt.sets, tAssign{column: col, expr: expr})
+		if !self.acceptOp(",") {
+			break
+		}
+	}
+
+	if self.acceptKeyword("WHERE") {
+		if stmt.where, err = self.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+func (self *tParser) parseDelete() (*tDeleteStmt, error) {
+	self.next() // DELETE
+	if err := self.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	name, err := self.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &tDeleteStmt{table: name}
+	if self.acceptKeyword("WHERE") {
+		if stmt.where, err = self.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+// parseTableName 表名,可带模式名前缀(如 public.orders,模式名被忽略)
+func (self *tParser) parseTableName() (string, error) {
+	name, err := self.parseIdent()
+	if err != nil {
+		return "", err
+	}
+	for self.acceptOp(".") {
+		if name, err = self.parseIdent(); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+func (self *tParser) parseIdent() (string, error) {
+	tok := self.peek()
+	if tok.kind == tokQuoted || (tok.kind == tokIdent && !self.reserved(tok.text)) {
+		self.next()
+		return tok.text, nil
+	}
+	return "", self.errorf("expected identifier, found %s", self.describe())
+}
+
+// 表达式,优先级从低到高:OR、AND、NOT、比较(= <> < <= > >= IS IN BETWEEN LIKE)、+ - ||(字符串连接)、* / %、一元 -
+func (self *tParser) parseExpr() (tExpr, error) {
+	return self.parseOr()
+}
+
+func (self *tParser) parseOr() (tExpr, error) {
+	left, err := self.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := self.peek()
+		if !self.acceptKeyword("OR") && !(self.expr && self.acceptOp("||")) {
+			return left, nil
+		}
+		right, err := self.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &tBinary{op: "OR", left: left, right: right, pos: tok.pos}
+	}
+}
+
+func (self *tParser) parseAnd() (tExpr, error) {
+	left, err := self.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := self.peek()
+		if !self.acceptKeyword("AND") && !(self.expr && self.acceptOp("&&")) {
+			return left, nil
+		}
+		right, err := self.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &tBinary{op: "AND", left: left, right: right, pos: tok.pos}
+	}
+}
+
+func (self *tParser) parseNot() (tExpr, error) {
+	tok := self.peek()
+	if self.acceptKeyword("NOT") || (self.expr && self.acceptOp("!")) {
+		x, err := self.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &tUnary{op: "NOT", x: x, pos: tok.pos}, nil
+	}
+	return self.parseComparison()
+}
+
+func (self *tParser) parseComparison() (tExpr, error) {
+	left, err := self.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := self.peek()
+	if tok.kind == tokOp {
+		switch tok.text {
+		case "=", "==", "<>", "!=", "<", "<=", ">", ">=":
+			self.next()
+			right, err := self.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			op := tok.text
+			switch op {
+			case "==":
+				op = "="
+			case "<>":
+				op = "!="
+			}
+			return &tBinary{op: op, left: left, right: right, pos: tok.pos}, nil
+		}
+		return left, nil
+	}
+
+	if self.acceptKeyword("IS") {
+		not := self.acceptKeyword("NOT")
+		if err := self.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &tIsNull{x: left, not: not}, nil
+	}
+
+	not := self.acceptKeyword("NOT")
+	switch {
+	case self.acceptKeyword("IN"):
+		if err := self.expectOp("("); err != nil {
+			return nil, err
+		}
+		in := &tIn{x: left, not: not}
+		for {
+			item, err := self.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, item)
+			if !self.acceptOp(",") {
+				break
+			}
+		}
+		if err := self.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+
+	case self.acceptKeyword("BETWEEN"):
+		low, err := self.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err := self.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := self.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &tBetween{x: left, low: low, high: high, not: not}, nil
+
+	case self.acceptKeyword("LIKE"):
+		pattern, err := self.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &tLike{x: left, pattern: pattern, not: not}, nil
+	}
+
+	if not {
+		return nil, self.errorf("expected IN, BETWEEN or LIKE after NOT")
+	}
+	return left, nil
+}
+
+func (self *tParser) parseAdditive() (tExpr, error) {
+	left, err := self.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := self.peek()
+		if tok.kind != tokOp || (tok.text != "+" && tok.text != "-" && (tok.text != "||" || self.expr)) {
+			return left, nil
+		}
+		self.next()
+		right, err := self.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &tBinary{op: tok.text, left: left, right: right, pos: tok.pos}
+	}
+}
+
+func (self *tParser) parseMultiplicative() (tExpr, error) {
+	left, err := self.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := self.peek()
+		if tok.kind != tokOp || (tok.text != "*" && tok.text != "/" && tok.text != "%") {
+			return left, nil
+		}
+		self.next()
+		right, err := self.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &tBinary{op: tok.text, left: left, right: right, pos: tok.pos}
+	}
+}
+
+func (self *tParser) parseUnary() (tExpr, error) {
+	tok := self.peek()
+	if tok.kind == tokOp && (tok.text == "-" || tok.text == "+") {
+		self.next()
+		x, err := self.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "+" {
+			return x, nil
+		}
+		return &tUnary{op: "-", x: x, pos: tok.pos}, nil
+	}
+	return self.parsePrimary()
+}
+
+func (self *tParser) parsePrimary() (tExpr, error) {
+	tok := self.peek()
+	switch tok.kind {
+	case tokNumber:
+		self.next()
+		if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return &tLiteral{value: n}, nil
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, &TSyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("invalid number %s", tok.text)}
+		}
+		return &tLiteral{value: f}, nil
+
+	case tokString:
+		self.next()
+		return &tLiteral{value: tok.text}, nil
+
+	case tokParam:
+		self.next()
+		if tok.text == "?" {
+			self.params++
+			return &tParam{index: self.params - 1, pos: tok.pos}, nil
+		}
+		n, _ := strconv.Atoi(tok.text[1:])
+		if n < 1 {
+			return nil, &TSyntaxError{Pos: tok.pos, Msg: "parameter numbers start at $1"}
+		}
+		self.params = max(self.params, n)
+		return &tParam{index: n - 1, pos: tok.pos}, nil
+
+	case tokQuoted:
+		self.next()
+		return self.parseColumn(tok)
+
+	case tokIdent:
+		switch strings.ToUpper(tok.text) {
+		case "NULL":
+			self.next()
+			return &tLiteral{}, nil
+		case "TRUE":
+			self.next()
+			return &tLiteral{value: true}, nil
+		case "FALSE":
+			self.next()
+			return &tLiteral{value: false}, nil
+		}
+		if self.reserved(tok.text) {
+			return nil, self.errorf("unexpected %s", self.describe())
+		}
+
+		self.next()
+		if self.acceptOp("(") {
+			return self.parseCall(tok)
+		}
+		return self.parseColumn(tok)
+
+	case tokOp:
+		if tok.text == "(" {
+			self.next()
+			x, err := self.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := self.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	return nil, self.errorf("unexpected %s", self.describe())
+}
+
+// parseColumn 列名及其后的 .key 路径,见 tColumnRef
+func (self *tParser) parseColumn(name tToken) (tExpr, error) {
+	col := &tColumnRef{name: name.text, pos: name.pos}
+	for self.acceptOp(".") {
+		key, err := self.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		col.path = append(col.path, key)
+	}
+	return col, nil
+}
+
+func (self *tParser) parseCall(name tToken) (tExpr, error) {
+	call := &tCall{name: strings.ToUpper(name.text), pos: name.pos}
+	if self.acceptOp("*") {
+		call.star = true
+	} else if !self.isOp(")") {
+		for {
+			arg, err := self.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if !self.acceptOp(",") {
+				break
+			}
+		}
+	}
+	if err := self.expectOp(")"); err != nil {
+		return nil, err
+	}
+	if err := checkCall(call); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+func (self *tParser) peek() tToken {
+	return self.tokens[self.pos]
+}
+
+func (self *tParser) next() tToken {
+	tok := self.tokens[self.pos]
+	if tok.kind != tokEOF {
+		self.pos++
+	}
+	return tok
+}
+
+func (self *tParser) isKeyword(word string) bool {
+	tok := self.peek()
+	return tok.kind == tokIdent && strings.EqualFold(tok.text, word)
+}
+
+func (self *tParser) acceptKeyword(word string) bool {
+	if self.isKeyword(word) {
+		self.next()
+		return true
+	}
+	return false
+}
+
+func (self *tParser) expectKeyword(word string) error {
+	if !self.acceptKeyword(word) {
+		return self.errorf("expected %s, found %s", word, self.describe())
+	}
+	return nil
+}
+
+func (self *tParser) isOp(op string) bool {
+	tok := self.peek()
+	return tok.kind == tokOp && tok.text == op
+}
+
+func (self *tParser) acceptOp(op string) bool {
+	if self.isOp(op) {
+		self.next()
+		return true
+	}
+	return false
+}
+
+func (self *tParser) expectOp(op string) error {
+	if !self.acceptOp(op) {
+		return self.errorf("expected %q, found %s", op, self.describe())
+	}
+	return nil
+}
+
+// describe 当前记号的描述,用于错误信息
+func (self *tParser) describe() string {
+	tok := self.peek()
+	switch tok.kind {
+	case tokEOF:
+		return "end of input"
+	case tokString:
+		return fmt.Sprintf("string '%s'", tok.text)
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
+
+func (self *tParser) errorf(format string, args ...any) error {
+	return &TSyntaxError{Pos: self.peek().pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// reserved 不能作为未加引号的标识符或别名的关键字,表达式模式下只保留运算符关键字
+func (self *tParser) reserved(word string) bool {
+	switch strings.ToUpper(word) {
+	case "AND", "OR", "NOT", "IS", "IN", "BETWEEN", "LIKE", "NULL", "TRUE", "FALSE":
+		return true
+	}
+	return !self.expr && isReservedWord(word)
+}
+
+// isReservedWord SQL 关键字
+func isReservedWord(word string) bool {
+	switch strings.ToUpper(word) {
+	case "SELECT", "FROM", "WHERE", "GROUP", "ORDER", "BY", "LIMIT", "OFFSET", "AND", "OR", "NOT",
+		"IS", "IN", "BETWEEN", "LIKE", "AS", "ASC", "DESC", "INSERT", "INTO", "VALUES",
+		"UPDATE", "SET", "DELETE", "NULL", "TRUE", "FALSE":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package dataset
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSQLParse(t *testing.T) {
+	stmt, params, err := parseSQL(`select "Name", count(*) AS n FROM public.orders WHERE amount >= $2 AND state IN ('a', 'it''s') -- comment
+		GROUP BY 1 ORDER BY n DESC, "Name" LIMIT ? OFFSET 5;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sel, ok := stmt.(*tSelectStmt)
+	if !ok || sel.table != "orders" || len(sel.items) != 2 || sel.items[1].alias != "n" {
+		t.Fatalf("unexpected select %+v", stmt)
+	}
+	if len(sel.groupBy) != 1 || len(sel.orderBy) != 2 || !sel.orderBy[0].desc || sel.orderBy[1].desc || sel.limit == nil || sel.offset == nil {
+		t.Fatalf("unexpected clauses %+v", sel)
+	}
+	if params != 3 { // $2 与之后的 ?(第 3 个)
+		t.Fatalf("expected 3 parameters, got %d", params)
+	}
+
+	stmt, params, err = parseSQL("INSERT INTO t (a, b) VALUES (?, 1), (?, NULL)")
+	if ins, ok := stmt.(*tInsertStmt); err != nil || !ok || len(ins.columns) != 2 || len(ins.rows) != 2 || params != 2 {
+		t.Fatalf("unexpected insert %+v %v", stmt, err)
+	}
+	stmt, _, err = parseSQL("UPDATE t SET a = a + 1, b = 'x' WHERE id = 1")
+	if upd, ok := stmt.(*tUpdateStmt); err != nil || !ok || len(upd.sets) != 2 || upd.where == nil {
+		t.Fatalf("unexpected update %+v %v", stmt, err)
+	}
+	stmt, _, err = parseSQL("DELETE FROM t")
+	if del, ok := stmt.(*tDeleteStmt); err != nil || !ok || del.where != nil {
+		t.Fatalf("unexpected delete %+v %v", stmt, err)
+	}
+}
+
+func TestSQLParseErrors(t *testing.T) {
+	cases := []struct {
+		sql string
+		pos int
+	}{
+		{"SELECT a FROM", 13},
+		{"SELECT a FROM t WHERE", 21},
+		{"SELECT a FROM t WHERE b = 'x", 26},
+		{"SELECT a, FROM t", 10},
+		{"SELECT nope(a) FROM t", 7},
+		{"SELECT a FROM t x extra", 18},
+		{"DROP TABLE t", 0},
+		{"SELECT a # b FROM t", 9},
+		{"SELECT a FROM t WHERE a NOT 1", 28},
+	}
+	for _, c := range cases {
+		_, _, err := parseSQL(c.sql)
+		var syntax *TSyntaxError
+		if !errors.As(err, &syntax) {
+			t.Fatalf("%q: expected a syntax error, got %v", c.sql, err)
+		}
+		if syntax.Pos != c.pos {
+			t.Fatalf("%q: expected error at %d, got %v", c.sql, c.pos, err)
+		}
+	}
+}
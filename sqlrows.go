@@ -0,0 +1,200 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// sqlLoadBatch NewDataSetFromRows 每批经 LoadRows 加载的行数
+const sqlLoadBatch = 1024
+
+type (
+	// tSQLColumn 查询结果列的转换规则,由 sql.ColumnType 决定
+	tSQLColumn struct {
+		name   string
+		kind   reflect.Kind // 文本值解析的目标类型,Invalid 时保持为字符串
+		binary bool         // 二进制列,[]byte 值保持为 []byte
+	}
+)
+
+// WithRowLimit NewDataSetFromRows 最多读取 limit 行,<= 0 表示不限
+func WithRowLimit(limit int) Option {
+	return func(cfg *Config) {
+		cfg.rowLimit = limit
+	}
+}
+
+// WithLoadContext NewDataSetFromRows 读取过程中 ctx 被取消时停止读取并返回 ctx.Err();
+// 同时作为 NewDataSetFromProvider 调用 DataProvider 时的 ctx
+func WithLoadContext(ctx context.Context) Option {
+	return func(cfg *Config) {
+		cfg.loadCtx = ctx
+	}
+}
+
+// NewDataSetFromRows 读取 rows 的全部结果创建数据集,读取完成或出错时关闭 rows。
+//
+// 按 ColumnTypes 转换驱动返回的值:
+//   - NULL(含 sql.Null* 扫描类型的无效值)为 nil;
+//   - 二进制列(数据库类型名含 BLOB/BINARY,或为 BYTEA/IMAGE/RAW;驱动未提供类型名时
+//     以扫描类型为 []byte 判断)的值保持为 []byte,其余列的 []byte 转为 string;
+//   - 扫描类型(或 sql.Null* 的值类型)为整数/浮点/布尔而驱动返回文本时解析为
+//     int64/uint64/float64/bool,其他值保持驱动返回的类型(int64/float64/time.Time 等)。
+//
+// 存在名为 id 的列(不区分大小写)时将其设为 KeyField。所有列均为 NULL 的行同样保留。
+// 行数限制与取消见 WithRowLimit/WithLoadContext,其余 opts 同 NewDataSet(如 WithFieldKind、WithColumnar)。
+func NewDataSetFromRows(rows *sql.Rows, opts ...Option) (*TDataSet, error) {
+	if rows == nil {
+		return nil, fmt.Errorf("rows is nil")
+	}
+	defer rows.Close()
+
+	ds := NewDataSet(opts...)
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, len(types))
+	columns := make([]tSQLColumn, len(types))
+	for i, ct := range types {
+		columns[i] = sqlColumnOf(ct)
+		fields[i] = columns[i].name
+	}
+	for _, field := range fields {
+		ds.AddField(field) // 结果为空时仍保留字段结构
+	}
+
+	ctx := ds.config.loadCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	limit := ds.config.rowLimit
+
+	var (
+		batch = make([][]any, 0, sqlLoadBatch)
+		count int
+	)
+	for (limit <= 0 || count < limit) && rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, col := range columns {
+			if values[i], err = col.convert(values[i]); err != nil {
+				return nil, fmt.Errorf("row %d: %w", count, err)
+			}
+		}
+
+		batch = append(batch, values)
+		count++
+		if len(batch) == sqlLoadBatch {
+			if err := ds.LoadRows(fields, batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := ds.LoadRows(fields, batch); err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		if strings.EqualFold(field, "id") {
+			if !ds.SetKeyField(field) {
+				ds.KeyField = field
+			}
+			break
+		}
+	}
+	ds.First()
+	return ds, nil
+}
+
+func sqlColumnOf(ct *sql.ColumnType) tSQLColumn {
+	col := tSQLColumn{name: ct.Name()}
+
+	st := ct.ScanType()
+	if st != nil && st.Kind() == reflect.Struct && st.NumField() == 2 {
+		// sql.NullInt64、sql.Null[T] 等:取值字段的类型
+		if valid, has := st.FieldByName("Valid"); has && valid.Type.Kind() == reflect.Bool {
+			value := st.Field(0)
+			if value.Name == "Valid" {
+				value = st.Field(1)
+			}
+			st = value.Type
+		}
+	}
+
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	switch {
+	case strings.Contains(name, "BLOB"), strings.Contains(name, "BINARY"),
+		name == "BYTEA", name == "IMAGE", name == "RAW":
+		col.binary = true
+	case name == "" && st == reflect.TypeOf([]byte(nil)):
+		col.binary = true
+	}
+
+	if st != nil {
+		switch st.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Bool:
+			col.kind = st.Kind()
+		}
+	}
+	return col
+}
+
+// convert 转换驱动返回的值,见 NewDataSetFromRows
+func (self tSQLColumn) convert(value any) (any, error) {
+	var s string
+	switch v := value.(type) {
+	case []byte:
+		if self.binary {
+			return v, nil // 扫描到 *any 时 database/sql 已复制
+		}
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return value, nil
+	}
+
+	var (
+		res any = s
+		err error
+	)
+	switch self.kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		res, err = strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		res, err = strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	case reflect.Float32, reflect.Float64:
+		res, err = strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case reflect.Bool:
+		res, err = strconv.ParseBool(strings.TrimSpace(s))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("column < %s >: %w", self.name, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,198 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type (
+	fakeColumn struct {
+		name     string
+		dbType   string
+		scanType reflect.Type
+	}
+
+	fakeTable struct {
+		columns []fakeColumn
+		rows    [][]driver.Value
+		closed  bool
+	}
+
+	fakeDriver struct{}
+	fakeConn   struct{ table *fakeTable }
+	fakeStmt   struct{ table *fakeTable }
+	fakeRows   struct {
+		table *fakeTable
+		pos   int
+	}
+)
+
+// fakeTables 以 DSN 为名的测试数据
+var fakeTables = map[string]*fakeTable{}
+
+// fakeOrderColumns/fakeOrderRows 覆盖常见的扫描类型,第 2 行除 ID 外均为 NULL
+var (
+	fakeOrderColumns = []fakeColumn{
+		{"ID", "BIGINT", reflect.TypeOf(int64(0))},
+		{"name", "VARCHAR", reflect.TypeOf(sql.RawBytes(nil))},
+		{"data", "BLOB", reflect.TypeOf([]byte(nil))},
+		{"amount", "DECIMAL", reflect.TypeOf(sql.NullFloat64{})},
+		{"qty", "INT", reflect.TypeOf(sql.Null[int32]{})},
+		{"active", "BOOL", reflect.TypeOf(sql.NullBool{})},
+		{"created", "TIMESTAMP", reflect.TypeOf(sql.NullTime{})},
+	}
+	fakeOrderCreated = time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	fakeOrderRows    = [][]driver.Value{
+		{int64(1), []byte("apple"), []byte{0, 1}, []byte("1.50"), []byte("3"), true, fakeOrderCreated},
+		{int64(2), []byte("pear"), nil, nil, nil, nil, nil},
+		{int64(3), "plum", []byte{2}, 2.5, int64(7), []byte("0"), fakeOrderCreated},
+	}
+)
+
+func init() {
+	sql.Register("dataset-fake", fakeDriver{})
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	table, has := fakeTables[name]
+	if !has {
+		return nil, errors.New("unknown table " + name)
+	}
+	return &fakeConn{table: table}, nil
+}
+
+func (self *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{table: self.table}, nil
+}
+func (self *fakeConn) Close() error              { return nil }
+func (self *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+func (self *fakeStmt) Close() error  { return nil }
+func (self *fakeStmt) NumInput() int { return -1 }
+func (self *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (self *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	self.table.closed = false
+	return &fakeRows{table: self.table}, nil
+}
+
+func (self *fakeRows) Columns() []string {
+	names := make([]string, len(self.table.columns))
+	for i, col := range self.table.columns {
+		names[i] = col.name
+	}
+	return names
+}
+
+func (self *fakeRows) Close() error {
+	self.table.closed = true
+	return nil
+}
+
+func (self *fakeRows) Next(dest []driver.Value) error {
+	if self.pos >= len(self.table.rows) {
+		return io.EOF
+	}
+	copy(dest, self.table.rows[self.pos])
+	self.pos++
+	return nil
+}
+
+func (self *fakeRows) ColumnTypeDatabaseTypeName(i int) string { return self.table.columns[i].dbType }
+func (self *fakeRows) ColumnTypeScanType(i int) reflect.Type   { return self.table.columns[i].scanType }
+
+func queryFake(t *testing.T, name string, table *fakeTable) *sql.Rows {
+	t.Helper()
+	fakeTables[name] = table
+	db, err := sql.Open("dataset-fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestNewDataSetFromRows(t *testing.T) {
+	table := &fakeTable{columns: fakeOrderColumns, rows: fakeOrderRows}
+	ds, err := NewDataSetFromRows(queryFake(t, "orders", table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !table.closed {
+		t.Fatal("rows should be closed")
+	}
+	if ds.Count() != 3 || ds.KeyField != "ID" || ds.RecordByKey(int64(2)) == nil {
+		t.Fatalf("unexpected dataset count=%d key=%q", ds.Count(), ds.KeyField)
+	}
+
+	rec := ds.Data[0]
+	if rec.GetByField("name") != "apple" || rec.GetByField("amount") != 1.5 || rec.GetByField("qty") != int64(3) ||
+		rec.GetByField("active") != true || rec.GetByField("created") != table.rows[0][6] {
+		t.Fatalf("unexpected values %v", rec.AsMap())
+	}
+	if data, ok := rec.GetByField("data").([]byte); !ok || len(data) != 2 {
+		t.Fatal("binary columns should stay []byte")
+	}
+
+	rec = ds.Data[1]
+	for _, field := range []string{"data", "amount", "qty", "active", "created"} {
+		if rec.GetByField(field) != nil {
+			t.Fatalf("NULL %s should be nil, got %v", field, rec.GetByField(field))
+		}
+	}
+	if ds.Data[2].GetByField("active") != false || ds.Data[2].GetByField("qty") != int64(7) {
+		t.Fatal("driver values should be kept or parsed by scan type")
+	}
+}
+
+func TestNewDataSetFromRowsOptions(t *testing.T) {
+	table := &fakeTable{columns: fakeOrderColumns, rows: fakeOrderRows}
+	ds, err := NewDataSetFromRows(queryFake(t, "limit", table), WithRowLimit(2), WithColumnar())
+	if err != nil || ds.Count() != 2 || !table.closed || !ds.IsColumnar() {
+		t.Fatalf("row limit should stop reading and close rows: %v", err)
+	}
+
+	// 全为 NULL 的行保留并计入行数限制
+	nulls := &fakeTable{columns: fakeOrderColumns, rows: slices.Clone(fakeOrderRows)}
+	nulls.rows[1] = make([]driver.Value, len(nulls.columns))
+	ds, err = NewDataSetFromRows(queryFake(t, "nulls", nulls), WithRowLimit(2))
+	if err != nil || ds.Count() != 2 || ds.Data[1].GetByField("ID") != nil || ds.Data[1].GetByField("name") != nil {
+		t.Fatalf("rows with only NULL values should be kept: %v", err)
+	}
+	ds, _ = NewDataSetFromRows(queryFake(t, "nulls", nulls))
+	if ds.Count() != 3 {
+		t.Fatalf("expected 3 rows, got %d", ds.Count())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewDataSetFromRows(queryFake(t, "cancel", &fakeTable{columns: fakeOrderColumns, rows: fakeOrderRows}), WithLoadContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	empty := &fakeTable{columns: fakeOrderColumns}
+	ds, err = NewDataSetFromRows(queryFake(t, "empty", empty))
+	if err != nil || ds.Count() != 0 || ds.FieldCount != 7 || ds.KeyField != "ID" {
+		t.Fatal("empty results should keep the columns")
+	}
+
+	bad := &fakeTable{columns: fakeOrderColumns, rows: slices.Clone(fakeOrderRows)}
+	bad.rows[2] = slices.Clone(bad.rows[2])
+	bad.rows[2][4] = []byte("x")
+	if _, err := NewDataSetFromRows(queryFake(t, "bad", bad)); err == nil {
+		t.Fatal("unparsable values should fail")
+	}
+}
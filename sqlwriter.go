@@ -0,0 +1,377 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultSQLBatchSize 每条 INSERT 语句默认最多包含的行数
+const DefaultSQLBatchSize = 500
+
+// ErrNoChangeLog 数据集未启用变更日志,无法生成增量语句
+var ErrNoChangeLog = errors.New("change log is not enabled")
+
+type (
+	// TDialect SQL 方言
+	TDialect struct {
+		Name        string
+		Placeholder func(n int) string        // 第 n 个参数(从 1 开始)的占位符
+		Quote       func(ident string) string // 引用标识符
+		MaxParams   int                       // 单条语句的参数上限
+	}
+
+	// TSQLStatement 生成的 SQL 语句及其参数
+	TSQLStatement struct {
+		SQL  string
+		Args []any
+	}
+
+	// TSQLExecutor 执行语句的接口,*sql.DB、*sql.Tx 与 *sql.Conn 均满足
+	TSQLExecutor interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	}
+
+	// TSQLWriter 将数据集写回数据库表,由 NewSQLWriter 创建
+	TSQLWriter struct {
+		table     string
+		dialect   *TDialect
+		fields    []string
+		keyField  string
+		batchSize int
+	}
+
+	// SQLWriterOption TSQLWriter 选项
+	SQLWriterOption func(*TSQLWriter)
+)
+
+var (
+	// DialectPostgres PostgreSQL:$1 占位符,"ident" 引用
+	DialectPostgres = &TDialect{
+		Name:        "postgres",
+		Placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+		Quote:       quoteWith(`"`),
+		MaxParams:   65535,
+	}
+
+	// DialectMySQL MySQL:? 占位符,`ident` 引用
+	DialectMySQL = &TDialect{
+		Name:        "mysql",
+		Placeholder: func(int) string { return "?" },
+		Quote:       quoteWith("`"),
+		MaxParams:   65535,
+	}
+
+	// DialectSQLite SQLite:? 占位符,"ident" 引用,参数上限按 3.32 之前的 999 计
+	DialectSQLite = &TDialect{
+		Name:        "sqlite",
+		Placeholder: func(int) string { return "?" },
+		Quote:       quoteWith(`"`),
+		MaxParams:   999,
+	}
+)
+
+// NewSQLWriter 创建写入 table 的 TSQLWriter,table 可带模式名(如 public.orders),dialect 为 nil 时使用 DialectPostgres
+func NewSQLWriter(table string, dialect *TDialect, opts ...SQLWriterOption) *TSQLWriter {
+	if dialect == nil {
+		dialect = DialectPostgres
+	}
+
+	w := &TSQLWriter{
+		table:     table,
+		dialect:   dialect,
+		batchSize: DefaultSQLBatchSize,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(w)
+		}
+	}
+	return w
+}
+
+// WithSQLFields 只写入指定字段,默认为数据集的全部字段(不含计算字段)
+func WithSQLFields(fields ...string) SQLWriterOption {
+	return func(w *TSQLWriter) {
+		w.fields = fields
+	}
+}
+
+// WithSQLKeyField 指定 UPDATE/DELETE 定位记录的主键字段,默认为数据集的 KeyField,未设置时为 id
+func WithSQLKeyField(field string) SQLWriterOption {
+	return func(w *TSQLWriter) {
+		w.keyField = field
+	}
+}
+
+// WithSQLBatchSize 每条 INSERT 最多包含的行数,同时受方言参数上限限制
+func WithSQLBatchSize(size int) SQLWriterOption {
+	return func(w *TSQLWriter) {
+		if size > 0 {
+			w.batchSize = size
+		}
+	}
+}
+
+// BuildInsert 为所有记录生成批量 INSERT
+func (self *TSQLWriter) BuildInsert(ds *TDataSet) ([]TSQLStatement, error) {
+	records, err := ds.records()
+	if err != nil {
+		return nil, err
+	}
+	return self.buildInsert(self.columns(ds), records)
+}
+
+// BuildUpdate 为所有记录生成按主键定位的 UPDATE,更新主键以外的全部字段
+func (self *TSQLWriter) BuildUpdate(ds *TDataSet) ([]TSQLStatement, error) {
+	key := self.key(ds)
+	fields := slices.DeleteFunc(self.columns(ds), func(f string) bool { return f == key })
+
+	var res []TSQLStatement
+	err := ds.scan(func(_ int, rec *TRecordSet) error {
+		stmt, err := self.buildUpdate(rec, fields, key, rec.GetByField(key))
+		if stmt != nil {
+			res = append(res, *stmt)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// BuildDelete 为所有记录生成按主键的 DELETE ... WHERE key IN (...)
+func (self *TSQLWriter) BuildDelete(ds *TDataSet) ([]TSQLStatement, error) {
+	key := self.key(ds)
+	keys := make([]any, 0, len(ds.Data))
+	if err := ds.scan(func(_ int, rec *TRecordSet) error {
+		keys = append(keys, rec.GetByField(key))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return self.buildDelete(key, keys)
+}
+
+// BuildDelta 由数据集的变更日志(Delta)生成语句:先 DELETE 被删除的记录,再 UPDATE 被修改的字段,
+// 最后 INSERT 新增的记录。主键被修改过的记录以修改前的主键定位。未启用变更日志时返回 ErrNoChangeLog。
+func (self *TSQLWriter) BuildDelta(ds *TDataSet) ([]TSQLStatement, error) {
+	delta := ds.Delta()
+	if delta == nil {
+		return nil, ErrNoChangeLog
+	}
+
+	key := self.key(ds)
+	origin := originalValues(ds.Changes(), key)
+	keyOf := func(rec *TRecordSet) any {
+		if v, has := origin[rec.latest()]; has {
+			return v
+		}
+		return rec.GetByField(key)
+	}
+
+	var res []TSQLStatement
+	keys := make([]any, 0, len(delta.Deleted))
+	for _, rec := range delta.Deleted {
+		keys = append(keys, keyOf(rec))
+	}
+	stmts, err := self.buildDelete(key, keys)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, stmts...)
+
+	columns := self.columns(ds)
+	for _, upd := range delta.Updated {
+		fields := slices.DeleteFunc(slices.Clone(upd.Fields), func(f string) bool {
+			return !slices.Contains(columns, f)
+		})
+		stmt, err := self.buildUpdate(upd.Record, fields, key, keyOf(upd.Record))
+		if err != nil {
+			return nil, err
+		}
+		if stmt != nil {
+			res = append(res, *stmt)
+		}
+	}
+
+	stmts, err = self.buildInsert(columns, delta.Inserted)
+	if err != nil {
+		return nil, err
+	}
+	return append(res, stmts...), nil
+}
+
+// Exec 依次执行语句,遇到错误即停止。需要原子性时传入 *sql.Tx。
+func (self *TSQLWriter) Exec(ctx context.Context, exec TSQLExecutor, stmts []TSQLStatement) error {
+	for i, stmt := range stmts {
+		if _, err := exec.ExecContext(ctx, stmt.SQL, stmt.Args...); err != nil {
+			return fmt.Errorf("statement %d (%s): %w", i, stmt.SQL, err)
+		}
+	}
+	return nil
+}
+
+// Save 执行 BuildDelta 生成的语句,全部成功后 AcceptChanges
+func (self *TSQLWriter) Save(ctx context.Context, exec TSQLExecutor, ds *TDataSet) error {
+	stmts, err := self.BuildDelta(ds)
+	if err != nil {
+		return err
+	}
+	if err := self.Exec(ctx, exec, stmts); err != nil {
+		return err
+	}
+	ds.AcceptChanges()
+	return nil
+}
+
+func (self *TSQLWriter) buildInsert(fields []string, records []*TRecordSet) ([]TSQLStatement, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields to insert into %s", self.table)
+	}
+
+	head := "INSERT INTO " + self.quoteTable() + " (" + self.quoteList(fields) + ") VALUES "
+	batch := self.chunkSize(len(fields))
+
+	var res []TSQLStatement
+	for chunk := range slices.Chunk(records, batch) {
+		var sb strings.Builder
+		sb.WriteString(head)
+		args := make([]any, 0, len(chunk)*len(fields))
+		for i, rec := range chunk {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteByte('(')
+			for j, field := range fields {
+				if j > 0 {
+					sb.WriteString(", ")
+				}
+				args = append(args, rec.GetByField(field))
+				sb.WriteString(self.dialect.Placeholder(len(args)))
+			}
+			sb.WriteByte(')')
+		}
+		res = append(res, TSQLStatement{SQL: sb.String(), Args: args})
+	}
+	return res, nil
+}
+
+// buildUpdate 生成更新 fields 的语句,fields 为空时返回 nil
+func (self *TSQLWriter) buildUpdate(rec *TRecordSet, fields []string, key string, keyValue any) (*TSQLStatement, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if isNull(keyValue) {
+		return nil, fmt.Errorf("record %d has no value for key field < %s >", rec.latest().index, key)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("UPDATE " + self.quoteTable() + " SET ")
+	args := make([]any, 0, len(fields)+1)
+	for i, field := range fields {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		args = append(args, rec.GetByField(field))
+		sb.WriteString(self.dialect.Quote(field) + " = " + self.dialect.Placeholder(len(args)))
+	}
+	args = append(args, keyValue)
+	sb.WriteString(" WHERE " + self.dialect.Quote(key) + " = " + self.dialect.Placeholder(len(args)))
+	return &TSQLStatement{SQL: sb.String(), Args: args}, nil
+}
+
+func (self *TSQLWriter) buildDelete(key string, keys []any) ([]TSQLStatement, error) {
+	for _, k := range keys {
+		if isNull(k) {
+			return nil, fmt.Errorf("record has no value for key field < %s >", key)
+		}
+	}
+
+	head := "DELETE FROM " + self.quoteTable() + " WHERE " + self.dialect.Quote(key) + " IN ("
+	var res []TSQLStatement
+	for chunk := range slices.Chunk(keys, self.chunkSize(1)) {
+		var sb strings.Builder
+		sb.WriteString(head)
+		for i := range chunk {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(self.dialect.Placeholder(i + 1))
+		}
+		sb.WriteByte(')')
+		res = append(res, TSQLStatement{SQL: sb.String(), Args: slices.Clone(chunk)})
+	}
+	return res, nil
+}
+
+// chunkSize 每条语句的行数:不超过 batchSize 且参数个数不超过方言上限
+func (self *TSQLWriter) chunkSize(paramsPerRow int) int {
+	size := self.batchSize
+	if self.dialect.MaxParams > 0 {
+		size = min(size, self.dialect.MaxParams/max(paramsPerRow, 1))
+	}
+	return max(size, 1)
+}
+
+// columns 写入的字段
+func (self *TSQLWriter) columns(ds *TDataSet) []string {
+	if len(self.fields) > 0 {
+		return slices.Clone(self.fields)
+	}
+	return slices.Clone(ds.Fields())
+}
+
+func (self *TSQLWriter) key(ds *TDataSet) string {
+	if self.keyField != "" {
+		return self.keyField
+	}
+	return ds.keyFieldName()
+}
+
+func (self *TSQLWriter) quoteTable() string {
+	parts := strings.Split(self.table, ".")
+	for i, part := range parts {
+		parts[i] = self.dialect.Quote(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (self *TSQLWriter) quoteList(fields []string) string {
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = self.dialect.Quote(field)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quoteWith 以 q 包围标识符,标识符中的 q 重复转义
+func quoteWith(q string) func(string) string {
+	return func(ident string) string {
+		return q + strings.ReplaceAll(ident, q, q+q) + q
+	}
+}
+
+// originalValues 变更日志中 field 被修改过的记录及其首次修改前的值
+func originalValues(changes []TChange, field string) map[*TRecordSet]any {
+	res := make(map[*TRecordSet]any)
+	for _, c := range changes {
+		if c.Kind != ChangeUpdate || c.Field != field {
+			continue
+		}
+		if rec := c.Record.latest(); rec != nil {
+			if _, has := res[rec]; !has {
+				res[rec] = c.OldValue
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingExecutor 记录执行的语句,fail 为第几条语句返回错误(从 1 开始)
+type recordingExecutor struct {
+	stmts []TSQLStatement
+	fail  int
+}
+
+func (self *recordingExecutor) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	self.stmts = append(self.stmts, TSQLStatement{SQL: query, Args: args})
+	if len(self.stmts) == self.fail {
+		return nil, errors.New("exec failed")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func TestSQLWriterBuild(t *testing.T) {
+	ds := newFixture(viewFixture)
+	w := NewSQLWriter("public.order", DialectPostgres, WithSQLFields("id", "cat"), WithSQLBatchSize(2))
+
+	stmts, err := w.BuildInsert(ds)
+	if err != nil || len(stmts) != 2 {
+		t.Fatalf("expected 2 batches, got %d: %v", len(stmts), err)
+	}
+	if stmts[0].SQL != `INSERT INTO "public"."order" ("id", "cat") VALUES ($1, $2), ($3, $4)` || fmt.Sprint(stmts[0].Args) != "[1 A 2 B]" {
+		t.Fatalf("unexpected insert %q %v", stmts[0].SQL, stmts[0].Args)
+	}
+	if stmts[1].SQL != `INSERT INTO "public"."order" ("id", "cat") VALUES ($1, $2)` {
+		t.Fatalf("placeholders should restart per statement, got %q", stmts[1].SQL)
+	}
+
+	stmts, _ = w.BuildUpdate(ds)
+	if len(stmts) != 3 || stmts[2].SQL != `UPDATE "public"."order" SET "cat" = $1 WHERE "id" = $2` || fmt.Sprint(stmts[2].Args) != "[A 3]" {
+		t.Fatalf("unexpected update %+v", stmts)
+	}
+
+	my := NewSQLWriter("order", DialectMySQL)
+	stmts, _ = my.BuildDelete(ds)
+	if len(stmts) != 1 || stmts[0].SQL != "DELETE FROM `order` WHERE `id` IN (?, ?, ?)" {
+		t.Fatalf("unexpected delete %+v", stmts)
+	}
+
+	// 参数上限分块
+	lite := NewSQLWriter(`we"ird`, &TDialect{Placeholder: DialectSQLite.Placeholder, Quote: DialectSQLite.Quote, MaxParams: 4})
+	stmts, _ = lite.BuildInsert(ds)
+	if len(stmts) != 3 || !strings.HasPrefix(stmts[0].SQL, `INSERT INTO "we""ird" (`) || !strings.HasSuffix(stmts[0].SQL, "VALUES (?, ?, ?)") {
+		t.Fatalf("inserts should be chunked by the parameter limit, got %+v", stmts)
+	}
+	if stmts, _ = lite.BuildDelete(ds); len(stmts) != 1 {
+		t.Fatal("deletes should fit in one statement")
+	}
+
+	ds.Data[0].SetByField("id", nil)
+	if _, err := w.BuildUpdate(ds); err == nil {
+		t.Fatal("records without key should fail")
+	}
+}
+
+func TestSQLWriterDelta(t *testing.T) {
+	ds := newFixture(viewFixture)
+	w := NewSQLWriter("orders", DialectPostgres, WithSQLFields("id", "cat"))
+	if _, err := w.BuildDelta(ds); !errors.Is(err, ErrNoChangeLog) {
+		t.Fatal("delta without change log should fail")
+	}
+
+	ds.EnableChangeLog(0)
+	ds.Data[0].SetByField("cat", "Z")
+	ds.Data[0].SetByField("id", 10)
+	ds.Data[1].SetByField("tags", nil) // 不在写入字段中
+	ds.NewRecord(map[string]any{"id": 4, "cat": "D"})
+	ds.Delete(2)
+
+	stmts, err := w.BuildDelta(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`DELETE FROM "orders" WHERE "id" IN ($1)`,
+		`UPDATE "orders" SET "cat" = $1, "id" = $2 WHERE "id" = $3`,
+		`INSERT INTO "orders" ("id", "cat") VALUES ($1, $2)`,
+	}
+	if len(stmts) != len(want) {
+		t.Fatalf("unexpected statements %+v", stmts)
+	}
+	for i, stmt := range stmts {
+		if stmt.SQL != want[i] {
+			t.Fatalf("statement %d: got %q, want %q", i, stmt.SQL, want[i])
+		}
+	}
+	if fmt.Sprint(stmts[0].Args) != "[3]" || fmt.Sprint(stmts[1].Args) != "[Z 10 1]" {
+		t.Fatalf("unexpected args %v %v", stmts[0].Args, stmts[1].Args)
+	}
+
+	exec := &recordingExecutor{fail: 2}
+	if err := w.Save(context.Background(), exec, ds); err == nil || len(ds.Changes()) == 0 {
+		t.Fatal("failed save should keep the change log")
+	}
+	exec = &recordingExecutor{}
+	if err := w.Save(context.Background(), exec, ds); err != nil || len(exec.stmts) != 3 || len(ds.Changes()) != 0 {
+		t.Fatalf("save should execute all statements and accept changes: %v", err)
+	}
+}
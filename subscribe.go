@@ -0,0 +1,219 @@
+package dataset
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+type (
+	// TChangeKind 变更类型
+	TChangeKind uint8
+
+	// TChangeEvent 通过 Subscribe 投递的变更事件
+	TChangeEvent struct {
+		Kind     TChangeKind
+		Index    int            // 事件发生时记录在数据集中的位置
+		Key      any            // 记录的主键值(KeyField,未设置时为 id)
+		Field    string         // 更新的字段,仅 ChangeUpdate
+		OldValue any            // 更新前的值,仅 ChangeUpdate
+		NewValue any            // 更新后的值,仅 ChangeUpdate
+		Record   map[string]any // 记录的 AsMap 副本:插入/更新后的值,删除前的值
+	}
+
+	// TSlowConsumerPolicy 订阅者缓冲区已满时的处理方式
+	TSlowConsumerPolicy uint8
+
+	// SubscribeOption 订阅选项
+	SubscribeOption func(*tSubscriber)
+
+	tSubscriber struct {
+		mu     sync.Mutex // 保护 ch 的发送与关闭
+		ch     chan TChangeEvent
+		ctx    context.Context
+		filter func(TChangeEvent) bool
+		policy TSlowConsumerPolicy
+		size   int
+		closed bool
+		done   chan struct{}
+	}
+)
+
+const (
+	ChangeInsert TChangeKind = iota + 1
+	ChangeUpdate
+	ChangeDelete
+)
+
+const (
+	SlowConsumerDrop       TSlowConsumerPolicy = iota // 丢弃新事件(默认)
+	SlowConsumerBlock                                 // 阻塞修改操作直至订阅者接收或取消订阅
+	SlowConsumerDisconnect                            // 关闭该订阅者的通道并取消订阅
+)
+
+// DefaultSubscribeBuffer 订阅通道的默认缓冲大小
+const DefaultSubscribeBuffer = 64
+
+func (self TChangeKind) String() string {
+	switch self {
+	case ChangeInsert:
+		return "insert"
+	case ChangeUpdate:
+		return "update"
+	case ChangeDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// WithSubscribeBuffer 设置订阅通道的缓冲大小
+func WithSubscribeBuffer(size int) SubscribeOption {
+	return func(sub *tSubscriber) {
+		if size >= 0 {
+			sub.size = size
+		}
+	}
+}
+
+// WithSlowConsumer 设置缓冲区已满时的处理方式
+func WithSlowConsumer(policy TSlowConsumerPolicy) SubscribeOption {
+	return func(sub *tSubscriber) {
+		sub.policy = policy
+	}
+}
+
+// Subscribe 订阅数据集的插入/更新/删除事件,filter 为 nil 时接收全部事件。
+// 事件来源与 hook.go 中的变更钩子相同,在所有 After* 钩子之后按发生顺序投递。
+// ctx 取消或按 SlowConsumerDisconnect 断开时自动取消订阅并关闭通道,ctx 为 nil 时同 context.Background()。
+//
+// 注意 SlowConsumerBlock 会使修改操作(及 TConcurrentDataSet 的写锁)一直等待到订阅者接收,
+// 仅适用于必须接收全部事件且消费及时的订阅者。
+func (self *TDataSet) Subscribe(ctx context.Context, filter func(TChangeEvent) bool, opts ...SubscribeOption) <-chan TChangeEvent {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sub := &tSubscriber{
+		ctx:    ctx,
+		filter: filter,
+		size:   DefaultSubscribeBuffer,
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(sub)
+		}
+	}
+	sub.ch = make(chan TChangeEvent, sub.size)
+
+	hooks := self.ensureHooks()
+	hooks.subMu.Lock()
+	hooks.subs = append(hooks.subs, sub)
+	hooks.subMu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-sub.done:
+		}
+		hooks.unsubscribe(sub)
+	}()
+
+	return sub.ch
+}
+
+// unsubscribe 移除订阅者并关闭其通道
+func (self *tHooks) unsubscribe(sub *tSubscriber) {
+	self.subMu.Lock()
+	self.subs = slices.DeleteFunc(self.subs, func(s *tSubscriber) bool {
+		return s == sub
+	})
+	self.subMu.Unlock()
+
+	sub.close()
+}
+
+// publish 向所有订阅者投递事件
+func (self *tHooks) publish(kind TChangeKind, rec *TRecordSet, field string, oldValue, newValue any) {
+	self.subMu.Lock()
+	subs := slices.Clone(self.subs)
+	self.subMu.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	event := TChangeEvent{
+		Kind:   kind,
+		Index:  rec.index,
+		Record: rec.AsMap(),
+	}
+	if ds := rec.dataset; ds != nil {
+		keyField := ds.KeyField
+		if keyField == "" {
+			keyField = "id"
+		}
+		event.Key = rec.GetByField(keyField)
+	}
+	if kind == ChangeUpdate {
+		event.Field = field
+		event.OldValue = oldValue
+		event.NewValue = newValue
+	}
+
+	for _, sub := range subs {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+
+		// 每个订阅者持有独立的深复制
+		ev := event
+		ev.Record = copyMap(maps.Clone(event.Record))
+		ev.OldValue = cloneValue(event.OldValue)
+		ev.NewValue = cloneValue(event.NewValue)
+		if !sub.send(ev) {
+			self.unsubscribe(sub)
+		}
+	}
+}
+
+// send 按策略投递事件,需要断开订阅者时返回 false
+func (self *tSubscriber) send(event TChangeEvent) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.closed {
+		return true
+	}
+
+	switch self.policy {
+	case SlowConsumerBlock:
+		select {
+		case self.ch <- event:
+		case <-self.ctx.Done():
+		}
+	case SlowConsumerDisconnect:
+		select {
+		case self.ch <- event:
+		default:
+			return false
+		}
+	default:
+		select {
+		case self.ch <- event:
+		default:
+		}
+	}
+	return true
+}
+
+func (self *tSubscriber) close() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if !self.closed {
+		self.closed = true
+		close(self.ch)
+		close(self.done)
+	}
+}
@@ -0,0 +1,114 @@
+package dataset
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDatasetSubscribe(t *testing.T) {
+	ds := newFixture(viewFixture)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	all := ds.Subscribe(ctx, nil)
+	updates := ds.Subscribe(ctx, func(ev TChangeEvent) bool {
+		return ev.Kind == ChangeUpdate
+	})
+
+	ds.NewRecord(map[string]any{"id": 4, "cat": "B"})
+	ds.Data[0].SetByField("cat", "Z")
+	ds.Delete(1)
+
+	want := []struct {
+		kind TChangeKind
+		key  any
+	}{{ChangeInsert, 4}, {ChangeUpdate, 1}, {ChangeDelete, 2}}
+	for _, w := range want {
+		ev := <-all
+		if ev.Kind != w.kind || ev.Key != w.key {
+			t.Fatalf("unexpected event %v key=%v, want %v key=%v", ev.Kind, ev.Key, w.kind, w.key)
+		}
+	}
+
+	ev := <-updates
+	if ev.Field != "cat" || ev.OldValue != "A" || ev.NewValue != "Z" || ev.Record["cat"] != "Z" {
+		t.Fatalf("unexpected update event: %+v", ev)
+	}
+	select {
+	case ev := <-updates:
+		t.Fatalf("filtered subscriber received %v", ev.Kind)
+	default:
+	}
+
+	// 取消后通道关闭
+	cancel()
+	if !waitClosed(all) || !waitClosed(updates) {
+		t.Fatal("channels should be closed after the context is cancelled")
+	}
+	ds.Data[0].SetByField("cat", "Y") // 无订阅者时不阻塞
+
+	// ctx 为 nil 时订阅一直有效
+	events := ds.Subscribe(nil, nil)
+	ds.Data[0].SetByField("cat", "X")
+	if ev := <-events; ev.Kind != ChangeUpdate || ev.NewValue != "X" {
+		t.Fatalf("unexpected event with nil ctx: %+v", ev)
+	}
+}
+
+func TestDatasetSubscribePolicy(t *testing.T) {
+	ds := newFixture(viewFixture)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	drop := ds.Subscribe(ctx, nil, WithSubscribeBuffer(1))
+	disconnect := ds.Subscribe(ctx, nil, WithSubscribeBuffer(1), WithSlowConsumer(SlowConsumerDisconnect))
+
+	ds.Data[0].SetByField("cat", "X")
+	ds.Data[0].SetByField("cat", "Y")
+
+	if ev := <-drop; ev.NewValue != "X" {
+		t.Fatalf("drop policy should keep the first event, got %v", ev.NewValue)
+	}
+	select {
+	case <-drop:
+		t.Fatal("drop policy should discard events when the buffer is full")
+	default:
+	}
+
+	<-disconnect
+	if !waitClosed(disconnect) {
+		t.Fatal("disconnect policy should close a slow subscriber")
+	}
+
+	// 阻塞策略:修改等待订阅者接收
+	block := ds.Subscribe(ctx, nil, WithSubscribeBuffer(0), WithSlowConsumer(SlowConsumerBlock))
+	done := make(chan struct{})
+	go func() {
+		ds.Data[1].SetByField("cat", "Z")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("block policy should wait for the subscriber")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if ev := <-block; ev.NewValue != "Z" {
+		t.Fatalf("unexpected event %v", ev.NewValue)
+	}
+	<-done
+}
+
+func waitClosed(ch <-chan TChangeEvent) bool {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
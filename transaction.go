@@ -0,0 +1,242 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+var (
+	// ErrRecordNotFound 事务中按主键编辑/删除的记录在提交时不存在
+	ErrRecordNotFound = errors.New("record not found")
+	// ErrWriteRejected 写入被 Before* 钩子否决或未通过校验规则
+	ErrWriteRejected = errors.New("write rejected")
+)
+
+type (
+	// Tx 事务,由 Transaction 传给回调,只在回调内有效。
+	// 各方法只暂存修改,回调返回 nil 后才按暂存顺序应用到数据集。
+	Tx struct {
+		ops  []tTxOp
+		done bool
+	}
+
+	tTxOpKind uint8
+
+	tTxOp struct {
+		kind   tTxOpKind
+		key    string
+		field  string
+		record map[string]any
+	}
+)
+
+const (
+	txInsert tTxOpKind = iota
+	txEdit
+	txDelete
+	txAddField
+)
+
+// Transaction 在事务中批量修改数据集:fn 中经 tx 暂存的插入、编辑、删除与新增字段
+// 在 fn 返回 nil 后一次性提交,fn 返回错误或 panic 时全部丢弃,数据集保持不变。
+//
+// 提交时各项修改按普通写入执行(触发 Before* 钩子与校验规则),任一项失败则撤销
+// 已应用的修改(含新增的字段)并返回错误;After* 钩子与 Subscribe 事件只在全部成功后触发。
+// 启用变更日志时,一次提交为一个可撤销的步骤。
+//
+// fn 执行期间不访问数据集,使用 TConcurrentDataSet.Transaction 时写锁只在提交时持有。
+// 在视图上调用时修改应用于其根数据集。
+func (self *TDataSet) Transaction(fn func(tx *Tx) error) error {
+	if self.IsFrozen() {
+		return ErrFrozen
+	}
+
+	tx, err := stageTx(fn)
+	if err != nil {
+		return err
+	}
+	return self.owner().commitTx(tx)
+}
+
+// NewRecord 暂存插入一条记录,同 TDataSet.NewRecord
+func (self *Tx) NewRecord(record map[string]any) {
+	if record != nil {
+		self.stage(tTxOp{kind: txInsert, record: copyMap(maps.Clone(record))})
+	}
+}
+
+// AppendRecord 暂存插入记录,暂存的是记录当前值的副本
+func (self *Tx) AppendRecord(records ...*TRecordSet) {
+	for _, rec := range records {
+		if rec = rec.latest(); rec == nil {
+			continue
+		}
+
+		m := make(map[string]any)
+		for field := range rec.getFieldsIndex() {
+			m[field] = cloneValue(rec.GetByField(field))
+		}
+		self.stage(tTxOp{kind: txInsert, record: m})
+	}
+}
+
+// EditRecord 暂存以 record 中的字段值更新主键值为 key 的记录,同 TDataSet.EditRecord
+func (self *Tx) EditRecord(key string, record map[string]any) {
+	if len(record) > 0 {
+		self.stage(tTxOp{kind: txEdit, key: key, record: copyMap(maps.Clone(record))})
+	}
+}
+
+// DeleteRecord 暂存删除主键值为 key 的记录,同 TDataSet.DeleteRecord
+func (self *Tx) DeleteRecord(key string) {
+	self.stage(tTxOp{kind: txDelete, key: key})
+}
+
+// AddField 暂存新增字段
+func (self *Tx) AddField(name string) {
+	if name != "" {
+		self.stage(tTxOp{kind: txAddField, field: name})
+	}
+}
+
+func (self *Tx) stage(op tTxOp) {
+	if self != nil && !self.done {
+		self.ops = append(self.ops, op)
+	}
+}
+
+// stageTx 执行 fn 收集暂存的修改,fn 返回后 tx 不再接受修改
+func stageTx(fn func(tx *Tx) error) (*Tx, error) {
+	tx := &Tx{}
+	defer func() { tx.done = true }()
+
+	if fn != nil {
+		if err := fn(tx); err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// commitTx 按顺序应用暂存的修改,失败或 panic 时经变更日志撤销
+func (self *TDataSet) commitTx(tx *Tx) error {
+	if self.IsFrozen() {
+		return ErrFrozen
+	}
+	if len(tx.ops) == 0 {
+		return nil
+	}
+
+	return self.atomic(func() error {
+		for _, op := range tx.ops {
+			if err := self.applyTxOp(op); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// atomic 执行 fn,fn 返回错误或 panic 时经变更日志撤销其修改与新增字段,
+// After* 钩子与事件在成功后才触发
+func (self *TDataSet) atomic(fn func() error) (err error) {
+	hooks := self.ensureHooks()
+	temp := hooks.log == nil // 未启用变更日志时临时启用,用于回滚
+	if temp {
+		hooks.log = &tChangeLog{}
+	}
+	log := hooks.log
+	fieldCount := len(self.Fields())
+
+	log.begin()
+	start := len(log.current.changes)
+	held := len(hooks.held)
+	hooks.holding++
+
+	committed := false
+	defer func() {
+		hooks.holding--
+		if !committed {
+			changes := slices.Clone(log.current.changes[start:])
+			log.current.changes = log.current.changes[:start]
+			self.replay(&tChangeStep{changes: changes}, true)
+			self.truncateFields(fieldCount)
+			hooks.held = hooks.held[:held]
+		}
+		log.end()
+		if temp {
+			hooks.log = nil
+		}
+
+		// 嵌套在其他提交中时由外层统一触发
+		var fns []func()
+		if hooks.holding == 0 {
+			fns, hooks.held = hooks.held, nil
+		}
+		for _, fn := range fns {
+			fn()
+		}
+	}()
+
+	if err = fn(); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func (self *TDataSet) applyTxOp(op tTxOp) error {
+	switch op.kind {
+	case txInsert:
+		return self.NewRecord(op.record)
+
+	case txEdit:
+		idx := self.indexOfKey(op.key)
+		if idx < 0 {
+			return fmt.Errorf("edit record %q: %w", op.key, ErrRecordNotFound)
+		}
+		rec := self.Data[idx]
+		for _, field := range slices.Sorted(maps.Keys(op.record)) {
+			if !rec.SetByField(field, op.record[field]) {
+				return fmt.Errorf("edit record %q field < %s >: %w", op.key, field, ErrWriteRejected)
+			}
+		}
+
+	case txDelete:
+		idx := self.indexOfKey(op.key)
+		if idx < 0 {
+			return fmt.Errorf("delete record %q: %w", op.key, ErrRecordNotFound)
+		}
+		if !self.Delete(idx) {
+			return fmt.Errorf("delete record %q: %w", op.key, ErrWriteRejected)
+		}
+
+	case txAddField:
+		if self.AddField(op.field) < 0 {
+			return fmt.Errorf("add field < %s >: too many fields", op.field)
+		}
+	}
+	return nil
+}
+
+// truncateFields 移除第 count 个之后新增的字段
+func (self *TDataSet) truncateFields(count int) {
+	if self.parent != nil {
+		self.parent.truncateFields(count)
+		self.syncView()
+		return
+	}
+	self.Lock()
+	defer self.Unlock()
+
+	if count >= len(self.fields) {
+		return
+	}
+	for _, name := range self.fields[count:] {
+		delete(self.fieldsIndex, name)
+	}
+	self.fields = self.fields[:count]
+	self.FieldCount = count
+}
@@ -0,0 +1,145 @@
+package dataset
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestDatasetTransaction(t *testing.T) {
+	ds := newFixture(viewFixture)
+	var events []string
+	ds.AfterInsert(func(rec *TRecordSet) { events = append(events, "insert") })
+	ds.AfterDelete(func(rec *TRecordSet) { events = append(events, "delete") })
+
+	err := ds.Transaction(func(tx *Tx) error {
+		tx.AddField("note")
+		tx.NewRecord(map[string]any{"id": 4, "cat": "D"})
+		tx.EditRecord("1", map[string]any{"cat": "Z", "note": "edited"})
+		tx.DeleteRecord("2")
+		if ds.Count() != 3 || ds.HasField("note") || len(events) > 0 {
+			t.Fatal("staged changes should not be visible before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if datasetIds(ds) != "[1 3 4]" || ds.Data[0].GetByField("note") != "edited" || ds.Data[0].GetByField("cat") != "Z" {
+		t.Fatalf("unexpected data after commit %s", datasetIds(ds))
+	}
+	if len(events) != 2 {
+		t.Fatalf("after hooks should fire once committed, got %v", events)
+	}
+}
+
+func TestDatasetTransactionRollback(t *testing.T) {
+	ds := newFixture(viewFixture)
+	ds.BeforeDelete(func(rec *TRecordSet) error {
+		if rec.GetByField("id") == 3 {
+			return errors.New("record 3 is protected")
+		}
+		return nil
+	})
+	var events int
+	ds.AfterUpdate(func(rec *TRecordSet, field string, oldValue, newValue any) { events++ })
+
+	// 回调返回错误:不应用任何修改
+	boom := errors.New("boom")
+	if err := ds.Transaction(func(tx *Tx) error {
+		tx.NewRecord(map[string]any{"id": 4})
+		return boom
+	}); !errors.Is(err, boom) || ds.Count() != 3 {
+		t.Fatal("error from fn should discard staged changes")
+	}
+
+	// 提交中途失败:撤销已应用的修改与新增字段
+	err := ds.Transaction(func(tx *Tx) error {
+		tx.NewRecord(map[string]any{"id": 4, "extra": true})
+		tx.EditRecord("1", map[string]any{"cat": "Z"})
+		tx.DeleteRecord("2")
+		tx.DeleteRecord("3")
+		return nil
+	})
+	if !errors.Is(err, ErrWriteRejected) {
+		t.Fatalf("expected ErrWriteRejected, got %v", err)
+	}
+	if datasetIds(ds) != "[1 2 3]" || ds.Data[0].GetByField("cat") != "A" || ds.Data[1].index != 1 {
+		t.Fatalf("failed commit should be rolled back, got %s", datasetIds(ds))
+	}
+	if ds.HasField("extra") || events != 0 {
+		t.Fatal("rollback should remove added fields and suppress after hooks")
+	}
+	if ds.RecordByKey(3, "id") == nil || ds.Count() != 3 || ds.Position() != 2 {
+		t.Fatalf("rollback should keep the cursor inside the records, got position %d count %d", ds.Position(), ds.Count())
+	}
+
+	if err := ds.Transaction(func(tx *Tx) error {
+		tx.EditRecord("9", map[string]any{"cat": "Z"})
+		return nil
+	}); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("expected ErrRecordNotFound, got %v", err)
+	}
+
+	// panic:丢弃修改并继续传播
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic should propagate")
+			}
+		}()
+		ds.Transaction(func(tx *Tx) error {
+			tx.DeleteRecord("1")
+			panic("boom")
+		})
+	}()
+	if ds.Count() != 3 {
+		t.Fatal("panic should discard staged changes")
+	}
+}
+
+func TestDatasetTransactionChangeLog(t *testing.T) {
+	ds := newFixture(viewFixture, WithChangeLog(0))
+	ds.AcceptChanges()
+
+	ds.Transaction(func(tx *Tx) error {
+		tx.NewRecord(map[string]any{"id": 4})
+		tx.DeleteRecord("1")
+		return nil
+	})
+	if !ds.Undo() || datasetIds(ds) != "[1 2 3]" || ds.CanUndo() {
+		t.Fatal("a commit should be a single undo step")
+	}
+
+	ds.Freeze()
+	if err := ds.Transaction(func(tx *Tx) error { return nil }); !errors.Is(err, ErrFrozen) {
+		t.Fatal("transaction on a frozen dataset should fail")
+	}
+}
+
+func TestConcurrentDataSetTransaction(t *testing.T) {
+	cds := NewConcurrent(newFixture(viewFixture))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			cds.Transaction(func(tx *Tx) error {
+				tx.NewRecord(map[string]any{"id": 100 + i, "cat": "T"})
+				tx.NewRecord(map[string]any{"id": 200 + i, "cat": "T"})
+				return nil
+			})
+		}(i)
+		go func() {
+			defer wg.Done()
+			if n := cds.Count(); n%2 == 0 {
+				t.Errorf("readers should never see a partial commit, count=%d", n)
+			}
+		}()
+	}
+	wg.Wait()
+	if cds.Count() != 19 {
+		t.Fatalf("expected 19 records, got %d", cds.Count())
+	}
+}
@@ -0,0 +1,270 @@
+package dataset
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/volts-dev/utils"
+)
+
+type (
+	// TFieldRule 字段校验规则,值不合法时返回错误,错误信息即报告中的 Message。
+	// 自定义规则直接使用 func(any) error 即可。
+	TFieldRule func(value any) error
+
+	// TRecordRule 跨字段校验规则,如 date_end >= date_start
+	TRecordRule func(rec *TRecordSet) error
+
+	// TValidationError 一条校验失败信息
+	TValidationError struct {
+		Index   int    `json:"index"` // 记录位置,插入时为将要插入的位置
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+
+	// TValidationErrors 校验报告,可直接序列化为 JSON 数组;同时实现 error,
+	// 插入被拒绝时 AppendRecord 返回该类型,可用 errors.As 取出。
+	TValidationErrors []TValidationError
+
+	tRule struct {
+		field  string
+		check  TFieldRule
+		record TRecordRule
+	}
+)
+
+// AddRule 为字段添加校验规则,按添加顺序执行,同一字段的规则在首次失败后不再继续。
+// 除 Required 外,内置规则对 NULL 值(nil/空白)不做校验。
+//
+// 规则默认在写入时执行:AppendRecord/NewRecord 返回 TValidationErrors,
+// SetByField 返回 false;使用 WithDeferredValidation 时仅在 Validate 时执行。
+func (self *TDataSet) AddRule(field string, rules ...TFieldRule) {
+	root := self.owner()
+	for _, rule := range rules {
+		if field != "" && rule != nil {
+			root.rules = append(root.rules, tRule{field: field, check: rule})
+		}
+	}
+}
+
+// AddRecordRule 添加跨字段校验规则,失败信息报告在 field 上
+func (self *TDataSet) AddRecordRule(field string, rule TRecordRule) {
+	if rule == nil {
+		return
+	}
+	root := self.owner()
+	root.rules = append(root.rules, tRule{field: field, record: rule})
+}
+
+// Validate 按所有规则校验全部记录,全部合法时返回 nil
+func (self *TDataSet) Validate() TValidationErrors {
+	if self == nil {
+		return nil
+	}
+
+	var res TValidationErrors
+	self.scan(func(i int, rec *TRecordSet) error {
+		res = append(res, self.validateRecord(rec, i)...)
+		return nil
+	})
+	return res
+}
+
+// validateRecord 按规则校验一条记录
+func (self *TDataSet) validateRecord(rec *TRecordSet, index int) TValidationErrors {
+	var (
+		res    TValidationErrors
+		failed map[string]bool
+	)
+	for _, rule := range self.owner().rules {
+		if failed[rule.field] {
+			continue
+		}
+
+		var err error
+		if rule.check != nil {
+			err = rule.check(rec.GetByField(rule.field))
+		} else {
+			err = rule.record(rec)
+		}
+		if err != nil {
+			res = append(res, TValidationError{Index: index, Field: rule.field, Message: err.Error()})
+			if failed == nil {
+				failed = make(map[string]bool)
+			}
+			failed[rule.field] = true
+		}
+	}
+	return res
+}
+
+// validateOnWrite 是否在写入时执行校验
+func (self *TDataSet) validateOnWrite() bool {
+	root := self.owner()
+	return len(root.rules) > 0 && !root.config.deferValidation
+}
+
+// validateUpdate 校验 rec 的 field 写入 value 后的记录
+func (self *TDataSet) validateUpdate(rec *TRecordSet, field string, value any) TValidationErrors {
+	shell := &TRecordSet{
+		dataset:     self,
+		index:       rec.index,
+		values:      rec.cloneValues(),
+		fieldsCount: rec.fieldsCount,
+	}
+	if idx, has := self.fieldsIndex[field]; has {
+		shell.set(idx, value, false)
+		return self.validateRecord(shell, rec.index)
+	}
+
+	// 新字段尚未加入数据集,只校验其字段规则
+	var res TValidationErrors
+	for _, rule := range self.rules {
+		if rule.field != field || rule.check == nil {
+			continue
+		}
+		if err := rule.check(value); err != nil {
+			res = append(res, TValidationError{Index: rec.index, Field: field, Message: err.Error()})
+			break
+		}
+	}
+	return res
+}
+
+func (self TValidationErrors) Error() string {
+	if len(self) == 0 {
+		return ""
+	}
+
+	msgs := make([]string, 0, len(self))
+	for _, e := range self {
+		msgs = append(msgs, fmt.Sprintf("record %d field < %s >: %s", e.Index, e.Field, e.Message))
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Required 值不能为 NULL(nil/空白)
+func Required() TFieldRule {
+	return func(value any) error {
+		if isNull(value) {
+			return fmt.Errorf("is required")
+		}
+		return nil
+	}
+}
+
+// Min 值须大于等于 min,比较规则同 SortBy
+func Min(min any) TFieldRule {
+	return func(value any) error {
+		if !isNull(value) && compareValue(value, min) < 0 {
+			return fmt.Errorf("must be greater than or equal to %v", min)
+		}
+		return nil
+	}
+}
+
+// Max 值须小于等于 max,比较规则同 SortBy
+func Max(max any) TFieldRule {
+	return func(value any) error {
+		if !isNull(value) && compareValue(value, max) > 0 {
+			return fmt.Errorf("must be less than or equal to %v", max)
+		}
+		return nil
+	}
+}
+
+// Length 字符串(按字符)或切片/map 的长度须在 [min, max] 内,max < 0 表示不限
+func Length(min, max int) TFieldRule {
+	return func(value any) error {
+		if isNull(value) {
+			return nil
+		}
+
+		var n int
+		switch v := value.(type) {
+		case string:
+			n = utf8.RuneCountInString(v)
+		case []byte:
+			n = len(v)
+		default:
+			rv := reflect.ValueOf(value)
+			switch rv.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				n = rv.Len()
+			default:
+				n = utf8.RuneCountInString(utils.ToString(value))
+			}
+		}
+
+		switch {
+		case n < min && max < 0:
+			return fmt.Errorf("length must be at least %d", min)
+		case n < min || (max >= 0 && n > max):
+			return fmt.Errorf("length must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// Match 值的字符串形式须匹配正则表达式,表达式无效时 panic(同 regexp.MustCompile)
+func Match(expr string) TFieldRule {
+	re := regexp.MustCompile(expr)
+	return func(value any) error {
+		if !isNull(value) && !re.MatchString(utils.ToString(value)) {
+			return fmt.Errorf("does not match pattern %s", expr)
+		}
+		return nil
+	}
+}
+
+// OneOf 值须为 values 之一,数值按数值比较
+func OneOf(values ...any) TFieldRule {
+	return func(value any) error {
+		if isNull(value) {
+			return nil
+		}
+		for _, v := range values {
+			if compareValue(value, v) == 0 {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %v", values)
+	}
+}
+
+// CompareFields 跨字段比较规则,如 CompareFields("date_end", ">=", "date_start")。
+// op 为 ==、!=、<、<=、>、>= 之一;任一字段为 NULL 时不做校验。
+func CompareFields(field, op, other string) TRecordRule {
+	return func(rec *TRecordSet) error {
+		a, b := rec.GetByField(field), rec.GetByField(other)
+		if isNull(a) || isNull(b) {
+			return nil
+		}
+
+		c := compareValue(a, b)
+		var ok bool
+		switch op {
+		case "==", "=":
+			ok = c == 0
+		case "!=", "<>":
+			ok = c != 0
+		case "<":
+			ok = c < 0
+		case "<=":
+			ok = c <= 0
+		case ">":
+			ok = c > 0
+		case ">=":
+			ok = c >= 0
+		default:
+			return fmt.Errorf("unknown operator %q", op)
+		}
+		if !ok {
+			return fmt.Errorf("must be %s %s", op, other)
+		}
+		return nil
+	}
+}
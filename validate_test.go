@@ -0,0 +1,68 @@
+package dataset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDatasetValidateOnWrite(t *testing.T) {
+	ds := newFixture(orderRulesFixture)
+
+	err := ds.NewRecord(map[string]any{"name": "a", "qty": 0, "state": "x", "code": "XXX", "date_start": 5, "date_end": 3})
+	var report TValidationErrors
+	if !errors.As(err, &report) || ds.Count() != 0 {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	got := map[string]string{}
+	for _, e := range report {
+		got[e.Field] = e.Message
+	}
+	want := map[string]string{
+		"name":     "length must be between 2 and 10",
+		"qty":      "must be greater than or equal to 1",
+		"state":    "must be one of [draft done]",
+		"code":     "is reserved",
+		"date_end": "must be >= date_start",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("unexpected report:\n%v\nwant:\n%v", got, want)
+	}
+
+	if err := ds.NewRecord(map[string]any{"name": "order", "qty": 5, "state": "draft", "date_start": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := ds.Data[0]
+	if rec.SetByField("qty", 101) || rec.SetByField("date_end", 0) || rec.SetByField("name", nil) {
+		t.Fatal("invalid updates should be rejected")
+	}
+	if !rec.SetByField("qty", 100) || !rec.SetByField("date_end", 2) {
+		t.Fatal("valid updates should be accepted")
+	}
+	if rec.GetByField("qty") != 100 || ds.Validate() != nil {
+		t.Fatal("dataset should be valid")
+	}
+}
+
+func TestDatasetValidateDeferred(t *testing.T) {
+	ds := newFixture(orderRulesFixture, WithDeferredValidation())
+	ds.NewRecord(map[string]any{"name": "order", "qty": 5})
+	ds.NewRecord(map[string]any{"qty": 500, "code": "abc"})
+	if ds.Count() != 2 {
+		t.Fatal("deferred validation must not reject writes")
+	}
+
+	report := ds.Validate()
+	js, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"index":1,"field":"name","message":"is required"},` +
+		`{"index":1,"field":"qty","message":"must be less than or equal to 100"},` +
+		`{"index":1,"field":"code","message":"does not match pattern ^[A-Z]{3}$"}]`
+	if string(js) != want {
+		t.Fatalf("unexpected report %s", js)
+	}
+}
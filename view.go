@@ -0,0 +1,198 @@
+package dataset
+
+import (
+	"maps"
+	"slices"
+)
+
+// 派生数据集的两种语义:
+//   - 视图(默认):引用父数据集的记录,不复制也不改变记录归属(rec.dataset/rec.index 仍指向父数据集)。
+//     通过视图修改记录即修改父数据集中的记录;视图的字段结构与父数据集共享,
+//     对视图新增字段/计算字段/字段类型等结构变更会作用到父数据集;
+//     向视图追加的记录同时追加到父数据集。视图的 Delete/Clear 只从视图中移除记录。
+//   - 复制(WithCopyOnDerive 或 Clone(true)):深复制记录与字段结构,与源数据集完全独立。
+
+// Clone 复制整个数据集。deep 为 false 时返回引用全部记录的视图,
+// 为 true 时返回包含记录深复制的独立数据集(嵌套的 map/slice 值同样复制)。
+func (self *TDataSet) Clone(deep bool) *TDataSet {
+	if self == nil {
+		return nil
+	}
+
+	var res *TDataSet
+	if deep {
+		res = self.cloneSchema()
+	} else {
+		res = self.newView()
+	}
+	res.Name = self.Name
+
+	self.scan(func(_ int, rec *TRecordSet) error {
+		res.addDerived(rec)
+		return nil
+	})
+	res.position.Store(self.position.Load())
+
+	return res
+}
+
+// IsView 是否为引用其他数据集记录的视图
+func (self *TDataSet) IsView() bool {
+	return self != nil && self.parent != nil
+}
+
+// owner 返回记录实际归属(决定字段结构)的数据集,视图返回其根数据集
+func (self *TDataSet) owner() *TDataSet {
+	if self.parent != nil {
+		return self.parent
+	}
+	return self
+}
+
+// deriveDataSet 按配置创建 Filter/GroupBy 的结果数据集
+func (self *TDataSet) deriveDataSet() *TDataSet {
+	if self.config.copyOnDerive {
+		return self.cloneSchema()
+	}
+	return self.newView()
+}
+
+// addDerived 将源数据集的记录加入派生数据集:视图引用记录,复制则深复制记录
+func (self *TDataSet) addDerived(rec *TRecordSet) {
+	self.ensureDataOwned()
+	if self.parent != nil {
+		self.Data = append(self.Data, rec)
+	} else {
+		self.Data = append(self.Data, rec.clone(self, len(self.Data), true))
+	}
+	self.position.Store(int32(len(self.Data) - 1))
+}
+
+// newView 创建 self 的空视图,视图的父数据集总是根数据集
+func (self *TDataSet) newView() *TDataSet {
+	root := self.owner()
+	view := NewDataSet(WithFieldFormater(self))
+	view.parent = root
+	view.KeyField = self.KeyField
+	view.classic = self.classic
+	view.config.checkFields = self.config.checkFields
+	view.config.copyOnDerive = self.config.copyOnDerive
+	view.syncView()
+	return view
+}
+
+// syncView 从根数据集同步共享的字段结构
+func (self *TDataSet) syncView() {
+	root := self.parent
+	if root == nil {
+		return
+	}
+
+	self.fieldsIndex = root.fieldsIndex
+	self.fields = root.fields
+	self.FieldCount = root.FieldCount
+	self.calcFields = root.calcFields
+	self.calcDeps = root.calcDeps
+	self.fieldKinds = root.fieldKinds
+}
+
+// cloneSchema 创建字段结构相同但不含记录的独立数据集
+func (self *TDataSet) cloneSchema() *TDataSet {
+	src := self.owner()
+	res := NewDataSet(WithFieldFormater(self))
+	res.KeyField = self.KeyField
+	res.classic = self.classic
+	res.config.checkFields = self.config.checkFields
+	res.config.copyOnDerive = self.config.copyOnDerive
+
+	if src.fieldsIndex != nil {
+		res.fieldsIndex = maps.Clone(src.fieldsIndex)
+	}
+	res.fields = slices.Clone(src.fields)
+	res.FieldCount = src.FieldCount
+	if src.calcFields != nil {
+		res.calcFields = maps.Clone(src.calcFields)
+		res.calcDeps = make(map[string][]string, len(src.calcDeps))
+		for dep, names := range src.calcDeps {
+			res.calcDeps[dep] = slices.Clone(names)
+		}
+	}
+	if src.fieldKinds != nil {
+		res.fieldKinds = maps.Clone(src.fieldKinds)
+	}
+	res.rules = slices.Clone(src.rules)
+	if src.defaults != nil {
+		res.defaults = maps.Clone(src.defaults)
+	}
+	res.keySequence = src.keySequence
+	res.config.deferValidation = src.config.deferValidation
+	if src.store != nil {
+		res.store = &tColumnStore{}
+	}
+	return res
+}
+
+// clone 复制记录并归属到 owner,owner 的字段结构须与记录当前所属数据集一致
+func (self *TRecordSet) clone(owner *TDataSet, index int, deep bool) *TRecordSet {
+	self = self.latest()
+	rec := NewRecordSet()
+	rec.dataset = owner
+	rec.gen = owner.gen
+	rec.index = index
+	rec.fieldsCount = self.fieldsCount
+	rec.fieldsIndex = nil // 使用 owner 的字段索引
+
+	values := self.cloneValues()
+	rec.ClassicValues = make([]any, len(self.ClassicValues))
+	for i, v := range values {
+		if deep {
+			values[i] = cloneValue(v)
+		}
+	}
+	if owner != nil {
+		owner.setValues(rec, values)
+	} else {
+		rec.values = values
+	}
+	for i, v := range self.ClassicValues {
+		if deep {
+			v = cloneValue(v)
+		}
+		rec.ClassicValues[i] = v
+	}
+
+	return rec
+}
+
+// cloneValue 深复制嵌套的复合值(关系字段内嵌的子记录等),标量原样返回
+func cloneValue(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(x))
+		for k, item := range x {
+			m[k] = cloneValue(item)
+		}
+		return m
+	case []any:
+		s := make([]any, len(x))
+		for i, item := range x {
+			s[i] = cloneValue(item)
+		}
+		return s
+	case []map[string]any:
+		s := make([]map[string]any, len(x))
+		for i, item := range x {
+			s[i] = cloneValue(item).(map[string]any)
+		}
+		return s
+	case []byte:
+		return slices.Clone(x)
+	case []string:
+		return slices.Clone(x)
+	case []int:
+		return slices.Clone(x)
+	case []int64:
+		return slices.Clone(x)
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDatasetFilterView(t *testing.T) {
+	ds := newFixture(viewFixture)
+	view := ds.Filter("cat", []any{"A"})
+	if !view.IsView() || view.Count() != 2 {
+		t.Fatalf("expected a view with 2 records, got view=%v count=%d", view.IsView(), view.Count())
+	}
+
+	// 视图不改变记录归属
+	if ds.Data[2].dataset != ds || ds.Data[2].index != 2 {
+		t.Fatal("filter must not re-parent records of the source dataset")
+	}
+
+	// 在源数据集记录上新增字段,字段归属源数据集,视图可见
+	ds.Data[0].SetByField("note", "hello")
+	if !ds.HasField("note") || !view.HasField("note") {
+		t.Fatal("new field should belong to the source dataset and be visible in the view")
+	}
+	if view.Data[0].GetByField("note") != "hello" {
+		t.Fatal("view should see edits of shared records")
+	}
+	if !slices.Contains(view.Fields(), "note") {
+		t.Fatalf("view fields should follow the source dataset, got %v", view.Fields())
+	}
+	if melted, err := view.Melt([]string{"id"}, nil); err != nil || melted.Count() != 2*len(ds.Fields())-2 {
+		t.Fatalf("melt on a view should see fields of the source dataset, got %v", err)
+	}
+	ds.BeforeUpdate(func(rec *TRecordSet, field string, oldValue, newValue any) error {
+		if field == "seq" {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+	if err := view.Window(nil, nil).RowNumber("seq").Apply(); err == nil || !ds.HasField("note") || ds.HasField("seq") {
+		t.Fatal("a failed window on a view should only drop the columns it added")
+	}
+
+	// 视图中新增的记录同时进入源数据集
+	view.NewRecord(map[string]any{"id": 4, "cat": "A"})
+	if ds.Count() != 4 || view.Count() != 3 {
+		t.Fatalf("expected append through view to reach source, got %d/%d", ds.Count(), view.Count())
+	}
+
+	// 视图删除只影响视图
+	view.Delete(0)
+	if ds.Count() != 4 || view.Count() != 2 {
+		t.Fatalf("delete on a view must not touch the source, got %d/%d", ds.Count(), view.Count())
+	}
+}
+
+func TestDatasetGroupByCopy(t *testing.T) {
+	ds := newFixture(viewFixture, WithCopyOnDerive())
+	groups := ds.GroupBy("cat")
+	grp := groups["A"]
+	if grp.IsView() || grp.Count() != 2 {
+		t.Fatalf("expected a copied group with 2 records")
+	}
+
+	grp.Data[0].SetByField("cat", "C")
+	grp.Data[0].GetByField("tags").([]any)[0] = "changed"
+	if ds.Data[0].GetByField("cat") != "A" || ds.Data[0].GetByField("tags").([]any)[0] != "x" {
+		t.Fatal("copied group must be independent from the source")
+	}
+
+	grp.Data[0].SetByField("extra", 1)
+	if ds.HasField("extra") {
+		t.Fatal("schema changes on a copy must not reach the source")
+	}
+}
+
+func TestDatasetAppendForeignRecord(t *testing.T) {
+	src := newFixture(viewFixture)
+	dst := NewDataSet()
+
+	rec := src.Data[1]
+	if err := dst.AppendRecord(rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.dataset != src || rec.index != 1 {
+		t.Fatal("AppendRecord must copy records owned by another dataset")
+	}
+	if dst.Data[0] == rec || dst.Data[0].GetByField("id") != 2 {
+		t.Fatal("expected an owned copy in the destination")
+	}
+}
+
+func TestDatasetClone(t *testing.T) {
+	ds := newFixture(viewFixture)
+	ds.Name = "invoice"
+	ds.SetKeyField("id")
+	ds.AddCalcField("double_id", []string{"id"}, func(rec *TRecordSet) any {
+		return rec.FieldByName("id").AsInteger() * 2
+	})
+
+	deep := ds.Clone(true)
+	if deep.Name != "invoice" || deep.KeyField != "id" || deep.Count() != 3 {
+		t.Fatalf("unexpected deep clone %s/%s/%d", deep.Name, deep.KeyField, deep.Count())
+	}
+	if deep.RecordByKey(3).GetByField("double_id") != int64(6) {
+		t.Fatal("deep clone should keep calc fields and key lookups")
+	}
+	deep.Data[0].SetByField("id", 100)
+	if ds.Data[0].GetByField("id") != 1 {
+		t.Fatal("deep clone must be independent")
+	}
+
+	shallow := ds.Clone(false)
+	if !shallow.IsView() || shallow.Data[0] != ds.Data[0] {
+		t.Fatal("shallow clone should be a view sharing records")
+	}
+	shallow.Clear()
+	if ds.Count() != 3 || ds.Data[0].GetByField("id") != 1 {
+		t.Fatal("clearing a view must not affect the source")
+	}
+}
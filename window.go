@@ -0,0 +1,272 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+)
+
+type (
+	// TWindow 窗口函数构建器,由 TDataSet.Window 创建。
+	// 添加的每个窗口列在 Apply 时按分区、排序计算,并作为新字段写回每条记录:
+	//
+	//	err := ds.Window([]string{"partner_id"}, []string{"date", "id"}).
+	//		RowNumber("seq").
+	//		CumSum("balance", "amount").
+	//		RollingAvg("avg_3", "amount", 3).
+	//		Apply()
+	//
+	// 窗口计算不改变数据集本身的记录顺序。
+	TWindow struct {
+		dataset     *TDataSet
+		partitionBy []string
+		orders      []sortField
+		columns     []windowColumn
+		err         error // 构建时的首个参数错误,由 Apply 返回
+	}
+
+	windowColumn struct {
+		name string
+		// calc 计算一个已排序分区的列值,返回值与 rows 一一对应
+		calc func(rows []*TRecordSet) []any
+	}
+)
+
+// Window 创建窗口函数构建器。partitionBy 为空时整个数据集为一个分区;
+// orderBy 格式同 SortBy,如 "date desc",为空时按记录原有顺序。
+func (self *TDataSet) Window(partitionBy []string, orderBy []string) *TWindow {
+	return &TWindow{
+		dataset:     self,
+		partitionBy: partitionBy,
+		orders:      parseOrder(orderBy...),
+	}
+}
+
+func (self *TWindow) add(name string, calc func(rows []*TRecordSet) []any) *TWindow {
+	self.columns = append(self.columns, windowColumn{name: name, calc: calc})
+	return self
+}
+
+// RowNumber 分区内从 1 开始的行号
+func (self *TWindow) RowNumber(name string) *TWindow {
+	return self.add(name, func(rows []*TRecordSet) []any {
+		res := make([]any, len(rows))
+		for i := range rows {
+			res[i] = int64(i + 1)
+		}
+		return res
+	})
+}
+
+// Rank 排名,排序字段相同的行名次相同,其后名次跳跃(1,1,3)
+func (self *TWindow) Rank(name string) *TWindow {
+	return self.add(name, func(rows []*TRecordSet) []any {
+		return self.rank(rows, false)
+	})
+}
+
+// DenseRank 排名,排序字段相同的行名次相同,其后名次连续(1,1,2)
+func (self *TWindow) DenseRank(name string) *TWindow {
+	return self.add(name, func(rows []*TRecordSet) []any {
+		return self.rank(rows, true)
+	})
+}
+
+func (self *TWindow) rank(rows []*TRecordSet, dense bool) []any {
+	res := make([]any, len(rows))
+	var rank int64
+	for i, rec := range rows {
+		if i == 0 || compareRecords(rows[i-1], rec, self.orders) != 0 {
+			if dense {
+				rank++
+			} else {
+				rank = int64(i + 1)
+			}
+		}
+		res[i] = rank
+	}
+	return res
+}
+
+// Lag 分区内向前第 offset 行的 field 值,越界时为 nil
+func (self *TWindow) Lag(name, field string, offset int) *TWindow {
+	return self.shift(name, field, -offset)
+}
+
+// Lead 分区内向后第 offset 行的 field 值,越界时为 nil
+func (self *TWindow) Lead(name, field string, offset int) *TWindow {
+	return self.shift(name, field, offset)
+}
+
+func (self *TWindow) shift(name, field string, offset int) *TWindow {
+	return self.add(name, func(rows []*TRecordSet) []any {
+		res := make([]any, len(rows))
+		for i := range rows {
+			if j := i + offset; j >= 0 && j < len(rows) {
+				res[i] = rows[j].GetByField(field)
+			}
+		}
+		return res
+	})
+}
+
+// CumSum 分区内截至当前行的 field 累计和,NULL 按 0 处理;
+// 列中含 TDecimal 时结果为 TDecimal,否则为 float64
+func (self *TWindow) CumSum(name, field string) *TWindow {
+	return self.add(name, func(rows []*TRecordSet) []any {
+		values := fieldValues(rows, field)
+		res := make([]any, len(rows))
+		if hasDecimal(values) {
+			var sum TDecimal
+			for i, v := range values {
+				if d, err := NewDecimal(v); err == nil && !isNull(v) {
+					sum = sum.Add(d)
+				}
+				res[i] = sum
+			}
+			return res
+		}
+
+		var sum float64
+		for i, v := range values {
+			if n, ok := toFloat(v); ok && !isNull(v) {
+				sum += n
+			}
+			res[i] = sum
+		}
+		return res
+	})
+}
+
+// CumCount 分区内截至当前行 field 非空值的个数
+func (self *TWindow) CumCount(name, field string) *TWindow {
+	return self.add(name, func(rows []*TRecordSet) []any {
+		res := make([]any, len(rows))
+		var cnt int64
+		for i, rec := range rows {
+			if !isNull(rec.GetByField(field)) {
+				cnt++
+			}
+			res[i] = cnt
+		}
+		return res
+	})
+}
+
+// RollingSum 分区内当前行及之前共 size 行的 field 之和,不足 size 行时取已有的行。
+// size 须 >= 1,否则 Apply 返回错误
+func (self *TWindow) RollingSum(name, field string, size int) *TWindow {
+	return self.rolling(name, field, size, AggSum)
+}
+
+// RollingAvg 分区内当前行及之前共 size 行 field 非空值的平均值,全部为空时为 nil。
+// size 须 >= 1,否则 Apply 返回错误
+func (self *TWindow) RollingAvg(name, field string, size int) *TWindow {
+	return self.rolling(name, field, size, AggAvg)
+}
+
+func (self *TWindow) rolling(name, field string, size int, agg TAggregate) *TWindow {
+	if size < 1 {
+		if self.err == nil {
+			self.err = fmt.Errorf("invalid rolling window size %d for column < %s >", size, name)
+		}
+		return self
+	}
+	return self.add(name, func(rows []*TRecordSet) []any {
+		values := fieldValues(rows, field)
+		res := make([]any, len(rows))
+		for i := range values {
+			res[i] = agg(values[max(0, i-size+1) : i+1])
+		}
+		return res
+	})
+}
+
+// Apply 计算所有窗口列并写回记录。窗口参数无效、列名与计算字段冲突、字段数超限
+// 或写入被拒绝(如 BeforeUpdate 否决)时返回错误,此时不写入任何值也不新增列。
+func (self *TWindow) Apply() error {
+	if self.err != nil {
+		return self.err
+	}
+	ds := self.dataset
+	if ds == nil || len(self.columns) == 0 {
+		return nil
+	}
+	if ds.IsFrozen() {
+		return ErrFrozen
+	}
+
+	added := make(map[string]bool)
+	for _, col := range self.columns {
+		if col.name == "" || ds.IsCalcField(col.name) {
+			return fmt.Errorf("invalid window column name < %s >", col.name)
+		}
+		if !ds.HasField(col.name) {
+			added[col.name] = true
+		}
+	}
+	if len(ds.fieldsIndex)+len(added) > 255 {
+		return fmt.Errorf("can not add window columns: %d fields exceed the limit of 255", len(ds.fieldsIndex)+len(added))
+	}
+
+	// #分区,保持记录首次出现的顺序
+	var partitions [][]*TRecordSet
+	partIdx := make(map[string]int)
+	keyVals := make([]any, len(self.partitionBy))
+	for _, rec := range ds.Data {
+		for i, field := range self.partitionBy {
+			keyVals[i] = rec.GetByField(field)
+		}
+		key := groupKey(keyVals)
+		idx, has := partIdx[key]
+		if !has {
+			idx = len(partitions)
+			partIdx[key] = idx
+			partitions = append(partitions, nil)
+		}
+		partitions[idx] = append(partitions[idx], rec)
+	}
+
+	// #计算
+	results := make([]map[*TRecordSet]any, len(self.columns))
+	for c := range self.columns {
+		results[c] = make(map[*TRecordSet]any, len(ds.Data))
+	}
+	for _, rows := range partitions {
+		if len(self.orders) > 0 {
+			slices.SortStableFunc(rows, func(a, b *TRecordSet) int {
+				return compareRecords(a, b, self.orders)
+			})
+		}
+
+		for c, col := range self.columns {
+			for i, v := range col.calc(rows) {
+				results[c][rows[i]] = v
+			}
+		}
+	}
+
+	// #写回,任一写入失败时撤销已写入的值与新增的列
+	return ds.atomic(func() error {
+		for _, col := range self.columns {
+			if ds.AddField(col.name) == -1 {
+				return fmt.Errorf("can not add window column < %s >: too many fields", col.name)
+			}
+		}
+		for c, col := range self.columns {
+			for _, rec := range ds.Data {
+				if !rec.SetByField(col.name, results[c][rec]) {
+					return fmt.Errorf("write window column < %s >: %w", col.name, ErrWriteRejected)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func fieldValues(rows []*TRecordSet, field string) []any {
+	values := make([]any, len(rows))
+	for i, rec := range rows {
+		values[i] = rec.GetByField(field)
+	}
+	return values
+}
@@ -0,0 +1,116 @@
+package dataset
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDatasetWindow(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"id": 1, "partner": "A", "score": 10, "amount": 5.0},
+		map[string]any{"id": 2, "partner": "B", "score": 20, "amount": 1.0},
+		map[string]any{"id": 3, "partner": "A", "score": 30, "amount": 2.0},
+		map[string]any{"id": 4, "partner": "A", "score": 10, "amount": nil},
+		map[string]any{"id": 5, "partner": "A", "score": 40, "amount": 3.0},
+	))
+
+	err := ds.Window([]string{"partner"}, []string{"score"}).
+		RowNumber("seq").
+		Rank("rank").
+		DenseRank("dense").
+		Lag("prev_id", "id", 1).
+		Lead("next_id", "id", 1).
+		CumSum("balance", "amount").
+		CumCount("cnt", "amount").
+		RollingSum("sum_2", "amount", 2).
+		RollingAvg("avg_2", "amount", 2).
+		Apply()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 分区 A 排序后: id 1(10), 4(10), 3(30), 5(40)
+	want := map[int]map[string]any{
+		1: {"seq": int64(1), "rank": int64(1), "dense": int64(1), "prev_id": nil, "next_id": 4, "balance": 5.0, "cnt": int64(1), "sum_2": 5.0, "avg_2": 5.0},
+		4: {"seq": int64(2), "rank": int64(1), "dense": int64(1), "prev_id": 1, "next_id": 3, "balance": 5.0, "cnt": int64(1), "sum_2": 5.0, "avg_2": 5.0},
+		3: {"seq": int64(3), "rank": int64(3), "dense": int64(2), "prev_id": 4, "next_id": 5, "balance": 7.0, "cnt": int64(2), "sum_2": 2.0, "avg_2": 2.0},
+		5: {"seq": int64(4), "rank": int64(4), "dense": int64(3), "prev_id": 3, "next_id": nil, "balance": 10.0, "cnt": int64(3), "sum_2": 5.0, "avg_2": 2.5},
+		2: {"seq": int64(1), "rank": int64(1), "dense": int64(1), "prev_id": nil, "next_id": nil, "balance": 1.0, "cnt": int64(1), "sum_2": 1.0, "avg_2": 1.0},
+	}
+
+	for i, rec := range ds.Data {
+		id := rec.GetByField("id").(int)
+		if id != i+1 {
+			t.Fatalf("window must not reorder records, got id %d at %d", id, i)
+		}
+		for field, v := range want[id] {
+			if got := rec.GetByField(field); got != v {
+				t.Errorf("id %d field %s: expected %v, got %v", id, field, v, got)
+			}
+		}
+	}
+}
+
+func TestDatasetWindowDecimal(t *testing.T) {
+	ds := NewDataSet(
+		WithData(
+			map[string]any{"id": 1, "amount": "0.10"},
+			map[string]any{"id": 2, "amount": "0.20"},
+		),
+		WithFieldKind("amount", KindDecimal),
+	)
+
+	if err := ds.Window(nil, nil).CumSum("balance", "amount").Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := ds.Data[1].GetByField("balance").(TDecimal); !ok || v.String() != "0.30" {
+		t.Errorf("expected decimal balance 0.30, got %v", ds.Data[1].GetByField("balance"))
+	}
+}
+
+func TestDatasetWindowInvalidName(t *testing.T) {
+	ds := newFixture(calcFixture)
+	if err := ds.Window(nil, nil).RowNumber("amount").Apply(); err == nil {
+		t.Error("expected error for calc field name")
+	}
+}
+
+func TestDatasetWindowInvalidSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		ds := NewDataSet(WithData(
+			map[string]any{"id": 1, "v": 1},
+			map[string]any{"id": 2, "v": 2},
+		))
+		err := ds.Window(nil, nil).RowNumber("seq").RollingSum("r", "v", size).Apply()
+		if err == nil {
+			t.Fatalf("size %d: expected error", size)
+		}
+		if ds.HasField("seq") || ds.HasField("r") {
+			t.Fatalf("size %d: no column should be written on error", size)
+		}
+		if err := ds.Window(nil, nil).RollingAvg("r", "v", size).Apply(); err == nil {
+			t.Fatalf("size %d: expected error from RollingAvg", size)
+		}
+	}
+}
+
+func TestDatasetWindowRejected(t *testing.T) {
+	ds := NewDataSet(WithData(
+		map[string]any{"id": 1, "v": 1, "seq": "a"},
+		map[string]any{"id": 2, "v": 2, "seq": "b"},
+	))
+	ds.BeforeUpdate(func(rec *TRecordSet, field string, oldValue, newValue any) error {
+		if field == "total" && rec.GetByField("id") == 2 {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+
+	err := ds.Window(nil, nil).RowNumber("seq").CumSum("total", "v").Apply()
+	if !errors.Is(err, ErrWriteRejected) {
+		t.Fatalf("expected ErrWriteRejected, got %v", err)
+	}
+	if ds.HasField("total") || ds.Data[0].GetByField("seq") != "a" || ds.Data[1].GetByField("seq") != "b" {
+		t.Fatal("a rejected write should undo earlier columns and values")
+	}
+}